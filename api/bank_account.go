@@ -1,27 +1,41 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
+	"github.com/radugaf/simplebank/apierror"
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
 	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
 )
 
 type createBankAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	Nickname string `json:"nickname" binding:"max=50"`
+	// Product is the code of the account product, the default one earning no interest if empty
+	Product string `json:"product" binding:"max=50"`
 }
 
+// maxAccountNumberAttempts bounds the accounts created again because
+// their random account number was already in use
+const maxAccountNumberAttempts = 3
+
 type getBankAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
 type listBankAccountsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=1,max=10"`
+	pageRequest
+	Currency string `form:"currency" binding:"omitempty,currency"`
+}
+
+type listBankAccountsResponse struct {
+	BankAccounts []db.BankAccount `json:"bank_accounts"`
+	NextCursor   string           `json:"next_cursor"`
 }
 
 func (server *Server) createBankAccount(ctx *gin.Context) {
@@ -34,28 +48,71 @@ func (server *Server) createBankAccount(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	bankAccountArgs := db.CreateBankAccountParams{
-		Owner:    authPayload.Username,
-		Currency: req.Currency,
-		Balance:  0,
-	}
+	var bankAccount db.BankAccount
+	for attempt := 1; ; attempt++ {
+		accountNumber, err := tools.NewAccountNumber()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
 
-	bankAccount, err := server.store.CreateBankAccount(ctx, bankAccountArgs)
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		bankAccountArgs := db.CreateBankAccountParams{
+			Owner:         authPayload.Username,
+			Currency:      req.Currency,
+			Balance:       0,
+			Nickname:      req.Nickname,
+			AccountNumber: accountNumber,
+			Product:       db.NullString(req.Product),
+		}
+
+		bankAccount, err = server.store.CreateBankAccount(ctx, bankAccountArgs)
+		if err == nil {
+			break
 		}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		var dbErr *db.Error
+		if errors.As(err, &dbErr) && dbErr.Constraint == db.AccountNumberConstraint && attempt < maxAccountNumberAttempts {
+			continue
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, bankAccount)
+}
+
+type updateBankAccountRequest struct {
+	Nickname string `json:"nickname" binding:"max=50"`
+}
+
+// updateBankAccount renames a bank account of the authenticated user
+func (server *Server) updateBankAccount(ctx *gin.Context) {
+	var uri getBankAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateBankAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedBankAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	bankAccount, err := server.store.UpdateBankAccountNickname(ctx, db.UpdateBankAccountNicknameParams{
+		ID:       uri.ID,
+		Nickname: req.Nickname,
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
 
+	ctx.JSON(http.StatusOK, bankAccount)
 }
 
 func (server *Server) getBankAccount(ctx *gin.Context) {
@@ -66,26 +123,67 @@ func (server *Server) getBankAccount(ctx *gin.Context) {
 		return
 	}
 
-	bankAccount, err := server.store.GetBankAccount(ctx, req.ID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
+	bankAccount, ok := server.ownedBankAccount(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bankAccount)
+}
+
+type closeBankAccountRequest struct {
+	// SweepAccountID receives the balance of the account, required unless the balance is zero
+	SweepAccountID int64 `json:"sweep_account_id" binding:"omitempty,min=1"`
+}
+
+// closeBankAccount closes a bank account of the authenticated user, keeping its history
+func (server *Server) closeBankAccount(ctx *gin.Context) {
+	var uri getBankAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req closeBankAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	if _, ok := server.ownedBankAccount(ctx, uri.ID); !ok {
 		return
 	}
 
+	result, err := server.store.CloseAccountTx(ctx, db.CloseAccountTxParams{
+		AccountID:      uri.ID,
+		SweepAccountID: req.SweepAccountID,
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// ownedBankAccount gets the bank account, and responds with 403
+// if it does not belong to the authenticated user
+func (server *Server) ownedBankAccount(ctx *gin.Context, id int64) (db.BankAccount, bool) {
+	bankAccount, err := server.store.GetBankAccount(ctx, id)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return bankAccount, false
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
 	if bankAccount.Owner != authPayload.Username {
 		err := errors.New("you are not the owner of this bank account")
 		ctx.JSON(http.StatusForbidden, errorResponse(err))
-		return
+		return bankAccount, false
 	}
 
-	ctx.JSON(http.StatusOK, bankAccount)
+	return bankAccount, true
 }
 
 func (server *Server) listBankAccounts(ctx *gin.Context) {
@@ -98,17 +196,46 @@ func (server *Server) listBankAccounts(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
+	scope := pagination.Scope("bank_accounts", authPayload.Username)
+	cursor, ok := server.decodeCursor(ctx, scope, req.pageRequest)
+	if !ok {
+		return
+	}
+
 	bankAccountsArgs := db.ListBankAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		Owner:           authPayload.Username,
+		Currency:        db.NullString(req.Currency),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.PageSize),
 	}
 
 	bankAccounts, err := server.store.ListBankAccounts(ctx, bankAccountsArgs)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := listBankAccountsResponse{}
+	rsp.BankAccounts, rsp.NextCursor = pagination.Page(server.cursorCodec, scope, bankAccounts, req.PageSize, bankAccountCursor)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func bankAccountCursor(bankAccount db.BankAccount) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: bankAccount.CreatedAt, ID: bankAccount.ID}
+}
+
+type listAccountProductsResponse struct {
+	AccountProducts []db.AccountProduct `json:"account_products"`
+}
+
+// listAccountProducts lists the products accounts can be opened with, and their interest rates
+func (server *Server) listAccountProducts(ctx *gin.Context) {
+	products, err := server.store.ListAccountProducts(ctx)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, bankAccounts)
+	ctx.JSON(http.StatusOK, listAccountProductsResponse{AccountProducts: products})
 }
@@ -4,21 +4,24 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
 	"github.com/radugaf/simplebank/token"
 	"github.com/radugaf/simplebank/tools"
 	"github.com/stretchr/testify/require"
-
-	mockdb "github.com/radugaf/simplebank/db/mock"
 )
 
 func TestGetBankAccountAPI(t *testing.T) {
@@ -36,7 +39,7 @@ func TestGetBankAccountAPI(t *testing.T) {
 			name:          "OK",
 			bankAccountID: bankAccount.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(bankAccount, nil)
@@ -50,10 +53,10 @@ func TestGetBankAccountAPI(t *testing.T) {
 			name:          "NotFound",
 			bankAccountID: bankAccount.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(db.BankAccount{}, sql.ErrNoRows)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(db.BankAccount{}, db.ErrRecordNotFound)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -63,7 +66,7 @@ func TestGetBankAccountAPI(t *testing.T) {
 			name:          "InternalError",
 			bankAccountID: bankAccount.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(db.BankAccount{}, sql.ErrConnDone)
@@ -76,7 +79,7 @@ func TestGetBankAccountAPI(t *testing.T) {
 			name:          "InvalidID",
 			bankAccountID: 0,
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -111,6 +114,29 @@ func TestGetBankAccountAPI(t *testing.T) {
 	}
 }
 
+type eqCreateBankAccountParamsMatcher struct {
+	arg db.CreateBankAccountParams
+}
+
+func (e eqCreateBankAccountParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateBankAccountParams)
+	if !ok || !tools.IsValidAccountNumber(arg.AccountNumber) {
+		return false
+	}
+
+	e.arg.AccountNumber = arg.AccountNumber
+	return reflect.DeepEqual(e.arg, arg)
+}
+
+func (e eqCreateBankAccountParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v with a valid account number", e.arg)
+}
+
+// EqCreateBankAccountParams matches the params of an account with any valid random account number
+func EqCreateBankAccountParams(arg db.CreateBankAccountParams) gomock.Matcher {
+	return eqCreateBankAccountParamsMatcher{arg}
+}
+
 func TestCreateBankAccountAPI(t *testing.T) {
 	user, _ := randomUser(t)
 	account := randomAccount(user.Username)
@@ -124,19 +150,20 @@ func TestCreateBankAccountAPI(t *testing.T) {
 	}{
 		{
 			name: "OK",
-			body: gin.H{"currency": account.Currency},
+			body: gin.H{"currency": account.Currency, "nickname": account.Nickname},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.CreateBankAccountParams{
 					Owner:    account.Owner,
 					Currency: account.Currency,
 					Balance:  0,
+					Nickname: account.Nickname,
 				}
 
 				store.EXPECT().
-					CreateBankAccount(gomock.Any(), gomock.Eq(arg)).
+					CreateBankAccount(gomock.Any(), EqCreateBankAccountParams(arg)).
 					Times(1).
 					Return(account, nil)
 			},
@@ -145,6 +172,93 @@ func TestCreateBankAccountAPI(t *testing.T) {
 				requireBodyMatchBankAccount(t, recorder.Body, account)
 			},
 		},
+		{
+			name: "OKWithProduct",
+			body: gin.H{"currency": account.Currency, "product": "savings"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateBankAccountParams{
+					Owner:    account.Owner,
+					Currency: account.Currency,
+					Product:  db.NullString("savings"),
+				}
+
+				store.EXPECT().
+					CreateBankAccount(gomock.Any(), EqCreateBankAccountParams(arg)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownProduct",
+			body: gin.H{"currency": account.Currency, "product": "gold"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateBankAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BankAccount{}, &db.Error{
+						Kind:       db.ErrForeignKeyViolation,
+						Constraint: "bank_accounts_product_fkey",
+						Err:        errors.New("insert or update violates foreign key constraint"),
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNumberTaken",
+			body: gin.H{"currency": account.Currency},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				taken := &db.Error{
+					Kind:       db.ErrUniqueViolation,
+					Constraint: db.AccountNumberConstraint,
+					Err:        errors.New("duplicate key value violates unique constraint"),
+				}
+
+				// a new account number is drawn for every attempt
+				gomock.InOrder(
+					store.EXPECT().
+						CreateBankAccount(gomock.Any(), gomock.Any()).
+						Times(maxAccountNumberAttempts-1).
+						Return(db.BankAccount{}, taken),
+					store.EXPECT().
+						CreateBankAccount(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(account, nil),
+				)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBankAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "NicknameTooLong",
+			body: gin.H{"currency": account.Currency, "nickname": tools.RandomString(51)},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateBankAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "NoAuthorization",
 			body: gin.H{
@@ -167,7 +281,7 @@ func TestCreateBankAccountAPI(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -185,7 +299,7 @@ func TestCreateBankAccountAPI(t *testing.T) {
 				"currency": "invalid",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -226,6 +340,101 @@ func TestCreateBankAccountAPI(t *testing.T) {
 	}
 }
 
+func TestUpdateBankAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	bankAccount := randomAccount(user.Username)
+
+	renamed := bankAccount
+	renamed.Nickname = "holidays"
+
+	testCases := []struct {
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		name          string
+		username      string
+	}{
+		{
+			name:     "OK",
+			body:     gin.H{"nickname": renamed.Nickname},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					UpdateBankAccountNickname(gomock.Any(), gomock.Eq(db.UpdateBankAccountNicknameParams{
+						ID:       bankAccount.ID,
+						Nickname: renamed.Nickname,
+					})).
+					Times(1).
+					Return(renamed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBankAccount(t, recorder.Body, renamed)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			body:     gin.H{"nickname": renamed.Nickname},
+			username: "someone_else",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					UpdateBankAccountNickname(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NicknameTooLong",
+			body:     gin.H{"nickname": tools.RandomString(51)},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/bank_accounts/%d", bankAccount.ID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, tc.username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
 func TestListBankAccountsAPI(t *testing.T) {
 	user, _ := randomUser(t)
 
@@ -235,14 +444,23 @@ func TestListBankAccountsAPI(t *testing.T) {
 		accounts[i] = randomAccount(user.Username)
 	}
 
+	cursor := pagination.Cursor{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		ID:        tools.RandomInt(1, 1000),
+	}
+	scope := pagination.Scope("bank_accounts", user.Username)
+
 	type Query struct {
-		pageID   int
 		pageSize int
+		currency string
+		cursor   pagination.Cursor
+		// rawCursor is sent as is, instead of the encoded cursor
+		rawCursor string
 	}
 
 	testCases := []struct {
 		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Token)
-		checkResponse func(recoder *httptest.ResponseRecorder)
+		checkResponse func(t *testing.T, server *Server, recoder *httptest.ResponseRecorder)
 		buildStubs    func(store *mockdb.MockStore)
 		name          string
 		query         Query
@@ -250,17 +468,15 @@ func TestListBankAccountsAPI(t *testing.T) {
 		{
 			name: "OK",
 			query: Query{
-				pageID:   1,
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.ListBankAccountsParams{
-					Owner:  user.Username,
-					Limit:  int32(n),
-					Offset: 0,
+					Owner: user.Username,
+					Limit: int32(n + 1),
 				}
 
 				store.EXPECT().
@@ -268,15 +484,48 @@ func TestListBankAccountsAPI(t *testing.T) {
 					Times(1).
 					Return(accounts, nil)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchBankAccounts(t, recorder.Body, accounts)
+				nextCursor := requireBodyMatchBankAccounts(t, recorder.Body, accounts)
+				require.Empty(t, nextCursor)
+			},
+		},
+		{
+			name: "NextPage",
+			query: Query{
+				pageSize: n - 1,
+				currency: tools.USD,
+				cursor:   cursor,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListBankAccountsParams{
+					Owner:           user.Username,
+					Currency:        db.NullString(tools.USD),
+					CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+					CursorID:        cursor.ID,
+					Limit:           int32(n),
+				}
+
+				store.EXPECT().
+					ListBankAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				nextCursor := requireBodyMatchBankAccounts(t, recorder.Body, accounts[:n-1])
+
+				next, err := server.cursorCodec.Decode(scope, nextCursor)
+				require.NoError(t, err)
+				require.Equal(t, accounts[n-2].ID, next.ID)
 			},
 		},
 		{
 			name: "NoAuthorization",
 			query: Query{
-				pageID:   1,
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
@@ -286,18 +535,17 @@ func TestListBankAccountsAPI(t *testing.T) {
 					ListBankAccounts(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
 			name: "InternalError",
 			query: Query{
-				pageID:   1,
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -305,43 +553,60 @@ func TestListBankAccountsAPI(t *testing.T) {
 					Times(1).
 					Return([]db.BankAccount{}, sql.ErrConnDone)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 		{
-			name: "InvalidPageID",
+			name: "InvalidCursor",
+			query: Query{
+				pageSize:  n,
+				rawCursor: "not-a-cursor",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListBankAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCurrency",
 			query: Query{
-				pageID:   -1,
 				pageSize: n,
+				currency: "XYZ",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListBankAccounts(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
 			name: "InvalidPageSize",
 			query: Query{
-				pageID:   1,
 				pageSize: 100000,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListBankAccounts(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
-			checkResponse: func(recorder *httptest.ResponseRecorder) {
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
@@ -366,23 +631,179 @@ func TestListBankAccountsAPI(t *testing.T) {
 
 			// Add query parameters to request URL
 			q := request.URL.Query()
-			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
 			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			if tc.query.currency != "" {
+				q.Add("currency", tc.query.currency)
+			}
+			if !tc.query.cursor.IsZero() {
+				q.Add("cursor", server.cursorCodec.Encode(scope, tc.query.cursor))
+			}
+			if tc.query.rawCursor != "" {
+				q.Add("cursor", tc.query.rawCursor)
+			}
 			request.URL.RawQuery = q.Encode()
 
 			tc.setupAuth(t, request, server.tokenGenerator)
 			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+func TestCloseBankAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	bankAccount := randomAccount(user.Username)
+	sweepAccount := randomAccount(user.Username)
+
+	testCases := []struct {
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		name          string
+		username      string
+	}{
+		{
+			name:     "OK",
+			body:     gin.H{"sweep_account_id": sweepAccount.ID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+
+				closed := bankAccount
+				closed.Status = db.AccountStatusClosed
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Eq(db.CloseAccountTxParams{
+						AccountID:      bankAccount.ID,
+						SweepAccountID: sweepAccount.ID,
+					})).
+					Times(1).
+					Return(db.CloseAccountTxResult{Account: closed}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result db.CloseAccountTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &result)
+				require.NoError(t, err)
+				require.Equal(t, db.AccountStatusClosed, result.Account.Status)
+			},
+		},
+		{
+			name:     "NoBody",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Eq(db.CloseAccountTxParams{AccountID: bankAccount.ID})).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, db.ErrAccountBalanceNotZero)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "someone_else",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidSweepAccount",
+			body:     gin.H{"sweep_account_id": sweepAccount.ID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, db.ErrInvalidSweepAccount)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidSweepAccountID",
+			body:     gin.H{"sweep_account_id": -1},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body io.Reader = http.NoBody
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+
+			url := fmt.Sprintf("/bank_accounts/%d/close", bankAccount.ID)
+			request, err := http.NewRequest(http.MethodPost, url, body)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, tc.username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
 	}
 }
 
 func randomAccount(owner string) db.BankAccount {
+	accountNumber, err := tools.NewAccountNumber()
+	if err != nil {
+		panic(err)
+	}
+
+	balance := tools.RandomMoney()
 	return db.BankAccount{
-		ID:       tools.RandomInt(1, 1000),
-		Owner:    owner,
-		Balance:  tools.RandomMoney(),
-		Currency: tools.RandomCurrency(),
+		ID:               tools.RandomInt(1, 1000),
+		Owner:            owner,
+		Balance:          balance,
+		AvailableBalance: balance,
+		Currency:         tools.RandomCurrency(),
+		Status:           db.AccountStatusActive,
+		Nickname:         tools.RandomString(8),
+		AccountNumber:    accountNumber,
 	}
 }
 
@@ -397,12 +818,13 @@ func requireBodyMatchBankAccount(t *testing.T, body *bytes.Buffer, bankAccount d
 	require.Equal(t, bankAccount, gotAccount)
 }
 
-func requireBodyMatchBankAccounts(t *testing.T, body *bytes.Buffer, accounts []db.BankAccount) {
+func requireBodyMatchBankAccounts(t *testing.T, body *bytes.Buffer, accounts []db.BankAccount) string {
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
 
-	var gotAccounts []db.BankAccount
-	err = json.Unmarshal(data, &gotAccounts)
+	var rsp listBankAccountsResponse
+	err = json.Unmarshal(data, &rsp)
 	require.NoError(t, err)
-	require.Equal(t, accounts, gotAccounts)
+	require.Equal(t, accounts, rsp.BankAccounts)
+	return rsp.NextCursor
 }
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+)
+
+type listEntriesURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// listEntriesRequest filters the entries of an account, on the absolute value of their amount
+// and on the category the owner gave them
+type listEntriesRequest struct {
+	pageRequest
+	rangeRequest
+	Category string `form:"category" binding:"max=50"`
+}
+
+type listEntriesResponse struct {
+	Entries    []db.Entry `json:"entries"`
+	NextCursor string     `json:"next_cursor"`
+}
+
+// listEntries lists the entries of an account of the authenticated user, newest first
+func (server *Server) listEntries(ctx *gin.Context) {
+	var uri listEntriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scope := pagination.Scope("entries", uri.ID)
+	cursor, ok := server.decodeCursor(ctx, scope, req.pageRequest)
+	if !ok {
+		return
+	}
+
+	if _, ok := server.ownedBankAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	entries, err := server.store.ListEntries(ctx, db.ListEntriesParams{
+		AccountID:       uri.ID,
+		FromTime:        db.NullTime(req.FromTime),
+		ToTime:          db.NullTime(req.ToTime),
+		MinAmount:       db.NullInt64(req.MinAmount),
+		MaxAmount:       db.NullInt64(req.MaxAmount),
+		Category:        db.NullString(req.Category),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.PageSize),
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := listEntriesResponse{}
+	rsp.Entries, rsp.NextCursor = pagination.Page(server.cursorCodec, scope, entries, req.PageSize, entryCursor)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// maxStatementPeriod is the longest period a statement can be exported for
+const maxStatementPeriod = 366 * 24 * time.Hour
+
+// statementColumns is the header row of the statement exports
+var statementColumns = []string{"date", "entry_id", "amount", "currency", "category"}
+
+type exportStatementRequest struct {
+	FromTime time.Time `form:"from_time" binding:"required"`
+	ToTime   time.Time `form:"to_time" binding:"required,gtfield=FromTime"`
+	Category string    `form:"category" binding:"max=50"`
+}
+
+// exportStatement exports the entries of an account of the authenticated user over a period
+// as a CSV file, oldest first, with the category the owner gave them
+func (server *Server) exportStatement(ctx *gin.Context) {
+	var uri listEntriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req exportStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.ToTime.Sub(req.FromTime) > maxStatementPeriod {
+		err := fmt.Errorf("statement period cannot be longer than %d days", maxStatementPeriod/(24*time.Hour))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.ownedBankAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	entries, err := server.store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: uri.ID,
+		FromTime:  req.FromTime,
+		ToTime:    req.ToTime,
+		Category:  db.NullString(req.Category),
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	records := [][]string{statementColumns}
+	for _, entry := range entries {
+		records = append(records, []string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(entry.ID, 10),
+			strconv.FormatInt(entry.Amount, 10),
+			account.Currency,
+			entry.Category,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%s-%s-%s.csv",
+		account.AccountNumber, req.FromTime.UTC().Format("2006-01-02"), req.ToTime.UTC().Format("2006-01-02"))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func entryCursor(entry db.Entry) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+}
+
+type updateEntryURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type updateEntryRequest struct {
+	// Category is chosen by the owner of the account, an empty one removes it
+	Category string `json:"category" binding:"max=50"`
+}
+
+// updateEntry sets the category of an entry of an account of the authenticated user
+func (server *Server) updateEntry(ctx *gin.Context) {
+	var uri updateEntryURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateEntryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	entry, err := server.store.GetEntry(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedBankAccount(ctx, entry.AccountID); !ok {
+		return
+	}
+
+	entry, err = server.store.UpdateEntryCategory(ctx, db.UpdateEntryCategoryParams{
+		ID:       uri.ID,
+		Category: req.Category,
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entry)
+}
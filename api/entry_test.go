@@ -0,0 +1,502 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	bankAccount := randomAccount(user.Username)
+
+	n := 5
+	entries := make([]db.Entry, n)
+	for i := 0; i < n; i++ {
+		entries[i] = randomEntry(bankAccount.ID)
+	}
+
+	fromTime := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	toTime := fromTime.Add(time.Hour)
+
+	testCases := []struct {
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Token)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+		name          string
+		accountID     int64
+	}{
+		{
+			name:      "OK",
+			accountID: bankAccount.ID,
+			query: url.Values{
+				"page_size":  {fmt.Sprintf("%d", n-1)},
+				"from_time":  {fromTime.Format(time.RFC3339)},
+				"to_time":    {toTime.Format(time.RFC3339)},
+				"min_amount": {"10"},
+				"max_amount": {"1000"},
+				"category":   {"groceries"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+
+				arg := db.ListEntriesParams{
+					AccountID: bankAccount.ID,
+					FromTime:  db.NullTime(fromTime),
+					ToTime:    db.NullTime(toTime),
+					MinAmount: db.NullInt64(10),
+					MaxAmount: db.NullInt64(1000),
+					Category:  db.NullString("groceries"),
+					Limit:     int32(n),
+				}
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				nextCursor := requireBodyMatchEntries(t, recorder.Body, entries[:n-1])
+
+				next, err := server.cursorCodec.Decode(pagination.Scope("entries", bankAccount.ID), nextCursor)
+				require.NoError(t, err)
+				require.Equal(t, entries[n-2].ID, next.ID)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: bankAccount.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, otherUser.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: bankAccount.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(db.BankAccount{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: bankAccount.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Entry{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "CursorOfOtherAccount",
+			accountID: bankAccount.ID,
+			query: url.Values{
+				"cursor": {newCursorCodec(t).Encode(pagination.Scope("entries", bankAccount.ID+1), pagination.Cursor{ID: 1})},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidAmountRange",
+			accountID: bankAccount.ID,
+			query: url.Values{
+				"min_amount": {"1000"},
+				"max_amount": {"10"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidTimeRange",
+			accountID: bankAccount.ID,
+			query: url.Values{
+				"from_time": {toTime.Format(time.RFC3339)},
+				"to_time":   {fromTime.Format(time.RFC3339)},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/bank_accounts/%d/entries?%s", tc.accountID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenGenerator)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+func TestUpdateEntryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	bankAccount := randomAccount(user.Username)
+	entry := randomEntry(bankAccount.ID)
+
+	categorized := entry
+	categorized.Category = "rent"
+
+	testCases := []struct {
+		body         string
+		buildStubs   func(store *mockdb.MockStore)
+		name         string
+		username     string
+		expectedCode int
+	}{
+		{
+			name:     "OK",
+			body:     `{"category":"rent"}`,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEntry(gomock.Any(), gomock.Eq(entry.ID)).Times(1).Return(entry, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(bankAccount, nil)
+				store.EXPECT().
+					UpdateEntryCategory(gomock.Any(), gomock.Eq(db.UpdateEntryCategoryParams{ID: entry.ID, Category: "rent"})).
+					Times(1).
+					Return(categorized, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:     "UnauthorizedUser",
+			body:     `{"category":"rent"}`,
+			username: otherUser.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEntry(gomock.Any(), gomock.Eq(entry.ID)).Times(1).Return(entry, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(bankAccount, nil)
+				store.EXPECT().UpdateEntryCategory(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:     "NotFound",
+			body:     `{"category":"rent"}`,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEntry(gomock.Any(), gomock.Eq(entry.ID)).Times(1).Return(db.Entry{}, db.ErrRecordNotFound)
+				store.EXPECT().UpdateEntryCategory(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:     "CategoryTooLong",
+			body:     fmt.Sprintf(`{"category":"%s"}`, tools.RandomString(51)),
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEntry(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/entries/%d", entry.ID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, tc.username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestExportStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	bankAccount := randomAccount(user.Username)
+
+	fromTime := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	toTime := fromTime.AddDate(0, 1, 0)
+
+	entries := []db.Entry{randomEntry(bankAccount.ID), randomEntry(bankAccount.ID)}
+	entries[1].Category = ""
+
+	testCases := []struct {
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+		name          string
+		username      string
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"from_time": {fromTime.Format(time.RFC3339)},
+				"to_time":   {toTime.Format(time.RFC3339)},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(bankAccount, nil)
+				store.EXPECT().
+					ListStatementEntries(gomock.Any(), gomock.Eq(db.ListStatementEntriesParams{
+						AccountID: bankAccount.ID,
+						FromTime:  fromTime,
+						ToTime:    toTime,
+					})).
+					Times(1).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), bankAccount.AccountNumber)
+
+				records, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Equal(t, [][]string{
+					{"date", "entry_id", "amount", "currency", "category"},
+					{
+						entries[0].CreatedAt.Format(time.RFC3339),
+						fmt.Sprint(entries[0].ID),
+						fmt.Sprint(entries[0].Amount),
+						bankAccount.Currency,
+						"groceries",
+					},
+					{
+						entries[1].CreatedAt.Format(time.RFC3339),
+						fmt.Sprint(entries[1].ID),
+						fmt.Sprint(entries[1].Amount),
+						bankAccount.Currency,
+						"",
+					},
+				}, records)
+			},
+		},
+		{
+			name: "ByCategory",
+			query: url.Values{
+				"from_time": {fromTime.Format(time.RFC3339)},
+				"to_time":   {toTime.Format(time.RFC3339)},
+				"category":  {"groceries"},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(bankAccount, nil)
+				store.EXPECT().
+					ListStatementEntries(gomock.Any(), gomock.Eq(db.ListStatementEntriesParams{
+						AccountID: bankAccount.ID,
+						FromTime:  fromTime,
+						ToTime:    toTime,
+						Category:  db.NullString("groceries"),
+					})).
+					Times(1).
+					Return(entries[:1], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			query: url.Values{
+				"from_time": {fromTime.Format(time.RFC3339)},
+				"to_time":   {toTime.Format(time.RFC3339)},
+			},
+			username: otherUser.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(1).Return(bankAccount, nil)
+				store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "MissingPeriod",
+			query:    url.Values{"from_time": {fromTime.Format(time.RFC3339)}},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PeriodTooLong",
+			query: url.Values{
+				"from_time": {fromTime.Format(time.RFC3339)},
+				"to_time":   {fromTime.AddDate(2, 0, 0).Format(time.RFC3339)},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/bank_accounts/%d/statement?%s", bankAccount.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, tc.username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func randomEntry(accountID int64) db.Entry {
+	return db.Entry{
+		ID:        tools.RandomInt(1, 1000),
+		AccountID: accountID,
+		Amount:    tools.RandomMoney(),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		Category:  "groceries",
+	}
+}
+
+func newCursorCodec(t *testing.T) *pagination.Codec {
+	codec, err := pagination.NewCodec(tools.RandomString(32))
+	require.NoError(t, err)
+	return codec
+}
+
+func requireBodyMatchEntries(t *testing.T, body *bytes.Buffer, entries []db.Entry) string {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp listEntriesResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	require.Len(t, rsp.Entries, len(entries))
+	for i := range entries {
+		require.Equal(t, entries[i].ID, rsp.Entries[i].ID)
+		require.Equal(t, entries[i].Amount, rsp.Entries[i].Amount)
+		require.Equal(t, entries[i].Category, rsp.Entries[i].Category)
+		require.WithinDuration(t, entries[i].CreatedAt, rsp.Entries[i].CreatedAt, time.Microsecond)
+	}
+	return rsp.NextCursor
+}
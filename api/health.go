@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// healthz reports that the process is alive and serving requests
+func (server *Server) healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz reports whether the database, the schema and the background workers are ready,
+// and reports not ready once a graceful shutdown has started
+func (server *Server) readyz(ctx *gin.Context) {
+	failures := server.healthChecker.Ready(ctx)
+	if len(failures) == 0 {
+		ctx.JSON(http.StatusOK, readinessResponse{Status: "ready"})
+		return
+	}
+
+	checks := make(map[string]string, len(failures))
+	for name, err := range failures {
+		checks[name] = err.Error()
+	}
+	ctx.JSON(http.StatusServiceUnavailable, readinessResponse{Status: "not ready", Checks: checks})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/radugaf/simplebank/healthcheck"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthz(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestReadyz(t *testing.T) {
+	testCases := []struct {
+		name          string
+		setupChecker  func(checker *healthcheck.Checker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Ready",
+			setupChecker: func(checker *healthcheck.Checker) {
+				checker.AddCheck("database", func(ctx context.Context) error { return nil })
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp readinessResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "ready", rsp.Status)
+				require.Empty(t, rsp.Checks)
+			},
+		},
+		{
+			name: "CheckFailed",
+			setupChecker: func(checker *healthcheck.Checker) {
+				checker.AddCheck("database", func(ctx context.Context) error { return nil })
+				checker.AddCheck("migrations", func(ctx context.Context) error {
+					return errors.New("schema version is 4, expected 5")
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+				var rsp readinessResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "not ready", rsp.Status)
+				require.Equal(t, map[string]string{"migrations": "schema version is 4, expected 5"}, rsp.Checks)
+			},
+		},
+		{
+			name: "ShuttingDown",
+			setupChecker: func(checker *healthcheck.Checker) {
+				checker.SetShuttingDown()
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+				var rsp readinessResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Contains(t, rsp.Checks, "shutdown")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			server.healthChecker = healthcheck.New(time.Second)
+			tc.setupChecker(server.healthChecker)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+)
+
+// holdResponse is a hold with the recipient account given by its account number,
+// as it can belong to another user
+type holdResponse struct {
+	ExpiresAt       time.Time  `json:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ReleasedAt      *time.Time `json:"released_at,omitempty"`
+	TransferID      *int64     `json:"transfer_id,omitempty"`
+	ToAccountNumber string     `json:"to_account_number"`
+	Memo            string     `json:"memo"`
+	Reference       string     `json:"reference"`
+	Status          string     `json:"status"`
+	ID              int64      `json:"id"`
+	FromAccountID   int64      `json:"from_account_id"`
+	Amount          int64      `json:"amount"`
+	CapturedAmount  int64      `json:"captured_amount"`
+}
+
+func newHoldResponse(hold db.Hold, toAccountNumber string) holdResponse {
+	rsp := holdResponse{
+		ID:              hold.ID,
+		FromAccountID:   hold.FromAccountID,
+		ToAccountNumber: toAccountNumber,
+		Amount:          hold.Amount,
+		Memo:            hold.Memo,
+		Reference:       hold.Reference,
+		Status:          hold.Status,
+		CapturedAmount:  hold.CapturedAmount,
+		ExpiresAt:       hold.ExpiresAt,
+		CreatedAt:       hold.CreatedAt,
+	}
+	if hold.TransferID.Valid {
+		rsp.TransferID = &hold.TransferID.Int64
+	}
+	if hold.ReleasedAt.Valid {
+		rsp.ReleasedAt = &hold.ReleasedAt.Time
+	}
+	return rsp
+}
+
+// holdTxResponse is the result of authorizing or voiding a hold
+type holdTxResponse struct {
+	Hold        holdResponse   `json:"hold"`
+	FromAccount db.BankAccount `json:"from_account"`
+}
+
+// captureTransferResponse is the result of capturing a hold, with the transfer settling it
+type captureTransferResponse struct {
+	Hold holdResponse `json:"hold"`
+	transferResponse
+}
+
+// authorizeTransfer places a hold for a transfer settled later, given the same body as the transfer itself
+func (server *Server) authorizeTransfer(ctx *gin.Context) {
+	var req transferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	toAccount, valid := server.transferAccounts(ctx, req)
+	if !valid {
+		return
+	}
+	req.ToAccountID = toAccount.ID
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateHoldParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Memo:          req.Memo,
+		Reference:     tools.NormalizeCreditorReference(req.Reference),
+		ExpiresAt:     time.Now().Add(server.config.HoldDuration),
+	}
+
+	// a hold can be captured without a confirmation, so it is confirmed like the transfer
+	if threshold, ok := server.config.StepUpThresholds.Get(req.Currency); ok && req.Amount > threshold {
+		challengeID, valid := server.requireStepUp(ctx, req, authPayload.Username)
+		if !valid {
+			return
+		}
+		arg.StepUpChallengeID = uuid.NullUUID{UUID: challengeID, Valid: true}
+	}
+
+	result, err := server.store.AuthorizeTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrStepUpChallengeUnusable) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holdTxResponse{
+		Hold:        newHoldResponse(result.Hold, toAccount.AccountNumber),
+		FromAccount: result.FromAccount,
+	})
+}
+
+type holdRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getHold(ctx *gin.Context) {
+	var req holdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.ownedHold(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	toAccountNumber, valid := server.holdRecipient(ctx, hold)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newHoldResponse(hold, toAccountNumber))
+}
+
+type captureTransferRequest struct {
+	// Amount is the part of the hold to transfer, the whole hold if it is not set
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// captureTransfer settles the whole hold or a part of it, the rest is released
+func (server *Server) captureTransfer(ctx *gin.Context) {
+	var uri holdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req captureTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.ownedHold(ctx, uri.ID); !valid {
+		return
+	}
+
+	result, err := server.store.CaptureTransferTx(ctx, db.CaptureTransferTxParams{
+		HoldID: uri.ID,
+		Amount: req.Amount,
+		Now:    time.Now(),
+	})
+	if err != nil {
+		// tell the client which limit was hit and how much it can still send
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(apierror.HTTPStatus(err), gin.H{"error": err.Error(), "limit": limitErr})
+			return
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, captureTransferResponse{
+		Hold:             newHoldResponse(result.Hold, result.ToAccount.AccountNumber),
+		transferResponse: newTransferResponse(result.TransferTxResult),
+	})
+}
+
+// voidTransfer releases the hold without transferring anything
+func (server *Server) voidTransfer(ctx *gin.Context) {
+	var req holdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.ownedHold(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	toAccountNumber, valid := server.holdRecipient(ctx, hold)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.VoidTransferTx(ctx, db.VoidTransferTxParams{
+		HoldID: req.ID,
+		Now:    time.Now(),
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holdTxResponse{
+		Hold:        newHoldResponse(result.Hold, toAccountNumber),
+		FromAccount: result.FromAccount,
+	})
+}
+
+// ownedHold returns the hold if it is placed on an account of the authenticated user
+func (server *Server) ownedHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, id)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return hold, false
+	}
+
+	fromAccount, err := server.store.GetBankAccount(ctx, hold.FromAccountID)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return hold, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("hold doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return hold, false
+	}
+
+	return hold, true
+}
+
+// holdRecipient returns the account number of the recipient account of the hold
+func (server *Server) holdRecipient(ctx *gin.Context, hold db.Hold) (string, bool) {
+	toAccount, err := server.store.GetBankAccount(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return "", false
+	}
+	return toAccount.AccountNumber, true
+}
@@ -0,0 +1,434 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account1.Currency = tools.USD
+	account2.Currency = tools.USD
+
+	// below the step-up threshold of the test server
+	amount := int64(500)
+
+	testCases := []struct {
+		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Token)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		body          gin.H
+		name          string
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        tools.USD,
+				"memo":            "deposit",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				heldAccount := account1
+				heldAccount.Held = amount
+				heldAccount.AvailableBalance = account1.Balance - amount
+
+				store.EXPECT().
+					AuthorizeTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateHoldParams) (db.AuthorizeTransferTxResult, error) {
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, amount, arg.Amount)
+						require.Equal(t, "deposit", arg.Memo)
+						require.False(t, arg.StepUpChallengeID.Valid)
+						require.WithinDuration(t, time.Now(), arg.ExpiresAt, time.Second)
+
+						return db.AuthorizeTransferTxResult{
+							Hold:        randomHold(account1.ID, account2.ID, amount),
+							FromAccount: heldAccount,
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp holdTxResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account1.Balance, rsp.FromAccount.Balance)
+				require.Equal(t, account1.Balance-amount, rsp.FromAccount.AvailableBalance)
+				require.Equal(t, db.HoldStatusAuthorized, rsp.Hold.Status)
+
+				// the recipient belongs to another user, the hold does not disclose its ID
+				require.Equal(t, account2.AccountNumber, rsp.Hold.ToAccountNumber)
+				require.NotContains(t, recorder.Body.String(), "toAccountID")
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        tools.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					AuthorizeTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AuthorizeTransferTxResult{}, fmt.Errorf("authorize: %w", db.ErrInsufficientFunds))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        tools.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user2.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "StepUpRequired",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        tools.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CreateStepUpChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomStepUpChallenge(user1.Username, account1, account2, 1001), nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				var rsp stepUpRequiredResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int64(1001), rsp.Challenge.Amount)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/holds", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenGenerator)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCaptureTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+
+	hold := randomHold(account1.ID, account2.ID, 800)
+
+	testCases := []struct {
+		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Token)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		body          []byte
+		name          string
+	}{
+		{
+			name: "WholeHold",
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					CaptureTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CaptureTransferTxParams) (db.CaptureTransferTxResult, error) {
+						require.Equal(t, hold.ID, arg.HoldID)
+						require.Zero(t, arg.Amount)
+
+						result := db.CaptureTransferTxResult{Hold: hold}
+						result.ToAccount = account2
+						result.Transfer = db.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: hold.Amount}
+						result.ToEntry = db.Entry{ID: 2, AccountID: account2.ID, Amount: hold.Amount}
+						return result, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp captureTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account2.AccountNumber, rsp.Hold.ToAccountNumber)
+				require.Equal(t, account2.AccountNumber, rsp.Transfer.ToAccountNumber)
+				require.Equal(t, account2.AccountNumber, rsp.ToEntry.AccountNumber)
+				require.NotContains(t, recorder.Body.String(), "toAccountID")
+			},
+		},
+		{
+			name: "PartialCapture",
+			body: []byte(`{"amount": 300}`),
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					CaptureTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CaptureTransferTxParams) (db.CaptureTransferTxResult, error) {
+						require.Equal(t, int64(300), arg.Amount)
+						return db.CaptureTransferTxResult{Hold: hold}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			body: []byte(`{"amount": -1}`),
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwner",
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user2.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyReleased",
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					CaptureTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CaptureTransferTxResult{}, db.ErrHoldNotAuthorized)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "HoldNotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.Hold{}, db.ErrRecordNotFound)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/holds/%d/capture", hold.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenGenerator)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVoidTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	recipient := randomAccount(user.Username)
+	recipient.ID = account.ID + 1
+	hold := randomHold(account.ID, recipient.ID, 800)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				voided := hold
+				voided.Status = db.HoldStatusVoided
+
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(recipient.ID)).Times(1).Return(recipient, nil)
+				store.EXPECT().
+					VoidTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.VoidTransferTxParams) (db.VoidTransferTxResult, error) {
+						require.Equal(t, hold.ID, arg.HoldID)
+						return db.VoidTransferTxResult{Hold: voided, FromAccount: account}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp holdTxResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.HoldStatusVoided, rsp.Hold.Status)
+				require.Equal(t, recipient.AccountNumber, rsp.Hold.ToAccountNumber)
+			},
+		},
+		{
+			name: "AlreadyReleased",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(recipient.ID)).Times(1).Return(recipient, nil)
+				store.EXPECT().
+					VoidTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VoidTransferTxResult{}, db.ErrHoldNotAuthorized)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/holds/%d/void", hold.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomHold(fromAccountID int64, toAccountID int64, amount int64) db.Hold {
+	return db.Hold{
+		ID:            tools.RandomInt(1, 1000),
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		Status:        db.HoldStatusAuthorized,
+		ExpiresAt:     time.Now().Add(time.Hour).Truncate(time.Second),
+		CreatedAt:     time.Now().Truncate(time.Second),
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/healthcheck"
 	"github.com/radugaf/simplebank/tools"
 	"github.com/stretchr/testify/require"
 )
@@ -18,11 +19,18 @@ func TestMain(m *testing.M) {
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := tools.Config{
-		TokenSymmetricKey:   tools.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:       tools.RandomString(32),
+		CursorSigningKey:        tools.RandomString(32),
+		AccessTokenDuration:     time.Minute,
+		StepUpThresholds:        tools.CurrencyAmounts{tools.USD: 1000},
+		StepUpChallengeDuration: time.Minute,
+		LoginMaxFailedAttempts:  3,
+		LoginFailureWindow:      time.Hour,
+		LoginBaseDelay:          time.Second,
+		LoginLockoutDuration:    time.Minute,
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, healthcheck.New(time.Second))
 	require.NoError(t, err)
 
 	return server
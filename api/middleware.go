@@ -3,11 +3,22 @@ package api
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/radugaf/simplebank/logging"
+	"github.com/radugaf/simplebank/metrics"
+	"github.com/radugaf/simplebank/ratelimit"
 	"github.com/radugaf/simplebank/token"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -52,3 +63,127 @@ func authMiddleware(tokenMaker token.Token) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// rateLimitMiddleware creates a gin middleware that limits the requests per route of every
+// authenticated user, or of every client IP for requests without an authenticated user
+func rateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		caller := "ip:" + ctx.ClientIP()
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			caller = "user:" + payload.(*token.Payload).Username
+		}
+
+		route := ctx.Request.Method + " " + ctx.FullPath()
+		if ok, retryAfter := limiter.Allow(route, caller); !ok {
+			err := errors.New("too many requests")
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requestLogger creates a gin middleware that carries the X-Request-ID of every request,
+// or a new one if the client did not send it, in the request context and the response,
+// and logs the request once it is handled
+func requestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		startTime := time.Now()
+
+		requestID := logging.RequestIDOrNew(ctx.GetHeader(logging.RequestIDHeader))
+		ctx.Header(logging.RequestIDHeader, requestID)
+		ctx.Request = ctx.Request.WithContext(logging.WithRequestID(ctx.Request.Context(), requestID))
+
+		ctx.Next()
+
+		logger := logging.FromContext(ctx.Request.Context())
+		event := logger.Info()
+		if ctx.Writer.Status() >= http.StatusInternalServerError {
+			event = logger.Error()
+		}
+
+		event.Str("protocol", "http").
+			Str("method", ctx.Request.Method).
+			Str("path", ctx.Request.URL.Path).
+			Int("status_code", ctx.Writer.Status()).
+			Str("status_text", http.StatusText(ctx.Writer.Status())).
+			Dur("duration", time.Since(startTime)).
+			Str("client_ip", ctx.ClientIP()).
+			Msg("received a HTTP request")
+	}
+}
+
+// recoveryHandler logs a panic in a handler and responds with an internal server error
+func recoveryHandler(ctx *gin.Context, recovered interface{}) {
+	logging.FromContext(ctx.Request.Context()).Error().
+		Interface("panic", recovered).
+		Str("path", ctx.Request.URL.Path).
+		Msg("recovered from panic")
+	ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(errors.New("internal error")))
+}
+
+// loginRoute is the route whose responses are counted as login attempts
+const loginRoute = "/users/login"
+
+// metricsMiddleware creates a gin middleware that counts the requests and observes
+// their latency per route, and counts the login attempts by their response
+func metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		startTime := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := ctx.Request.Method
+		statusCode := ctx.Writer.Status()
+
+		metrics.HTTPRequests.WithLabelValues(route, method, strconv.Itoa(statusCode)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(route, method).Observe(time.Since(startTime).Seconds())
+
+		if method == http.MethodPost && route == loginRoute {
+			switch statusCode {
+			case http.StatusOK, http.StatusUnauthorized:
+				metrics.Logins.WithLabelValues(metrics.LoginResult(statusCode == http.StatusOK)).Inc()
+			}
+		}
+	}
+}
+
+const tracerName = "github.com/radugaf/simplebank/api"
+
+// tracingMiddleware creates a gin middleware that starts a span for every request,
+// continuing the W3C trace context of the request headers if there is one
+func tracingMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestCtx := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		requestCtx, span := otel.Tracer(tracerName).Start(requestCtx, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(ctx.Request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Next()
+
+		statusCode := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(statusCode))
+		if statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,8 +10,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/radugaf/simplebank/logging"
+	"github.com/radugaf/simplebank/metrics"
+	"github.com/radugaf/simplebank/ratelimit"
 	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 )
 
 func addAuthorization(
@@ -18,9 +33,10 @@ func addAuthorization(
 	tokenGenerator token.Token,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
-	token, payload, err := tokenGenerator.GenerateToken(username, duration)
+	token, payload, err := tokenGenerator.GenerateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -37,7 +53,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, "user", time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, "user", tools.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -54,7 +70,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "UnsupportedAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, "unsupported", "user", time.Minute)
+				addAuthorization(t, request, tokenGenerator, "unsupported", "user", tools.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -63,7 +79,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthorizationFormat",
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, "", "user", time.Minute)
+				addAuthorization(t, request, tokenGenerator, "", "user", tools.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -72,7 +88,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
-				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, "user", -time.Minute)
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, "user", tools.DepositorRole, -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -104,3 +120,212 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimitMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+	limiter := ratelimit.NewLimiter(tools.RateLimits{
+		tools.DefaultRateLimitKey: {Rate: 0.001, Burst: 2},
+	})
+
+	limitPath := "/limit"
+	server.router.GET(
+		limitPath,
+		func(ctx *gin.Context) {
+			if ctx.GetHeader(authorizationHeaderKey) != "" {
+				authMiddleware(server.tokenGenerator)(ctx)
+			}
+		},
+		rateLimitMiddleware(limiter),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	send := func(setupRequest func(request *http.Request)) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, limitPath, nil)
+		require.NoError(t, err)
+		setupRequest(request)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	fromIP := func(ip string) func(request *http.Request) {
+		return func(request *http.Request) {
+			request.RemoteAddr = ip + ":1234"
+		}
+	}
+	asUser := func(username string) func(request *http.Request) {
+		return func(request *http.Request) {
+			request.RemoteAddr = "10.0.0.1:1234"
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, username, tools.DepositorRole, time.Minute)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusOK, send(fromIP("10.0.0.1")).Code)
+	}
+
+	recorder := send(fromIP("10.0.0.1"))
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "1000", recorder.Header().Get("Retry-After"))
+
+	// a forged X-Forwarded-For does not move the client to another bucket
+	forged := func(request *http.Request) {
+		fromIP("10.0.0.1")(request)
+		request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	}
+	require.Equal(t, http.StatusTooManyRequests, send(forged).Code)
+
+	// behind a trusted proxy the client is the forwarded IP
+	require.NoError(t, server.router.SetTrustedProxies([]string{"10.0.0.9"}))
+	proxied := func(request *http.Request) {
+		fromIP("10.0.0.9")(request)
+		request.Header.Set("X-Forwarded-For", "10.0.0.1")
+	}
+	require.Equal(t, http.StatusTooManyRequests, send(proxied).Code)
+
+	// another IP has a bucket of its own
+	require.Equal(t, http.StatusOK, send(fromIP("10.0.0.2")).Code)
+
+	// authenticated users are limited per user, not per IP
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusOK, send(asUser("alice")).Code)
+	}
+	require.Equal(t, http.StatusTooManyRequests, send(asUser("alice")).Code)
+	require.Equal(t, http.StatusOK, send(asUser("bob")).Code)
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = logger }()
+
+	server := newTestServer(t, nil)
+
+	requestIDPath := "/request_id"
+	var handlerRequestID string
+	server.router.GET(
+		requestIDPath,
+		func(ctx *gin.Context) {
+			// the gin context falls back to the values of the request context
+			handlerRequestID = logging.RequestIDFromContext(ctx)
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	testCases := []struct {
+		name           string
+		requestID      string
+		checkRequestID func(t *testing.T, requestID string)
+	}{
+		{
+			name:      "FromHeader",
+			requestID: "client-request-id",
+			checkRequestID: func(t *testing.T, requestID string) {
+				require.Equal(t, "client-request-id", requestID)
+			},
+		},
+		{
+			name:      "Generated",
+			requestID: "",
+			checkRequestID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, requestIDPath, nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(logging.RequestIDHeader, tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			requestID := recorder.Header().Get(logging.RequestIDHeader)
+			tc.checkRequestID(t, requestID)
+			require.Equal(t, requestID, handlerRequestID)
+
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			require.Equal(t, requestID, entry["request_id"])
+			require.Equal(t, "http", entry["protocol"])
+			require.Equal(t, requestIDPath, entry["path"])
+			require.Equal(t, float64(http.StatusOK), entry["status_code"])
+		})
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	requests := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/bank_accounts/:id", http.MethodGet, "401"))
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/bank_accounts/1", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	require.Equal(t, requests+1, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("/bank_accounts/:id", http.MethodGet, "401")))
+
+	// the metrics are only served on the metrics address, not by the API
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body := recorder.Body.String()
+	require.Contains(t, body, `simplebank_http_requests_total{method="GET",route="/bank_accounts/:id",status_code="401"}`)
+	require.Contains(t, body, "simplebank_http_request_duration_seconds")
+	require.Contains(t, body, "go_goroutines")
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+
+	server := newTestServer(t, nil)
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID := "00f067aa0ba902b7"
+
+	httpRecorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/bank_accounts/1", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-01", traceID, parentSpanID))
+
+	server.router.ServeHTTP(httpRecorder, request)
+	require.Equal(t, http.StatusUnauthorized, httpRecorder.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, "GET /bank_accounts/:id", span.Name())
+	require.Equal(t, traceID, span.SpanContext().TraceID().String())
+	require.Equal(t, parentSpanID, span.Parent().SpanID().String())
+	require.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusUnauthorized))
+}
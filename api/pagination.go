@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radugaf/simplebank/pagination"
+)
+
+// pageRequest holds the query parameters of a page of a listing. The first page is
+// requested without a cursor and the next ones with the next_cursor of the previous page.
+type pageRequest struct {
+	// max is pagination.MaxPageSize
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Cursor   string `form:"cursor"`
+}
+
+// rangeRequest holds the filters shared by the listings of entries and transfers,
+// times in RFC 3339 format and amounts in minor units
+type rangeRequest struct {
+	FromTime  time.Time `form:"from_time"`
+	ToTime    time.Time `form:"to_time" binding:"omitempty,gtfield=FromTime"`
+	MinAmount int64     `form:"min_amount" binding:"omitempty,min=1"`
+	MaxAmount int64     `form:"max_amount" binding:"omitempty,min=1,gtefield=MinAmount"`
+}
+
+// decodeCursor decodes the cursor of the request, and responds with 400 if it is invalid
+func (server *Server) decodeCursor(ctx *gin.Context, scope string, req pageRequest) (pagination.Cursor, bool) {
+	cursor, err := server.cursorCodec.Decode(scope, req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return cursor, false
+	}
+	return cursor, true
+}
@@ -0,0 +1,298 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+)
+
+type payeeResponse struct {
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DisplayName   string     `json:"display_name"`
+	AccountNumber string     `json:"account_number"`
+	Currency      string     `json:"currency"`
+	Note          string     `json:"note"`
+	ID            int64      `json:"id"`
+	// TargetClosed flags a payee whose account was closed, it cannot receive transfers anymore
+	TargetClosed bool `json:"target_closed"`
+}
+
+func newPayeeResponse(payee db.Payee, targetClosed bool) payeeResponse {
+	rsp := payeeResponse{
+		ID:            payee.ID,
+		DisplayName:   payee.DisplayName,
+		AccountNumber: payee.AccountNumber,
+		Currency:      payee.Currency,
+		Note:          payee.Note,
+		CreatedAt:     payee.CreatedAt,
+		TargetClosed:  targetClosed,
+	}
+	if payee.ConfirmedAt.Valid {
+		rsp.ConfirmedAt = &payee.ConfirmedAt.Time
+	}
+	return rsp
+}
+
+type createPayeeRequest struct {
+	DisplayName   string `json:"display_name" binding:"required,max=50"`
+	AccountNumber string `json:"account_number" binding:"required,account_number"`
+	Note          string `json:"note" binding:"max=200"`
+}
+
+// createPayee adds a payee to the address book of the authenticated user. When step-up is
+// required for new payees, the payee is pending and responded with 202 until it is confirmed.
+func (server *Server) createPayee(ctx *gin.Context) {
+	var req createPayeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := server.store.GetBankAccountByNumber(ctx, tools.NormalizeAccountNumber(req.AccountNumber))
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	if account.Status == db.AccountStatusClosed {
+		err := fmt.Errorf("bank account [%s] is closed: %w", account.AccountNumber, db.ErrAccountNotActive)
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	payee, err := server.store.CreatePayee(ctx, db.CreatePayeeParams{
+		Owner:         authPayload.Username,
+		DisplayName:   req.DisplayName,
+		AccountNumber: account.AccountNumber,
+		Currency:      account.Currency,
+		Note:          req.Note,
+		Confirmed:     !server.config.PayeeStepUpRequired,
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	code := http.StatusOK
+	if !payee.ConfirmedAt.Valid {
+		code = http.StatusAccepted
+	}
+	ctx.JSON(code, newPayeeResponse(payee, false))
+}
+
+type getPayeeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getPayee(ctx *gin.Context) {
+	var req getPayeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, ok := server.ownedPayee(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPayeeResponse(payee.Payee, payee.TargetClosed))
+}
+
+type listPayeesRequest struct {
+	pageRequest
+}
+
+type listPayeesResponse struct {
+	Payees     []payeeResponse `json:"payees"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+func (server *Server) listPayees(ctx *gin.Context) {
+	var req listPayeesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	scope := pagination.Scope("payees", authPayload.Username)
+	cursor, ok := server.decodeCursor(ctx, scope, req.pageRequest)
+	if !ok {
+		return
+	}
+
+	rows, err := server.store.ListPayees(ctx, db.ListPayeesParams{
+		Owner:           authPayload.Username,
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.PageSize),
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rows, nextCursor := pagination.Page(server.cursorCodec, scope, rows, req.PageSize, payeeCursor)
+
+	rsp := listPayeesResponse{
+		Payees:     make([]payeeResponse, len(rows)),
+		NextCursor: nextCursor,
+	}
+	for i, row := range rows {
+		rsp.Payees[i] = newPayeeResponse(row.Payee, row.TargetClosed)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func payeeCursor(row db.ListPayeesRow) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: row.Payee.CreatedAt, ID: row.Payee.ID}
+}
+
+type updatePayeeRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,min=1,max=50"`
+	Note        *string `json:"note" binding:"omitempty,max=200"`
+}
+
+// updatePayee renames a payee or changes its note, the account it targets cannot be changed
+func (server *Server) updatePayee(ctx *gin.Context) {
+	var uri getPayeeRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updatePayeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	current, ok := server.ownedPayee(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	arg := db.UpdatePayeeParams{
+		ID: uri.ID,
+		DisplayName: pgtype.Text{
+			String: stringValue(req.DisplayName),
+			Valid:  req.DisplayName != nil,
+		},
+		Note: pgtype.Text{
+			String: stringValue(req.Note),
+			Valid:  req.Note != nil,
+		},
+	}
+
+	payee, err := server.store.UpdatePayee(ctx, arg)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPayeeResponse(payee, current.TargetClosed))
+}
+
+func (server *Server) deletePayee(ctx *gin.Context) {
+	var req getPayeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ownedPayee(ctx, req.ID); !ok {
+		return
+	}
+
+	if err := server.store.DeletePayee(ctx, req.ID); err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// confirmPayee confirms a pending payee with the password or the TOTP code of the user
+func (server *Server) confirmPayee(ctx *gin.Context) {
+	var uri getPayeeRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req stepUpCredentialsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	current, ok := server.ownedPayee(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	if current.Payee.ConfirmedAt.Valid {
+		err := errors.New("payee is already confirmed")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if _, ok := server.checkStepUpCredentials(ctx, authPayload.Username, req); !ok {
+		return
+	}
+
+	payee, err := server.store.ConfirmPayee(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("payee is already confirmed")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPayeeResponse(payee, current.TargetClosed))
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ownedPayee gets the payee, and responds with 403
+// if it is not in the address book of the authenticated user
+func (server *Server) ownedPayee(ctx *gin.Context, id int64) (db.GetPayeeRow, bool) {
+	payee, err := server.store.GetPayee(ctx, id)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return payee, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if payee.Payee.Owner != authPayload.Username {
+		err := errors.New("payee doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return payee, false
+	}
+
+	return payee, true
+}
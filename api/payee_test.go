@@ -0,0 +1,440 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePayeeAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	target := randomAccount(otherUser.Username)
+
+	pending := randomPayee(user.Username, target, false)
+	confirmed := randomPayee(user.Username, target, true)
+
+	testCases := []struct {
+		body                gin.H
+		buildStubs          func(store *mockdb.MockStore)
+		checkResponse       func(recorder *httptest.ResponseRecorder)
+		name                string
+		payeeStepUpRequired bool
+	}{
+		{
+			name:                "Pending",
+			body:                gin.H{"display_name": pending.DisplayName, "account_number": target.AccountNumber, "note": pending.Note},
+			payeeStepUpRequired: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccountByNumber(gomock.Any(), gomock.Eq(target.AccountNumber)).Times(1).Return(target, nil)
+
+				arg := db.CreatePayeeParams{
+					Owner:         user.Username,
+					DisplayName:   pending.DisplayName,
+					AccountNumber: target.AccountNumber,
+					Currency:      target.Currency,
+					Note:          pending.Note,
+					Confirmed:     false,
+				}
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Eq(arg)).Times(1).Return(pending, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				rsp := decodePayee(t, recorder)
+				require.Equal(t, pending.ID, rsp.ID)
+				require.Nil(t, rsp.ConfirmedAt)
+			},
+		},
+		{
+			name: "StepUpNotRequired",
+			body: gin.H{"display_name": confirmed.DisplayName, "account_number": target.AccountNumber, "note": confirmed.Note},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccountByNumber(gomock.Any(), gomock.Eq(target.AccountNumber)).Times(1).Return(target, nil)
+
+				arg := db.CreatePayeeParams{
+					Owner:         user.Username,
+					DisplayName:   confirmed.DisplayName,
+					AccountNumber: target.AccountNumber,
+					Currency:      target.Currency,
+					Note:          confirmed.Note,
+					Confirmed:     true,
+				}
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Eq(arg)).Times(1).Return(confirmed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodePayee(t, recorder)
+				require.NotNil(t, rsp.ConfirmedAt)
+			},
+		},
+		{
+			name: "TargetClosed",
+			body: gin.H{"display_name": pending.DisplayName, "account_number": target.AccountNumber},
+			buildStubs: func(store *mockdb.MockStore) {
+				closedAccount := target
+				closedAccount.Status = db.AccountStatusClosed
+
+				store.EXPECT().GetBankAccountByNumber(gomock.Any(), gomock.Eq(target.AccountNumber)).Times(1).Return(closedAccount, nil)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{"display_name": pending.DisplayName, "account_number": target.AccountNumber},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccountByNumber(gomock.Any(), gomock.Any()).Times(1).Return(db.BankAccount{}, db.ErrRecordNotFound)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyAdded",
+			body: gin.H{"display_name": pending.DisplayName, "account_number": target.AccountNumber},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccountByNumber(gomock.Any(), gomock.Any()).Times(1).Return(target, nil)
+				store.EXPECT().
+					CreatePayee(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Payee{}, &db.Error{Kind: db.ErrUniqueViolation, Err: errors.New("duplicate key")})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAccountNumber",
+			body: gin.H{"display_name": pending.DisplayName, "account_number": tools.MistypedAccountNumber(target.AccountNumber)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.PayeeStepUpRequired = tc.payeeStepUpRequired
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/payees", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestConfirmPayeeAPI(t *testing.T) {
+	user, password := randomUser(t)
+	otherUser, _ := randomUser(t)
+	target := randomAccount(otherUser.Username)
+
+	pending := randomPayee(user.Username, target, false)
+	confirmed := pending
+	confirmed.ConfirmedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		name          string
+		username      string
+	}{
+		{
+			name:     "OK",
+			body:     gin.H{"password": password},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(db.GetPayeeRow{Payee: pending}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ConfirmPayee(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(confirmed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodePayee(t, recorder)
+				require.NotNil(t, rsp.ConfirmedAt)
+			},
+		},
+		{
+			name:     "WrongPassword",
+			body:     gin.H{"password": "not-the-password"},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(db.GetPayeeRow{Payee: pending}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ConfirmPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyConfirmed",
+			body:     gin.H{"password": password},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(db.GetPayeeRow{Payee: confirmed}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ConfirmPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			body:     gin.H{"password": password},
+			username: otherUser.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(db.GetPayeeRow{Payee: pending}, nil)
+				store.EXPECT().ConfirmPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "MissingProof",
+			body:     gin.H{},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/payees/%d/confirm", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, tc.username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListPayeesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+
+	n := 3
+	rows := make([]db.ListPayeesRow, n)
+	for i := range rows {
+		rows[i] = db.ListPayeesRow{Payee: randomPayee(user.Username, randomAccount(otherUser.Username), true)}
+	}
+	rows[1].TargetClosed = true
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.ListPayeesParams{
+		Owner: user.Username,
+		Limit: 3,
+	}
+	store.EXPECT().ListPayees(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rows, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/payees?page_size=2", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp listPayeesResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Len(t, rsp.Payees, 2)
+	require.False(t, rsp.Payees[0].TargetClosed)
+	require.True(t, rsp.Payees[1].TargetClosed)
+
+	cursor, err := server.cursorCodec.Decode(pagination.Scope("payees", user.Username), rsp.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, rows[1].Payee.ID, cursor.ID)
+}
+
+func TestUpdatePayeeAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	payee := randomPayee(user.Username, randomAccount(otherUser.Username), true)
+
+	updated := payee
+	updated.Note = ""
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(db.GetPayeeRow{Payee: payee, TargetClosed: true}, nil)
+
+	// the display name is left unchanged and the note cleared
+	arg := db.UpdatePayeeParams{
+		ID:   payee.ID,
+		Note: pgtype.Text{String: "", Valid: true},
+	}
+	store.EXPECT().UpdatePayee(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/payees/%d", payee.ID)
+	request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader([]byte(`{"note":""}`)))
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	rsp := decodePayee(t, recorder)
+	require.Equal(t, payee.DisplayName, rsp.DisplayName)
+	require.Empty(t, rsp.Note)
+	require.True(t, rsp.TargetClosed)
+}
+
+func TestDeletePayeeAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	payee := randomPayee(user.Username, randomAccount(otherUser.Username), true)
+
+	testCases := []struct {
+		buildStubs   func(store *mockdb.MockStore)
+		name         string
+		username     string
+		expectedCode int
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(db.GetPayeeRow{Payee: payee}, nil)
+				store.EXPECT().DeletePayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:     "NotOwner",
+			username: otherUser.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(db.GetPayeeRow{Payee: payee}, nil)
+				store.EXPECT().DeletePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(db.GetPayeeRow{}, db.ErrRecordNotFound)
+				store.EXPECT().DeletePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/payees/%d", payee.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, tc.username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func randomPayee(owner string, target db.BankAccount, confirmed bool) db.Payee {
+	payee := db.Payee{
+		ID:            tools.RandomInt(1, 1000),
+		Owner:         owner,
+		DisplayName:   tools.RandomOwner(),
+		AccountNumber: target.AccountNumber,
+		Currency:      target.Currency,
+		Note:          tools.RandomString(12),
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if confirmed {
+		payee.ConfirmedAt = pgtype.Timestamptz{Time: payee.CreatedAt, Valid: true}
+	}
+	return payee
+}
+
+func decodePayee(t *testing.T, recorder *httptest.ResponseRecorder) payeeResponse {
+	var rsp payeeResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	return rsp
+}
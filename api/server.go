@@ -2,11 +2,17 @@ package api
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/healthcheck"
+	"github.com/radugaf/simplebank/loginguard"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/ratelimit"
 	"github.com/radugaf/simplebank/token"
 	"github.com/radugaf/simplebank/tools"
 )
@@ -16,43 +22,113 @@ type Server struct {
 	store          db.Store
 	router         *gin.Engine
 	tokenGenerator token.Token
+	loginGuard     *loginguard.Guard
+	passwordHasher *tools.PasswordHasher
+	rateLimiter    *ratelimit.Limiter
+	healthChecker  *healthcheck.Checker
+	cursorCodec    *pagination.Codec
 	config         tools.Config
 }
 
 // NewServer creates a new HTTP server and setup routing.
-func NewServer(config tools.Config, store db.Store) (*Server, error) {
+func NewServer(config tools.Config, store db.Store, healthChecker *healthcheck.Checker) (*Server, error) {
 
 	tokenGenerator, err := token.NewPasetoGenerator(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token generator: %w", err)
 	}
 
-	server := &Server{config: config, store: store, tokenGenerator: tokenGenerator}
+	cursorCodec, err := pagination.NewCodec(config.CursorSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cursor codec: %w", err)
+	}
+
+	server := &Server{
+		config:         config,
+		store:          store,
+		tokenGenerator: tokenGenerator,
+		loginGuard:     loginguard.New(store, config),
+		passwordHasher: config.PasswordHasher(),
+		rateLimiter:    ratelimit.NewLimiter(config.RateLimits),
+		healthChecker:  healthChecker,
+		cursorCodec:    cursorCodec,
+	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("account_number", validAccountNumber)
+		v.RegisterValidation("creditor_reference", validCreditorReference)
 	}
 
-	server.setupRouter()
+	if err := server.setupRouter(); err != nil {
+		return nil, fmt.Errorf("cannot set up router: %w", err)
+	}
 
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
-	router := gin.Default()
+func (server *Server) setupRouter() error {
+	router := gin.New()
+	// the client IP is only taken from X-Forwarded-For behind a trusted proxy,
+	// so that clients cannot pick the IP their logins and rate limits are tracked under
+	if err := router.SetTrustedProxies(server.config.TrustedProxies); err != nil {
+		return err
+	}
+	// let handlers pass the gin context on with the values of the request context
+	router.ContextWithFallback = true
+	router.Use(tracingMiddleware(), requestLogger(), metricsMiddleware(), gin.CustomRecoveryWithWriter(io.Discard, recoveryHandler))
+
 	// routes
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenGenerator))
+	publicRoutes := router.Group("/").Use(rateLimitMiddleware(server.rateLimiter))
+
+	publicRoutes.POST("/users", server.createUser)
+	publicRoutes.POST("/users/login", server.loginUser)
+
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenGenerator), rateLimitMiddleware(server.rateLimiter))
 
 	authRoutes.POST("/bank_accounts", server.createBankAccount)
 	authRoutes.GET("/bank_accounts/:id", server.getBankAccount)
+	authRoutes.PATCH("/bank_accounts/:id", server.updateBankAccount)
+	authRoutes.GET("/account_products", server.listAccountProducts)
 	authRoutes.GET("/bank_accounts", server.listBankAccounts)
+	authRoutes.GET("/bank_accounts/:id/entries", server.listEntries)
+	authRoutes.GET("/bank_accounts/:id/statement", server.exportStatement)
+	authRoutes.GET("/bank_accounts/:id/transfers", server.listAccountTransfers)
+	authRoutes.POST("/bank_accounts/:id/close", server.closeBankAccount)
+	authRoutes.PATCH("/entries/:id", server.updateEntry)
+
+	authRoutes.POST("/users/totp", server.enrollTOTP)
+
+	authRoutes.POST("/payees", server.createPayee)
+	authRoutes.GET("/payees", server.listPayees)
+	authRoutes.GET("/payees/:id", server.getPayee)
+	authRoutes.PATCH("/payees/:id", server.updatePayee)
+	authRoutes.DELETE("/payees/:id", server.deletePayee)
+	authRoutes.POST("/payees/:id/confirm", server.confirmPayee)
 
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/quote", server.quoteTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.POST("/transfer_batches", server.createTransferBatch)
+	authRoutes.GET("/transfer_batches/:id", server.getTransferBatch)
+
+	authRoutes.POST("/holds", server.authorizeTransfer)
+	authRoutes.GET("/holds/:id", server.getHold)
+	authRoutes.POST("/holds/:id/capture", server.captureTransfer)
+	authRoutes.POST("/holds/:id/void", server.voidTransfer)
+	authRoutes.POST("/step_up_challenges/:id/verify", server.verifyStepUpChallenge)
 
 	server.router = router
+	return nil
+}
+
+// Handler returns the HTTP handler of the server.
+func (server *Server) Handler() http.Handler {
+	return server.router
 }
 
 // Start runs the HTTP server on a specific address.
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+)
+
+const (
+	stepUpMethodPassword = "password"
+	stepUpMethodTOTP     = "totp"
+	totpIssuer           = "SimpleBank"
+	// defaultStepUpMaxAttempts is used when STEP_UP_MAX_ATTEMPTS is not set
+	defaultStepUpMaxAttempts = 5
+)
+
+type stepUpChallengeResponse struct {
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	Method        string     `json:"method,omitempty"`
+	Currency      string     `json:"currency"`
+	BatchHash     string     `json:"batch_hash,omitempty"`
+	ToAccountID   *int64     `json:"to_account_id,omitempty"`
+	FromAccountID int64      `json:"from_account_id"`
+	Amount        int64      `json:"amount"`
+	ID            uuid.UUID  `json:"id"`
+}
+
+func newStepUpChallengeResponse(challenge db.StepUpChallenge) stepUpChallengeResponse {
+	rsp := stepUpChallengeResponse{
+		ID:            challenge.ID,
+		FromAccountID: challenge.FromAccountID,
+		BatchHash:     challenge.BatchHash.String,
+		Amount:        challenge.Amount,
+		Currency:      challenge.Currency,
+		Method:        challenge.Method.String,
+		ExpiresAt:     challenge.ExpiresAt,
+	}
+	if challenge.ToAccountID.Valid {
+		rsp.ToAccountID = &challenge.ToAccountID.Int64
+	}
+	if challenge.VerifiedAt.Valid {
+		rsp.VerifiedAt = &challenge.VerifiedAt.Time
+	}
+	return rsp
+}
+
+type stepUpRequiredResponse struct {
+	Error     string                  `json:"error"`
+	Challenge stepUpChallengeResponse `json:"challenge"`
+}
+
+// requireStepUp checks that a transfer above the step-up threshold carries a verified challenge
+// issued for exactly this transfer. If no challenge is given, a new one is issued to the client.
+func (server *Server) requireStepUp(ctx *gin.Context, req transferRequest, username string) (uuid.UUID, bool) {
+	return server.requireStepUpFor(ctx, req.StepUpChallengeID, db.CreateStepUpChallengeParams{
+		Username:      username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   pgtype.Int8{Int64: req.ToAccountID, Valid: true},
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+	})
+}
+
+// requireStepUpFor checks that the challenge of the ID was verified for exactly the action,
+// a transfer to an account or a batch of the hash. If no ID is given, a new one is issued.
+func (server *Server) requireStepUpFor(ctx *gin.Context, id string, action db.CreateStepUpChallengeParams) (uuid.UUID, bool) {
+	if id == "" {
+		server.issueStepUpChallenge(ctx, action)
+		return uuid.Nil, false
+	}
+
+	challengeID, err := uuid.Parse(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return uuid.Nil, false
+	}
+
+	challenge, err := server.store.GetStepUpChallenge(ctx, challengeID)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return uuid.Nil, false
+	}
+
+	if challenge.Username != action.Username {
+		err := errors.New("step-up challenge doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return uuid.Nil, false
+	}
+
+	if challenge.FromAccountID != action.FromAccountID ||
+		challenge.ToAccountID != action.ToAccountID ||
+		challenge.BatchHash != action.BatchHash ||
+		challenge.Amount != action.Amount ||
+		challenge.Currency != action.Currency {
+		err := errors.New("step-up challenge was issued for a different transfer")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return uuid.Nil, false
+	}
+
+	if !challenge.VerifiedAt.Valid {
+		err := errors.New("step-up challenge is not verified")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return uuid.Nil, false
+	}
+
+	if challenge.ConsumedAt.Valid {
+		err := errors.New("step-up challenge was already used")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return uuid.Nil, false
+	}
+
+	if time.Now().After(challenge.ExpiresAt) {
+		err := errors.New("expired step-up challenge")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return uuid.Nil, false
+	}
+
+	return challenge.ID, true
+}
+
+func (server *Server) issueStepUpChallenge(ctx *gin.Context, action db.CreateStepUpChallengeParams) {
+	challengeID, err := uuid.NewRandom()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	action.ID = challengeID
+	action.ExpiresAt = time.Now().Add(server.config.StepUpChallengeDuration)
+	challenge, err := server.store.CreateStepUpChallenge(ctx, action)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := stepUpRequiredResponse{
+		Error:     "step-up authentication required",
+		Challenge: newStepUpChallengeResponse(challenge),
+	}
+	ctx.JSON(http.StatusForbidden, rsp)
+}
+
+type verifyStepUpChallengeURI struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// stepUpCredentialsRequest holds the password or the TOTP code the user confirms a sensitive action with
+type stepUpCredentialsRequest struct {
+	Password string `json:"password" binding:"required_without=TOTPCode"`
+	TOTPCode string `json:"totp_code" binding:"required_without=Password,omitempty,len=6,numeric"`
+}
+
+func (server *Server) verifyStepUpChallenge(ctx *gin.Context) {
+	var uri verifyStepUpChallengeURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req stepUpCredentialsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	challenge, err := server.store.GetStepUpChallenge(ctx, uuid.MustParse(uri.ID))
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	if challenge.Username != authPayload.Username {
+		err := errors.New("step-up challenge doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	if challenge.VerifiedAt.Valid {
+		err := errors.New("step-up challenge is already verified")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	if time.Now().After(challenge.ExpiresAt) {
+		err := errors.New("expired step-up challenge")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// every attempt is counted before the credentials are checked, so that a challenge
+	// cannot be used to guess the password or the TOTP code, even with parallel requests
+	_, err = server.store.AttemptStepUpChallenge(ctx, db.AttemptStepUpChallengeParams{
+		ID:          challenge.ID,
+		MaxAttempts: server.stepUpMaxAttempts(),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("step-up challenge has no attempts left, request a new one")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	method, ok := server.checkStepUpCredentials(ctx, authPayload.Username, req)
+	if !ok {
+		return
+	}
+
+	challenge, err = server.store.VerifyStepUpChallenge(ctx, db.VerifyStepUpChallengeParams{
+		ID:     challenge.ID,
+		Method: method,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("step-up challenge is already verified")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newStepUpChallengeResponse(challenge))
+}
+
+// checkStepUpCredentials checks the password or the TOTP code of the user, and returns the step-up method used
+func (server *Server) checkStepUpCredentials(ctx *gin.Context, username string, req stepUpCredentialsRequest) (string, bool) {
+	user, err := server.store.GetUser(ctx, username)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return "", false
+	}
+
+	if req.TOTPCode == "" {
+		if err := tools.CheckPassword(req.Password, user.HashedPassword); err != nil {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return "", false
+		}
+		return stepUpMethodPassword, true
+	}
+
+	if !user.TotpSecret.Valid {
+		err := errors.New("totp is not enabled for this user")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return "", false
+	}
+	step, ok := tools.ValidateTOTP(user.TotpSecret.String, req.TOTPCode, time.Now())
+	if !ok {
+		err := errors.New("incorrect totp code")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return "", false
+	}
+
+	_, err = server.store.UseUserTOTPStep(ctx, db.UseUserTOTPStepParams{
+		Username: username,
+		Step:     step,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("totp code was already used, wait for the next one")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return "", false
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return "", false
+	}
+	return stepUpMethodTOTP, true
+}
+
+func (server *Server) stepUpMaxAttempts() int32 {
+	if server.config.StepUpMaxAttempts > 0 {
+		return server.config.StepUpMaxAttempts
+	}
+	return defaultStepUpMaxAttempts
+}
+
+type enrollTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type enrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// enrollTOTP enables TOTP for the authenticated user, or replaces its secret. The password is
+// required so that a stolen access token cannot enroll a secret and pass the step-up challenges.
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	var req enrollTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if _, ok := server.checkStepUpCredentials(ctx, authPayload.Username, stepUpCredentialsRequest{Password: req.Password}); !ok {
+		return
+	}
+
+	secret, err := tools.GenerateTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		Username:   authPayload.Username,
+		TotpSecret: secret,
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := enrollTOTPResponse{
+		Secret: secret,
+		URI:    tools.TOTPURI(totpIssuer, user.Username, secret),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
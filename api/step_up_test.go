@@ -0,0 +1,342 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyStepUpChallengeAPI(t *testing.T) {
+	user, password := randomUser(t)
+	otherUser, _ := randomUser(t)
+
+	secret, err := tools.GenerateTOTPSecret()
+	require.NoError(t, err)
+	totpUser := user
+	totpUser.TotpSecret = pgtype.Text{String: secret, Valid: true}
+
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(otherUser.Username)
+	challenge := randomStepUpChallenge(user.Username, account1, account2, 5000)
+
+	attemptArg := db.AttemptStepUpChallengeParams{ID: challenge.ID, MaxAttempts: defaultStepUpMaxAttempts}
+
+	verified := func(method string) db.StepUpChallenge {
+		c := challenge
+		c.Method = pgtype.Text{String: method, Valid: true}
+		c.VerifiedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+		return c
+	}
+
+	testCases := []struct {
+		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Token)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		body          func() gin.H
+		name          string
+	}{
+		{
+			name: "PasswordOK",
+			body: func() gin.H { return gin.H{"password": password} },
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().AttemptStepUpChallenge(gomock.Any(), gomock.Eq(attemptArg)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+
+				arg := db.VerifyStepUpChallengeParams{ID: challenge.ID, Method: stepUpMethodPassword}
+				store.EXPECT().
+					VerifyStepUpChallenge(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(verified(stepUpMethodPassword), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp stepUpChallengeResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, stepUpMethodPassword, rsp.Method)
+				require.NotNil(t, rsp.VerifiedAt)
+			},
+		},
+		{
+			name: "TOTPOK",
+			body: func() gin.H {
+				code, err := tools.TOTPCode(secret, time.Now())
+				require.NoError(t, err)
+				return gin.H{"totp_code": code}
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().AttemptStepUpChallenge(gomock.Any(), gomock.Eq(attemptArg)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpUser, nil)
+				store.EXPECT().
+					UseUserTOTPStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UseUserTOTPStepParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.InDelta(t, time.Now().Unix()/30, arg.Step, 1)
+						return totpUser, nil
+					})
+
+				arg := db.VerifyStepUpChallengeParams{ID: challenge.ID, Method: stepUpMethodTOTP}
+				store.EXPECT().
+					VerifyStepUpChallenge(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(verified(stepUpMethodTOTP), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// the code was already accepted for its time step, by this challenge or another one
+			name: "TOTPReplayed",
+			body: func() gin.H {
+				code, err := tools.TOTPCode(secret, time.Now())
+				require.NoError(t, err)
+				return gin.H{"totp_code": code}
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().AttemptStepUpChallenge(gomock.Any(), gomock.Eq(attemptArg)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpUser, nil)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().VerifyStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "already used")
+			},
+		},
+		{
+			name: "NoAttemptsLeft",
+			body: func() gin.H { return gin.H{"password": password} },
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().
+					AttemptStepUpChallenge(gomock.Any(), gomock.Eq(attemptArg)).
+					Times(1).
+					Return(db.StepUpChallenge{}, db.ErrRecordNotFound)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().VerifyStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "no attempts left")
+			},
+		},
+		{
+			name: "TOTPNotEnabled",
+			body: func() gin.H { return gin.H{"totp_code": "123456"} },
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().AttemptStepUpChallenge(gomock.Any(), gomock.Eq(attemptArg)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().VerifyStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "WrongPassword",
+			body: func() gin.H { return gin.H{"password": "wrong-password"} },
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().AttemptStepUpChallenge(gomock.Any(), gomock.Eq(attemptArg)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().VerifyStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwner",
+			body: func() gin.H { return gin.H{"password": password} },
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, otherUser.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().VerifyStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Expired",
+			body: func() gin.H { return gin.H{"password": password} },
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := challenge
+				expired.ExpiresAt = time.Now().Add(-time.Second)
+
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(expired, nil)
+				store.EXPECT().VerifyStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingProof",
+			body: func() gin.H { return gin.H{} },
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body())
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/step_up_challenges/%s/verify", challenge.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenGenerator)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		body          gin.H
+		name          string
+	}{
+		{
+			name: "OK",
+			body: gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					SetUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.SetUserTOTPSecretParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						enrolled := user
+						enrolled.TotpSecret = pgtype.Text{String: arg.TotpSecret, Valid: true}
+						return enrolled, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp enrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+				require.Contains(t, rsp.URI, rsp.Secret)
+			},
+		},
+		{
+			name: "WrongPassword",
+			body: gin.H{"password": "wrong-password"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingPassword",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/totp", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, user.Username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -1,21 +1,34 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/radugaf/simplebank/apierror"
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
 	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
 )
 
 type transferRequest struct {
 	Currency      string `json:"currency" binding:"required,currency"`
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	// The recipient account is addressed either by its ID, by its account number or by a payee of the user
+	ToAccountID     int64  `json:"to_account_id" binding:"required_without_all=ToAccountNumber PayeeID,omitempty,min=1"`
+	ToAccountNumber string `json:"to_account_number" binding:"excluded_with=ToAccountID PayeeID,omitempty,account_number"`
+	PayeeID         int64  `json:"payee_id" binding:"excluded_with=ToAccountID ToAccountNumber,omitempty,min=1"`
+	Amount          int64  `json:"amount" binding:"required,gt=0"`
+	// Memo is free text for the users, Reference a structured ISO 11649 creditor reference
+	Memo      string `json:"memo" binding:"max=140"`
+	Reference string `json:"reference" binding:"omitempty,creditor_reference"`
+	// StepUpChallengeID is the verified challenge required for transfers above the step-up threshold
+	StepUpChallengeID string `json:"step_up_challenge_id" binding:"omitempty,uuid"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -26,55 +39,383 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	fromAccount, valid := server.validBankAccount(ctx, req.FromAccountID, req.Currency)
+	toAccount, valid := server.transferAccounts(ctx, req)
 	if !valid {
 		return
 	}
+	req.ToAccountID = toAccount.ID
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+
+	transferArg := db.CreateTransferParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Memo:          req.Memo,
+		Reference:     tools.NormalizeCreditorReference(req.Reference),
+	}
+
+	if threshold, ok := server.config.StepUpThresholds.Get(req.Currency); ok && req.Amount > threshold {
+		challengeID, valid := server.requireStepUp(ctx, req, authPayload.Username)
+		if !valid {
+			return
+		}
+		transferArg.StepUpChallengeID = uuid.NullUUID{UUID: challengeID, Valid: true}
+	}
+
+	transfer, err := server.store.TransferTx(ctx, transferArg)
+	if err != nil {
+		if errors.Is(err, db.ErrStepUpChallengeUnusable) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		// tell the client which limit was hit and how much it can still send
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(apierror.HTTPStatus(err), gin.H{"error": err.Error(), "limit": limitErr})
+			return
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
 		return
 	}
 
-	_, valid = server.validBankAccount(ctx, req.ToAccountID, req.Currency)
+	ctx.JSON(http.StatusOK, newTransferResponse(transfer))
+}
+
+// transferResponse is the result of a transfer. The recipient account can belong to
+// another user, so it is only addressed by its account number, never by its ID.
+type transferResponse struct {
+	FromAccount db.BankAccount           `json:"from_account"`
+	ToAccount   recipientAccountResponse `json:"to_account"`
+	Transfer    sentTransferResponse     `json:"transfer"`
+	FromEntry   db.Entry                 `json:"from_entry"`
+	ToEntry     recipientEntryResponse   `json:"to_entry"`
+	FeeEntry    *db.Entry                `json:"fee_entry,omitempty"`
+}
+
+type recipientAccountResponse struct {
+	AccountNumber string `json:"account_number"`
+	Currency      string `json:"currency"`
+}
+
+// sentTransferResponse is a transfer with the recipient account given by its account number
+type sentTransferResponse struct {
+	CreatedAt       time.Time `json:"created_at"`
+	ToAccountNumber string    `json:"to_account_number"`
+	Memo            string    `json:"memo"`
+	Reference       string    `json:"reference"`
+	ID              int64     `json:"id"`
+	FromAccountID   int64     `json:"from_account_id"`
+	Amount          int64     `json:"amount"`
+	Fee             int64     `json:"fee"`
+}
+
+// recipientEntryResponse is the entry of a transfer on the recipient account, without
+// the category chosen by the owner of the account
+type recipientEntryResponse struct {
+	CreatedAt     time.Time `json:"created_at"`
+	AccountNumber string    `json:"account_number"`
+	ID            int64     `json:"id"`
+	Amount        int64     `json:"amount"`
+}
+
+func newTransferResponse(result db.TransferTxResult) transferResponse {
+	return transferResponse{
+		FromAccount: result.FromAccount,
+		ToAccount: recipientAccountResponse{
+			AccountNumber: result.ToAccount.AccountNumber,
+			Currency:      result.ToAccount.Currency,
+		},
+		Transfer: sentTransferResponse{
+			ID:              result.Transfer.ID,
+			FromAccountID:   result.Transfer.FromAccountID,
+			ToAccountNumber: result.ToAccount.AccountNumber,
+			Amount:          result.Transfer.Amount,
+			Fee:             result.Transfer.Fee,
+			Memo:            result.Transfer.Memo,
+			Reference:       result.Transfer.Reference,
+			CreatedAt:       result.Transfer.CreatedAt,
+		},
+		FromEntry: result.FromEntry,
+		ToEntry: recipientEntryResponse{
+			ID:            result.ToEntry.ID,
+			AccountNumber: result.ToAccount.AccountNumber,
+			Amount:        result.ToEntry.Amount,
+			CreatedAt:     result.ToEntry.CreatedAt,
+		},
+		FeeEntry: result.FeeEntry,
+	}
+}
+
+type transferQuoteResponse struct {
+	Amount   int64  `json:"amount"`
+	Fee      int64  `json:"fee"`
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+// quoteTransfer previews the fee of a transfer, given the same body as the transfer itself
+func (server *Server) quoteTransfer(ctx *gin.Context) {
+	var req transferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	toAccount, valid := server.transferAccounts(ctx, req)
 	if !valid {
 		return
 	}
 
-	transferArg := db.CreateTransferParams{
+	fee, _, err := db.TransferFee(ctx, server.store, db.CreateTransferParams{
 		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
+		ToAccountID:   toAccount.ID,
 		Amount:        req.Amount,
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
 	}
 
-	transfer, err := server.store.TransferTx(ctx, transferArg)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	if fee > math.MaxInt64-req.Amount {
+		err := errors.New("amount and fee of the transfer are too large")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, transfer)
+	rsp := transferQuoteResponse{
+		Amount:   req.Amount,
+		Fee:      fee,
+		Total:    req.Amount + fee,
+		Currency: req.Currency,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// transferAccounts checks that the from account belongs to the authenticated user,
+// and returns the account receiving the transfer
+func (server *Server) transferAccounts(ctx *gin.Context, req transferRequest) (db.BankAccount, bool) {
+	if _, valid := server.senderBankAccount(ctx, req.FromAccountID, req.Currency); !valid {
+		return db.BankAccount{}, false
+	}
+
+	return server.recipientBankAccount(ctx, req)
+}
+
+// senderBankAccount gets the account sending a transfer, which must be an active
+// account of the authenticated user in the currency of the transfer
+func (server *Server) senderBankAccount(ctx *gin.Context, accountID int64, currency string) (db.BankAccount, bool) {
+	fromAccount, valid := server.validBankAccount(ctx, accountID, currency)
+	if !valid {
+		return db.BankAccount{}, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return db.BankAccount{}, false
+	}
+
+	return fromAccount, true
 }
 
 func (server *Server) validBankAccount(ctx *gin.Context, accountID int64, currency string) (db.BankAccount, bool) {
 	account, err := server.store.GetBankAccount(ctx, accountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return account, false
+	}
+
+	return account, checkBankAccount(ctx, account, fmt.Sprintf("[%d]", accountID), currency)
+}
+
+// recipientBankAccount gets the account receiving the transfer, by its account number
+// or through the payee if one is given
+func (server *Server) recipientBankAccount(ctx *gin.Context, req transferRequest) (db.BankAccount, bool) {
+	accountNumber := tools.NormalizeAccountNumber(req.ToAccountNumber)
+
+	switch {
+	case req.PayeeID != 0:
+		payee, ok := server.ownedPayee(ctx, req.PayeeID)
+		if !ok {
+			return db.BankAccount{}, false
 		}
+		if !payee.Payee.ConfirmedAt.Valid {
+			err := fmt.Errorf("payee [%d] is not confirmed", payee.Payee.ID)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return db.BankAccount{}, false
+		}
+		accountNumber = payee.Payee.AccountNumber
+	case accountNumber == "":
+		return server.validBankAccount(ctx, req.ToAccountID, req.Currency)
+	}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	account, err := server.store.GetBankAccountByNumber(ctx, accountNumber)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
 		return account, false
 	}
 
+	return account, checkBankAccount(ctx, account, accountNumber, req.Currency)
+}
+
+// checkBankAccount responds with an error unless the account is active and in the currency of the transfer.
+// The errors name the account as the client addressed it, an account addressed by its number
+// can belong to another user whose account ID and status must not be disclosed.
+func checkBankAccount(ctx *gin.Context, account db.BankAccount, addressedAs string, currency string) bool {
 	if account.Currency != currency {
-		err := fmt.Errorf("bank account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		err := fmt.Errorf("bank account %s currency mismatch: %s vs %s", addressedAs, account.Currency, currency)
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return account, false
+		return false
+	}
+
+	if account.Status != db.AccountStatusActive {
+		err := fmt.Errorf("bank account %s: %w", addressedAs, db.ErrAccountNotActive)
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+type listAccountTransfersURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listAccountTransfersRequest struct {
+	pageRequest
+	rangeRequest
+	CounterpartyID int64 `form:"counterparty_id" binding:"omitempty,min=1"`
+}
+
+type listTransfersResponse struct {
+	Transfers  []db.Transfer `json:"transfers"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// listAccountTransfers lists the transfers from or to an account of the authenticated user, newest first
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	var uri listAccountTransfersURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scope := pagination.Scope("transfers", uri.ID)
+	cursor, ok := server.decodeCursor(ctx, scope, req.pageRequest)
+	if !ok {
+		return
+	}
+
+	if _, ok := server.ownedBankAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	transfers, err := server.store.ListTransfers(ctx, db.ListTransfersParams{
+		AccountID:       uri.ID,
+		CounterpartyID:  db.NullInt64(req.CounterpartyID),
+		FromTime:        db.NullTime(req.FromTime),
+		ToTime:          db.NullTime(req.ToTime),
+		MinAmount:       db.NullInt64(req.MinAmount),
+		MaxAmount:       db.NullInt64(req.MaxAmount),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.PageSize),
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
 	}
 
-	return account, true
+	rsp := listTransfersResponse{}
+	rsp.Transfers, rsp.NextCursor = pagination.Page(server.cursorCodec, scope, transfers, req.PageSize, transferCursor)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransfer gets a transfer from or to an account of the authenticated user.
+// Other transfers are reported as not found, so that their IDs are not disclosed.
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	transfer, err := server.store.GetOwnerTransfer(ctx, db.GetOwnerTransferParams{
+		ID:    req.ID,
+		Owner: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
+
+// listTransfersRequest filters the transfers of the authenticated user. The direction is
+// relative to their accounts, or to the account of AccountID if it is set.
+type listTransfersRequest struct {
+	pageRequest
+	rangeRequest
+	AccountID int64  `form:"account_id" binding:"omitempty,min=1"`
+	Direction string `form:"direction" binding:"omitempty,oneof=in out"`
+	Currency  string `form:"currency" binding:"omitempty,currency"`
+}
+
+// listTransfers lists the transfers from or to any account of the authenticated user, newest first
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	scope := pagination.Scope("owner_transfers", authPayload.Username)
+	cursor, ok := server.decodeCursor(ctx, scope, req.pageRequest)
+	if !ok {
+		return
+	}
+
+	transfers, err := server.store.ListOwnerTransfers(ctx, db.ListOwnerTransfersParams{
+		Owner:           authPayload.Username,
+		Direction:       db.NullString(req.Direction),
+		AccountID:       db.NullInt64(req.AccountID),
+		Currency:        db.NullString(req.Currency),
+		FromTime:        db.NullTime(req.FromTime),
+		ToTime:          db.NullTime(req.ToTime),
+		MinAmount:       db.NullInt64(req.MinAmount),
+		MaxAmount:       db.NullInt64(req.MaxAmount),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.PageSize),
+	})
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp := listTransfersResponse{}
+	rsp.Transfers, rsp.NextCursor = pagination.Page(server.cursorCodec, scope, transfers, req.PageSize, transferCursor)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func transferCursor(transfer db.Transfer) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: transfer.CreatedAt, ID: transfer.ID}
 }
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+)
+
+// transferBatchItemRequest is a transfer of a batch, its recipient is addressed like the one of a single transfer
+type transferBatchItemRequest struct {
+	ToAccountID     int64  `json:"to_account_id" binding:"required_without_all=ToAccountNumber PayeeID,omitempty,min=1"`
+	ToAccountNumber string `json:"to_account_number" binding:"excluded_with=ToAccountID PayeeID,omitempty,account_number"`
+	PayeeID         int64  `json:"payee_id" binding:"excluded_with=ToAccountID ToAccountNumber,omitempty,min=1"`
+	Amount          int64  `json:"amount" binding:"required,gt=0"`
+	Memo            string `json:"memo" binding:"max=140"`
+	Reference       string `json:"reference" binding:"omitempty,creditor_reference"`
+}
+
+type createTransferBatchRequest struct {
+	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	// Mode is atomic to transfer all the items or none, per_item to transfer every item that can be
+	Mode  string                     `json:"mode" binding:"required,oneof=atomic per_item"`
+	Items []transferBatchItemRequest `json:"items" binding:"required,min=1,dive"`
+	// StepUpChallengeID is the verified challenge of a batch whose total is above the step-up threshold
+	StepUpChallengeID string `json:"step_up_challenge_id" binding:"omitempty,uuid"`
+}
+
+// transferBatchResponse is a batch with the outcome of its items. The recipient accounts
+// can belong to other users, so the items give them by their account numbers.
+type transferBatchResponse struct {
+	Batch db.TransferBatch            `json:"batch"`
+	Items []transferBatchItemResponse `json:"items"`
+}
+
+type transferBatchItemResponse struct {
+	TransferID      *int64 `json:"transfer_id,omitempty"`
+	ToAccountNumber string `json:"to_account_number"`
+	Memo            string `json:"memo"`
+	Reference       string `json:"reference"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	Amount          int64  `json:"amount"`
+	Position        int32  `json:"position"`
+}
+
+// newTransferBatchResponse looks up the account numbers of the recipients of the batch
+func (server *Server) newTransferBatchResponse(ctx *gin.Context, batch db.TransferBatch, items []db.TransferBatchItem) (transferBatchResponse, error) {
+	recipients, err := server.store.ListTransferBatchRecipients(ctx, batch.ID)
+	if err != nil {
+		return transferBatchResponse{}, err
+	}
+
+	accountNumbers := make(map[int64]string, len(recipients))
+	for _, recipient := range recipients {
+		accountNumbers[recipient.ID] = recipient.AccountNumber
+	}
+
+	rsp := transferBatchResponse{
+		Batch: batch,
+		Items: make([]transferBatchItemResponse, len(items)),
+	}
+	for i, item := range items {
+		rsp.Items[i] = transferBatchItemResponse{
+			Position:        item.Position,
+			ToAccountNumber: accountNumbers[item.ToAccountID],
+			Amount:          item.Amount,
+			Memo:            item.Memo,
+			Reference:       item.Reference,
+			Status:          item.Status,
+			Error:           item.Error,
+		}
+		if item.TransferID.Valid {
+			rsp.Items[i].TransferID = &item.TransferID.Int64
+		}
+	}
+	return rsp, nil
+}
+
+type invalidTransferBatchResponse struct {
+	Error string                      `json:"error"`
+	Items []db.TransferBatchItemError `json:"items"`
+}
+
+// createTransferBatch runs many transfers from one account of the authenticated user. Every item
+// is checked before any of them runs, and the batch is stored so that its outcome can be fetched again.
+func (server *Server) createTransferBatch(ctx *gin.Context) {
+	var req createTransferBatchRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if len(req.Items) > db.MaxTransferBatchItems {
+		err := fmt.Errorf("a batch has at most %d items", db.MaxTransferBatchItems)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.senderBankAccount(ctx, req.FromAccountID, req.Currency); !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.TransferBatchTxParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		Currency:      req.Currency,
+		Mode:          req.Mode,
+		Items:         make([]db.TransferBatchItemParams, len(req.Items)),
+	}
+
+	for i, item := range req.Items {
+		arg.Items[i] = db.TransferBatchItemParams{
+			ToAccountID:     item.ToAccountID,
+			ToAccountNumber: tools.NormalizeAccountNumber(item.ToAccountNumber),
+			PayeeID:         item.PayeeID,
+			Amount:          item.Amount,
+			Memo:            item.Memo,
+			Reference:       tools.NormalizeCreditorReference(item.Reference),
+		}
+	}
+
+	total, ok := arg.TotalAmount()
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, errorResponse(db.ErrTransferBatchTooLarge))
+		return
+	}
+
+	if threshold, ok := server.config.StepUpThresholds.Get(req.Currency); ok && total > threshold {
+		challengeID, valid := server.requireStepUpFor(ctx, req.StepUpChallengeID, db.CreateStepUpChallengeParams{
+			Username:      authPayload.Username,
+			FromAccountID: req.FromAccountID,
+			BatchHash:     pgtype.Text{String: arg.Hash(), Valid: true},
+			Amount:        total,
+			Currency:      req.Currency,
+		})
+		if !valid {
+			return
+		}
+		arg.StepUpChallengeID = uuid.NullUUID{UUID: challengeID, Valid: true}
+	}
+
+	result, err := server.store.TransferBatchTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrStepUpChallengeUnusable) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		var validationErr *db.TransferBatchValidationError
+		if errors.As(err, &validationErr) {
+			ctx.JSON(apierror.HTTPStatus(err), invalidTransferBatchResponse{Error: err.Error(), Items: validationErr.Items})
+			return
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp, err := server.newTransferBatchResponse(ctx, result.Batch, result.Items)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getTransferBatchRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransferBatch gets a batch of the authenticated user with the outcome of its items
+func (server *Server) getTransferBatch(ctx *gin.Context) {
+	var req getTransferBatchRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, err := server.store.GetTransferBatch(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if batch.Owner != authPayload.Username {
+		err := errors.New("transfer batch doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	rsp, err := server.newTransferBatchResponse(ctx, batch, items)
+	if err != nil {
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
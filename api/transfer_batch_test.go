@@ -0,0 +1,458 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransferBatchAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account := randomAccount(user1.Username)
+	account.Currency = tools.USD
+	recipient := randomAccount(user2.Username)
+
+	items := []gin.H{
+		{"to_account_id": recipient.ID, "amount": 100, "memo": "salary"},
+		{"to_account_number": recipient.AccountNumber, "amount": 200},
+	}
+
+	stepUpItems := []gin.H{
+		{"to_account_id": recipient.ID, "amount": 600},
+		{"to_account_id": recipient.ID, "amount": 401},
+	}
+	stepUpBatch := db.TransferBatchTxParams{
+		Owner:         user1.Username,
+		FromAccountID: account.ID,
+		Currency:      tools.USD,
+		Mode:          db.TransferBatchModeAtomic,
+		Items: []db.TransferBatchItemParams{
+			{ToAccountID: recipient.ID, Amount: 600},
+			{ToAccountID: recipient.ID, Amount: 401},
+		},
+	}
+	batchChallenge := db.StepUpChallenge{
+		ID:            uuid.New(),
+		Username:      user1.Username,
+		FromAccountID: account.ID,
+		BatchHash:     pgtype.Text{String: stepUpBatch.Hash(), Valid: true},
+		Amount:        1001,
+		Currency:      tools.USD,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	verifiedBatchChallenge := batchChallenge
+	verifiedBatchChallenge.ID = uuid.New()
+	verifiedBatchChallenge.VerifiedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	tooMany := make([]gin.H, db.MaxTransferBatchItems+1)
+	for i := range tooMany {
+		tooMany[i] = gin.H{"to_account_id": recipient.ID, "amount": 1}
+	}
+
+	testCases := []struct {
+		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Token)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+		body          gin.H
+		name          string
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            db.TransferBatchModePerItem,
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.TransferBatchTxParams{
+					Owner:         user1.Username,
+					FromAccountID: account.ID,
+					Currency:      tools.USD,
+					Mode:          db.TransferBatchModePerItem,
+					Items: []db.TransferBatchItemParams{
+						{ToAccountID: recipient.ID, Amount: 100, Memo: "salary"},
+						{ToAccountNumber: recipient.AccountNumber, Amount: 200},
+					},
+				}
+				result := db.TransferBatchTxResult{
+					Batch: db.TransferBatch{
+						ID:             1,
+						Owner:          user1.Username,
+						FromAccountID:  account.ID,
+						Currency:       tools.USD,
+						Mode:           db.TransferBatchModePerItem,
+						Status:         db.TransferBatchStatusCompleted,
+						ItemCount:      2,
+						TotalAmount:    300,
+						SucceededCount: 2,
+					},
+					Items: []db.TransferBatchItem{
+						{BatchID: 1, Position: 0, ToAccountID: recipient.ID, Amount: 100, Memo: "salary", Status: db.TransferBatchItemStatusSucceeded, TransferID: pgtype.Int8{Int64: 7, Valid: true}},
+						{BatchID: 1, Position: 1, ToAccountID: recipient.ID, Amount: 200, Status: db.TransferBatchItemStatusSucceeded, TransferID: pgtype.Int8{Int64: 8, Valid: true}},
+					},
+				}
+
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+				store.EXPECT().ListTransferBatchRecipients(gomock.Any(), gomock.Eq(int64(1))).
+					Times(1).
+					Return([]db.ListTransferBatchRecipientsRow{{ID: recipient.ID, AccountNumber: recipient.AccountNumber}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferBatchStatusCompleted, rsp.Batch.Status)
+				require.Equal(t, int32(2), rsp.Batch.SucceededCount)
+
+				// the recipient belongs to another user, the items do not disclose its ID
+				require.Len(t, rsp.Items, 2)
+				for _, item := range rsp.Items {
+					require.Equal(t, recipient.AccountNumber, item.ToAccountNumber)
+				}
+				require.Equal(t, int64(8), *rsp.Items[1].TransferID)
+				require.NotContains(t, recorder.Body.String(), "toAccountID")
+			},
+		},
+		{
+			name: "InvalidRecipients",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            db.TransferBatchModeAtomic,
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					TransferBatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferBatchTxResult{}, &db.TransferBatchValidationError{
+						Items: []db.TransferBatchItemError{{Index: 1, Error: "recipient account not found"}},
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var rsp invalidTransferBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, []db.TransferBatchItemError{{Index: 1, Error: "recipient account not found"}}, rsp.Items)
+			},
+		},
+		{
+			// every item is below the threshold of 1000, the total is not
+			name: "StepUpTotal",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            db.TransferBatchModeAtomic,
+				"items":           stepUpItems,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateStepUpChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateStepUpChallengeParams) (db.StepUpChallenge, error) {
+						require.Equal(t, user1.Username, arg.Username)
+						require.Equal(t, account.ID, arg.FromAccountID)
+						require.False(t, arg.ToAccountID.Valid)
+						require.Equal(t, stepUpBatch.Hash(), arg.BatchHash.String)
+						require.Equal(t, int64(1001), arg.Amount)
+						return batchChallenge, nil
+					})
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				var rsp stepUpRequiredResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, batchChallenge.ID, rsp.Challenge.ID)
+				require.Equal(t, stepUpBatch.Hash(), rsp.Challenge.BatchHash)
+				require.Nil(t, rsp.Challenge.ToAccountID)
+			},
+		},
+		{
+			name: "StepUpVerified",
+			body: gin.H{
+				"from_account_id":      account.ID,
+				"currency":             tools.USD,
+				"mode":                 db.TransferBatchModeAtomic,
+				"items":                stepUpItems,
+				"step_up_challenge_id": verifiedBatchChallenge.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := stepUpBatch
+				arg.StepUpChallengeID = uuid.NullUUID{UUID: verifiedBatchChallenge.ID, Valid: true}
+				result := db.TransferBatchTxResult{
+					Batch: db.TransferBatch{ID: 2, Owner: user1.Username, Status: db.TransferBatchStatusCompleted},
+				}
+
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(verifiedBatchChallenge.ID)).Times(1).Return(verifiedBatchChallenge, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+				store.EXPECT().ListTransferBatchRecipients(gomock.Any(), gomock.Eq(int64(2))).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// a challenge confirmed for another batch of the same total does not authorize this one
+			name: "StepUpOtherBatch",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            db.TransferBatchModeAtomic,
+				"items": []gin.H{
+					{"to_account_id": recipient.ID, "amount": 401},
+					{"to_account_id": recipient.ID, "amount": 600},
+				},
+				"step_up_challenge_id": verifiedBatchChallenge.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetStepUpChallenge(gomock.Any(), gomock.Eq(verifiedBatchChallenge.ID)).Times(1).Return(verifiedBatchChallenge, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TotalOverflow",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            db.TransferBatchModeAtomic,
+				"items": []gin.H{
+					{"to_account_id": recipient.ID, "amount": int64(math.MaxInt64)},
+					{"to_account_id": recipient.ID, "amount": int64(math.MaxInt64)},
+				},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateStepUpChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyItems",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            db.TransferBatchModePerItem,
+				"items":           tooMany,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidMode",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            "best_effort",
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user1.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        tools.USD,
+				"mode":            db.TransferBatchModePerItem,
+				"items":           items,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenGenerator token.Token) {
+				addAuthorization(t, request, tokenGenerator, authorizationTypeBearer, user2.Username, tools.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer_batches", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenGenerator)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetTransferBatchAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	batch := db.TransferBatch{
+		ID:            tools.RandomInt(1, 1000),
+		Owner:         user1.Username,
+		FromAccountID: tools.RandomInt(1, 1000),
+		Currency:      tools.EUR,
+		Mode:          db.TransferBatchModeAtomic,
+		Status:        db.TransferBatchStatusProcessing,
+		ItemCount:     1,
+		TotalAmount:   50,
+	}
+	items := []db.TransferBatchItem{
+		{BatchID: batch.ID, ToAccountID: batch.FromAccountID + 1, Amount: 50, Status: db.TransferBatchItemStatusPending},
+	}
+	accountNumber, err := tools.NewAccountNumber()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(items, nil)
+				store.EXPECT().ListTransferBatchRecipients(gomock.Any(), gomock.Eq(batch.ID)).
+					Times(1).
+					Return([]db.ListTransferBatchRecipientsRow{{ID: items[0].ToAccountID, AccountNumber: accountNumber}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferBatchStatusProcessing, rsp.Batch.Status)
+				require.Equal(t, []transferBatchItemResponse{{
+					ToAccountNumber: accountNumber,
+					Amount:          50,
+					Status:          db.TransferBatchItemStatusPending,
+				}}, rsp.Items)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.TransferBatch{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer_batches/%d", batch.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenGenerator, authorizationTypeBearer, tc.username, tools.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -1,20 +1,24 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
+	"github.com/radugaf/simplebank/apierror"
 	db "github.com/radugaf/simplebank/db/sqlc"
-	"github.com/radugaf/simplebank/tools"
+	"github.com/radugaf/simplebank/logging"
+	"github.com/radugaf/simplebank/loginguard"
 )
 
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	FullName string `json:"full_name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 }
@@ -25,6 +29,7 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Tier              string    `json:"tier"`
 }
 
 func newUserResponse(user db.User) userResponse {
@@ -34,6 +39,7 @@ func newUserResponse(user db.User) userResponse {
 		Email:             user.Email,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
+		Tier:              user.Tier,
 	}
 }
 
@@ -45,7 +51,12 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := tools.HashPassword(req.Password)
+	if err := server.config.PasswordPolicy().Validate(req.Password); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("password %w", err)))
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -60,14 +71,7 @@ func (server *Server) createUser(ctx *gin.Context) {
 
 	user, err := server.store.CreateUser(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
 		return
 	}
 
@@ -77,7 +81,7 @@ func (server *Server) createUser(ctx *gin.Context) {
 
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type loginUserResponse struct {
@@ -96,24 +100,50 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := server.store.GetUser(ctx, req.Username)
+	clientIP := ctx.ClientIP()
+	attempt, err := server.loginGuard.Begin(ctx, req.Username, clientIP)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		var lockedErr *loginguard.LockedError
+		if errors.As(err, &lockedErr) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	err = tools.CheckPassword(req.Password, user.HashedPassword)
+	user, err := server.store.GetUser(ctx, req.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		if err := attempt.Release(); err != nil {
+			logging.FromContext(ctx).Error().Err(err).Str("username", req.Username).Msg("cannot release login attempt")
+		}
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
+		return
+	}
+
+	err = loginguard.VerifyPassword(req.Password, user.HashedPassword, err == nil)
 	if err != nil {
+		if err := attempt.Fail(); err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
+	if err := attempt.Succeed(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := loginguard.UpgradePasswordHash(ctx, server.store, server.passwordHasher, user, req.Password); err != nil {
+		logging.FromContext(ctx).Error().Err(err).Str("username", user.Username).Msg("cannot upgrade password hash")
+	}
+
 	accessToken, accessPayload, err := server.tokenGenerator.GenerateToken(
 		user.Username,
+		user.Role,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -123,6 +153,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	refreshToken, refreshPayload, err := server.tokenGenerator.GenerateToken(
 		user.Username,
+		user.Role,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
@@ -140,7 +171,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ExpiresAt:    refreshPayload.ExpiresAt,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(apierror.HTTPStatus(err), errorResponse(err))
 		return
 	}
 
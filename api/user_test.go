@@ -4,20 +4,24 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgtype"
 	mockdb "github.com/radugaf/simplebank/db/mock"
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/loginguard"
 	"github.com/radugaf/simplebank/tools"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type eqCreateUserParamsMatcher struct {
@@ -111,7 +115,11 @@ func TestCreateUserAPI(t *testing.T) {
 				store.EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, &pq.Error{Code: "23505"})
+					Return(db.User{}, &db.Error{
+						Kind:       db.ErrUniqueViolation,
+						Constraint: "users_pkey",
+						Err:        errors.New(`duplicate key value violates unique constraint "users_pkey"`),
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, recorder.Code)
@@ -151,6 +159,23 @@ func TestCreateUserAPI(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "CommonPassword",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  "password123",
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "TooShortPassword",
 			body: gin.H{
@@ -213,10 +238,55 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectLoginNotLocked(store, user.Username)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					DeleteLoginFailure(gomock.Any(), gomock.Eq(db.DeleteLoginFailureParams{
+						Scope:      loginguard.ScopeUsername,
+						Identifier: user.Username,
+					})).
+					Times(1)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "OKRehashesBcryptPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				require.NoError(t, err)
+				bcryptUser := user
+				bcryptUser.HashedPassword = string(bcryptHash)
+
+				expectLoginNotLocked(store, user.Username)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(bcryptUser, nil)
+				store.EXPECT().
+					DeleteLoginFailure(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateUserParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.True(t, arg.HashedPassword.Valid)
+						require.NoError(t, tools.CheckPassword(password, arg.HashedPassword.String))
+						require.False(t, arg.PasswordChangedAt.Valid)
+						return user, nil
+					})
 				store.EXPECT().
 					CreateSession(gomock.Any(), gomock.Any()).
 					Times(1)
@@ -232,13 +302,16 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectLoginNotLocked(store, "NotFound")
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrNoRows)
+					Return(db.User{}, db.ErrRecordNotFound)
+				expectLoginFailure(store, "NotFound")
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, loginguard.ErrInvalidCredentials)
 			},
 		},
 		{
@@ -248,13 +321,45 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": "incorrect",
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectLoginNotLocked(store, user.Username)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				expectLoginFailure(store, user.Username)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, loginguard.ErrInvalidCredentials)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StartLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginFailure{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetLoginFailure(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginFailure{
+						Scope:          loginguard.ScopeUsername,
+						Identifier:     user.Username,
+						FailedAttempts: 3,
+						LockedUntil:    pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+					}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.NotEmpty(t, recorder.Header().Get("Retry-After"))
 			},
 		},
 		{
@@ -264,10 +369,14 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				expectLoginNotLocked(store, user.Username)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().
+					ReleaseLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -343,3 +452,82 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Equal(t, user.Email, gotUser.Email)
 	require.Empty(t, gotUser.HashedPassword)
 }
+
+func TestLoginUserForwardedFor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	server.loginGuard = loginguard.New(store, tools.Config{
+		LoginMaxFailedAttemptsPerIP: 10,
+		LoginFailureWindow:          time.Hour,
+		LoginBaseDelay:              time.Second,
+		LoginLockoutDuration:        time.Minute,
+	})
+
+	// without trusted proxies the forwarded IP is ignored, the attempt counts against the peer
+	store.EXPECT().
+		CountLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.CountLoginAttemptParams) (db.LoginFailure, error) {
+			require.Equal(t, loginguard.ScopeIP, arg.Scope)
+			require.Equal(t, "10.0.0.1", arg.Identifier)
+			return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, FailedAttempts: 1}, nil
+		})
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.User{}, db.ErrRecordNotFound)
+	store.EXPECT().
+		LockLogin(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	data, err := json.Marshal(gin.H{"username": "alice", "password": "secret"})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func expectLoginNotLocked(store *mockdb.MockStore, username string) {
+	store.EXPECT().
+		StartLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.StartLoginAttemptParams) (db.LoginFailure, error) {
+			if arg.Scope != loginguard.ScopeUsername || arg.Identifier != username {
+				return db.LoginFailure{}, fmt.Errorf("unexpected login attempt of %s %s", arg.Scope, arg.Identifier)
+			}
+			return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, FailedAttempts: 1}, nil
+		})
+}
+
+func expectLoginFailure(store *mockdb.MockStore, username string) {
+	store.EXPECT().
+		LockLogin(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.LockLoginParams) (db.LoginFailure, error) {
+			if arg.Identifier != username {
+				return db.LoginFailure{}, fmt.Errorf("unexpected lock of %s", arg.Identifier)
+			}
+			return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, LockedUntil: pgtype.Timestamptz{Time: arg.LockedUntil, Valid: true}}, nil
+		})
+}
+
+func requireBodyMatchError(t *testing.T, body *bytes.Buffer, expected error) {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotError struct {
+		Error string `json:"error"`
+	}
+	err = json.Unmarshal(data, &gotError)
+	require.NoError(t, err)
+	require.Equal(t, expected.Error(), gotError.Error)
+}
@@ -11,3 +11,21 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+// validAccountNumber accepts account numbers written with spaces or in lower case,
+// which the handlers normalize with tools.NormalizeAccountNumber
+var validAccountNumber validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if number, ok := fieldLevel.Field().Interface().(string); ok {
+		return tools.IsValidAccountNumber(tools.NormalizeAccountNumber(number))
+	}
+	return false
+}
+
+// validCreditorReference accepts ISO 11649 creditor references written with spaces or in lower case,
+// which the handlers normalize with tools.NormalizeCreditorReference
+var validCreditorReference validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if reference, ok := fieldLevel.Field().Interface().(string); ok {
+		return tools.IsValidCreditorReference(tools.NormalizeCreditorReference(reference))
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// Package apierror maps the errors of the store to the status codes
+// of the HTTP and gRPC APIs, so that both APIs report them alike.
+package apierror
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"google.golang.org/grpc/codes"
+)
+
+type mapping struct {
+	err        error
+	httpStatus int
+	grpcCode   codes.Code
+}
+
+var mappings = []mapping{
+	{err: db.ErrRecordNotFound, httpStatus: http.StatusNotFound, grpcCode: codes.NotFound},
+	{err: db.ErrUniqueViolation, httpStatus: http.StatusForbidden, grpcCode: codes.AlreadyExists},
+	{err: db.ErrForeignKeyViolation, httpStatus: http.StatusForbidden, grpcCode: codes.FailedPrecondition},
+	{err: db.ErrSerialization, httpStatus: http.StatusServiceUnavailable, grpcCode: codes.Aborted},
+	{err: db.ErrAccountNotActive, httpStatus: http.StatusConflict, grpcCode: codes.FailedPrecondition},
+	{err: db.ErrInvalidStatusTransition, httpStatus: http.StatusConflict, grpcCode: codes.FailedPrecondition},
+	{err: db.ErrAccountBalanceNotZero, httpStatus: http.StatusConflict, grpcCode: codes.FailedPrecondition},
+	{err: db.ErrAccountHasHolds, httpStatus: http.StatusConflict, grpcCode: codes.FailedPrecondition},
+	{err: db.ErrInvalidSweepAccount, httpStatus: http.StatusBadRequest, grpcCode: codes.InvalidArgument},
+	{err: db.ErrTransferLimitExceeded, httpStatus: http.StatusUnprocessableEntity, grpcCode: codes.ResourceExhausted},
+	{err: db.ErrInsufficientFunds, httpStatus: http.StatusUnprocessableEntity, grpcCode: codes.FailedPrecondition},
+	{err: db.ErrHoldNotAuthorized, httpStatus: http.StatusConflict, grpcCode: codes.FailedPrecondition},
+	{err: db.ErrCaptureExceedsHold, httpStatus: http.StatusBadRequest, grpcCode: codes.InvalidArgument},
+	{err: db.ErrInvalidTransferBatch, httpStatus: http.StatusBadRequest, grpcCode: codes.InvalidArgument},
+	{err: db.ErrTransferBatchTooLarge, httpStatus: http.StatusBadRequest, grpcCode: codes.InvalidArgument},
+}
+
+// HTTPStatus returns the HTTP status of the error, 500 if it is not an error of the store
+func HTTPStatus(err error) int {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m.httpStatus
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode returns the gRPC code of the error, Internal if it is not an error of the store
+func GRPCCode(err error) codes.Code {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m.grpcCode
+		}
+	}
+	return codes.Internal
+}
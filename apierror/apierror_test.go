@@ -0,0 +1,121 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMapping(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		httpStatus int
+		grpcCode   codes.Code
+	}{
+		{
+			name:       "NotFound",
+			err:        fmt.Errorf("get user: %w", db.ErrRecordNotFound),
+			httpStatus: http.StatusNotFound,
+			grpcCode:   codes.NotFound,
+		},
+		{
+			name: "UniqueViolation",
+			err: &db.Error{
+				Kind:       db.ErrUniqueViolation,
+				Constraint: "users_email_key",
+				Err:        errors.New("duplicate key value violates unique constraint"),
+			},
+			httpStatus: http.StatusForbidden,
+			grpcCode:   codes.AlreadyExists,
+		},
+		{
+			name:       "ForeignKeyViolation",
+			err:        db.ErrForeignKeyViolation,
+			httpStatus: http.StatusForbidden,
+			grpcCode:   codes.FailedPrecondition,
+		},
+		{
+			name:       "Serialization",
+			err:        db.ErrSerialization,
+			httpStatus: http.StatusServiceUnavailable,
+			grpcCode:   codes.Aborted,
+		},
+		{
+			name:       "AccountNotActive",
+			err:        db.ErrAccountNotActive,
+			httpStatus: http.StatusConflict,
+			grpcCode:   codes.FailedPrecondition,
+		},
+		{
+			// a revenue account that cannot be credited is a problem of the bank, not of the customer
+			name:       "FeeRevenueAccountNotActive",
+			err:        fmt.Errorf("revenue account [1]: %w", db.ErrFeeRevenueAccountNotActive),
+			httpStatus: http.StatusInternalServerError,
+			grpcCode:   codes.Internal,
+		},
+		{
+			name:       "AccountHasHolds",
+			err:        db.ErrAccountHasHolds,
+			httpStatus: http.StatusConflict,
+			grpcCode:   codes.FailedPrecondition,
+		},
+		{
+			name:       "InvalidSweepAccount",
+			err:        db.ErrInvalidSweepAccount,
+			httpStatus: http.StatusBadRequest,
+			grpcCode:   codes.InvalidArgument,
+		},
+		{
+			name: "TransferLimitExceeded",
+			err: fmt.Errorf("transfer: %w", &db.TransferLimitError{
+				Limit:     db.TransferLimitDailyUser,
+				Currency:  "USD",
+				Max:       1000,
+				Remaining: 200,
+			}),
+			httpStatus: http.StatusUnprocessableEntity,
+			grpcCode:   codes.ResourceExhausted,
+		},
+		{
+			name:       "InsufficientFunds",
+			err:        fmt.Errorf("hold of 100 on account [1] available 50: %w", db.ErrInsufficientFunds),
+			httpStatus: http.StatusUnprocessableEntity,
+			grpcCode:   codes.FailedPrecondition,
+		},
+		{
+			name: "InvalidTransferBatch",
+			err: &db.TransferBatchValidationError{
+				Items: []db.TransferBatchItemError{{Index: 2, Error: "recipient account not found"}},
+			},
+			httpStatus: http.StatusBadRequest,
+			grpcCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "HoldNotAuthorized",
+			err:        db.ErrHoldNotAuthorized,
+			httpStatus: http.StatusConflict,
+			grpcCode:   codes.FailedPrecondition,
+		},
+		{
+			name:       "Other",
+			err:        errors.New("connection refused"),
+			httpStatus: http.StatusInternalServerError,
+			grpcCode:   codes.Internal,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.httpStatus, HTTPStatus(tc.err))
+			require.Equal(t, tc.grpcCode, GRPCCode(tc.err))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar;
+
+CREATE TABLE "step_up_challenges" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "method" varchar,
+  "verified_at" timestamptz,
+  "consumed_at" timestamptz,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "step_up_challenges" ("username");
+
+COMMENT ON COLUMN "step_up_challenges"."method" IS 'password or totp, set once the challenge is verified';
+
+ALTER TABLE "step_up_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfers" ADD COLUMN "step_up_challenge_id" uuid UNIQUE;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("step_up_challenge_id") REFERENCES "step_up_challenges" ("id");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "login_failures";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,12 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+CREATE TABLE "login_failures" (
+  "scope" varchar NOT NULL,
+  "identifier" varchar NOT NULL,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  PRIMARY KEY ("scope", "identifier")
+);
+
+COMMENT ON COLUMN "login_failures"."scope" IS 'username or ip';
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "bank_accounts" DROP CONSTRAINT IF EXISTS "bank_accounts_status_check";
+
+ALTER TABLE IF EXISTS "bank_accounts" DROP COLUMN IF EXISTS "closed_at";
+
+ALTER TABLE IF EXISTS "bank_accounts" DROP COLUMN IF EXISTS "status";
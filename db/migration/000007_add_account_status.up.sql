@@ -0,0 +1,7 @@
+ALTER TABLE "bank_accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "bank_accounts" ADD COLUMN "closed_at" timestamptz;
+
+ALTER TABLE "bank_accounts" ADD CONSTRAINT "bank_accounts_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "bank_accounts"."status" IS 'active, frozen or closed';
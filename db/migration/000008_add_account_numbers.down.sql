@@ -0,0 +1,8 @@
+ALTER TABLE IF EXISTS "bank_accounts" DROP CONSTRAINT IF EXISTS "bank_accounts_account_number_key";
+
+ALTER TABLE IF EXISTS "bank_accounts" DROP COLUMN IF EXISTS "account_number";
+
+ALTER TABLE IF EXISTS "bank_accounts" DROP COLUMN IF EXISTS "nickname";
+
+-- fails if an owner has several accounts in the same currency by now
+ALTER TABLE "bank_accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
@@ -0,0 +1,18 @@
+ALTER TABLE "bank_accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+
+ALTER TABLE "bank_accounts" ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "bank_accounts" ADD COLUMN "account_number" varchar;
+
+-- give the existing accounts a random number, with the check digits computed like tools.NewAccountNumber
+UPDATE "bank_accounts" SET "account_number" = 'SB' || lpad((98 - ("basic" || '281100')::numeric % 97)::text, 2, '0') || "basic"
+FROM (
+  SELECT "id", lpad(floor(random() * 1e16)::bigint::text, 16, '0') AS "basic" FROM "bank_accounts"
+) AS "generated"
+WHERE "bank_accounts"."id" = "generated"."id";
+
+ALTER TABLE "bank_accounts" ALTER COLUMN "account_number" SET NOT NULL;
+
+ALTER TABLE "bank_accounts" ADD CONSTRAINT "bank_accounts_account_number_key" UNIQUE ("account_number");
+
+COMMENT ON COLUMN "bank_accounts"."account_number" IS 'public number with mod-97 check digits';
@@ -0,0 +1,20 @@
+CREATE TABLE "payees" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "display_name" varchar NOT NULL,
+  "account_number" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "note" varchar NOT NULL DEFAULT '',
+  "confirmed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX "payees_owner_created_at_id_idx" ON "payees" ("owner", "created_at", "id");
+
+ALTER TABLE "payees" ADD CONSTRAINT "payees_owner_account_number_key" UNIQUE ("owner", "account_number");
+
+COMMENT ON COLUMN "payees"."confirmed_at" IS 'null until the payee is confirmed with step-up authentication';
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_number") REFERENCES "bank_accounts" ("account_number");
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "entries_account_id_category_idx";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "category";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "reference";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "memo";
@@ -0,0 +1,11 @@
+ALTER TABLE "transfers" ADD COLUMN "memo" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "entries" ADD COLUMN "category" varchar NOT NULL DEFAULT '';
+
+CREATE INDEX "entries_account_id_category_idx" ON "entries" ("account_id", "category");
+
+COMMENT ON COLUMN "transfers"."reference" IS 'ISO 11649 RF creditor reference';
+
+COMMENT ON COLUMN "entries"."category" IS 'chosen by the owner of the account';
@@ -0,0 +1,18 @@
+CREATE TABLE "transfer_limit_overrides" (
+  "username" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "per_transfer" bigint,
+  "daily_account" bigint,
+  "monthly_account" bigint,
+  "daily_user" bigint,
+  "monthly_user" bigint,
+  "updated_by" varchar NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "currency")
+);
+
+COMMENT ON COLUMN "transfer_limit_overrides"."per_transfer" IS 'null limits fall back to the configured default of the currency';
+
+ALTER TABLE "transfer_limit_overrides" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limit_overrides" ADD FOREIGN KEY ("updated_by") REFERENCES "users" ("username");
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "fee_schedules";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "tier";
@@ -0,0 +1,32 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "transfer_type" varchar NOT NULL,
+  "tier" varchar NOT NULL,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percent_bps" bigint NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "revenue_account_id" bigint NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "fee_schedules_currency_transfer_type_tier_key" UNIQUE ("currency", "transfer_type", "tier");
+
+COMMENT ON COLUMN "users"."tier" IS 'selects the fee schedule of the transfers of the user';
+
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the sender on top of the amount';
+
+COMMENT ON COLUMN "fee_schedules"."transfer_type" IS 'own or cross_owner';
+
+COMMENT ON COLUMN "fee_schedules"."percent_bps" IS 'percentage of the amount, in basis points';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'null for no maximum';
+
+COMMENT ON COLUMN "fee_schedules"."revenue_account_id" IS 'bank-owned account receiving the fees';
+
+ALTER TABLE "fee_schedules" ADD FOREIGN KEY ("revenue_account_id") REFERENCES "bank_accounts" ("id");
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "interest_runs";
+
+DROP TABLE IF EXISTS "interest_accruals";
+
+ALTER TABLE IF EXISTS "bank_accounts" DROP COLUMN IF EXISTS "product";
+
+DROP TABLE IF EXISTS "account_products";
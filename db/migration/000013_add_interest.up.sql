@@ -0,0 +1,49 @@
+CREATE TABLE "account_products" (
+  "code" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "annual_rate_bps" bigint NOT NULL DEFAULT 0,
+  "day_count" varchar NOT NULL DEFAULT 'actual/365',
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+INSERT INTO "account_products" ("code", "name") VALUES ('checking', 'Checking');
+
+ALTER TABLE "bank_accounts" ADD COLUMN "product" varchar NOT NULL DEFAULT 'checking';
+
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate_bps" bigint NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "posted_at" timestamptz,
+  "entry_id" bigint,
+  PRIMARY KEY ("account_id", "accrual_date")
+);
+
+CREATE INDEX "interest_accruals_unposted_idx" ON "interest_accruals" ("accrual_date") WHERE "posted_at" IS NULL;
+
+CREATE TABLE "interest_runs" (
+  "kind" varchar NOT NULL,
+  "run_date" date NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("kind", "run_date")
+);
+
+COMMENT ON COLUMN "account_products"."annual_rate_bps" IS 'annual interest rate, in basis points';
+
+COMMENT ON COLUMN "account_products"."day_count" IS 'actual/365, actual/360 or actual/actual';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'balance of the account at the end of the day';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest of the day, in millionths of the minor unit';
+
+COMMENT ON COLUMN "interest_accruals"."entry_id" IS 'interest entry the accrual was posted with';
+
+COMMENT ON COLUMN "interest_runs"."kind" IS 'accrual or posting, each runs once per date';
+
+ALTER TABLE "bank_accounts" ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "bank_accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
@@ -0,0 +1,43 @@
+ALTER TABLE "bank_accounts" ADD COLUMN "held" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "bank_accounts" ADD COLUMN "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held") STORED;
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "memo" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'authorized',
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "transfer_id" bigint,
+  "step_up_challenge_id" uuid UNIQUE,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "released_at" timestamptz
+);
+
+CREATE INDEX "holds_from_account_id_idx" ON "holds" ("from_account_id");
+
+CREATE INDEX "holds_authorized_expires_at_idx" ON "holds" ("expires_at") WHERE "status" = 'authorized';
+
+COMMENT ON COLUMN "bank_accounts"."held" IS 'sum of the authorized holds, not available to spend';
+
+COMMENT ON COLUMN "bank_accounts"."available_balance" IS 'ledger balance minus the held amount';
+
+COMMENT ON COLUMN "holds"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'authorized, captured, voided or expired';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer settling the captured amount';
+
+ALTER TABLE "holds" ADD CONSTRAINT "holds_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("from_account_id") REFERENCES "bank_accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "bank_accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("step_up_challenge_id") REFERENCES "step_up_challenges" ("id");
@@ -0,0 +1,46 @@
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "mode" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'processing',
+  "item_count" integer NOT NULL,
+  "total_amount" bigint NOT NULL,
+  "succeeded_count" integer NOT NULL DEFAULT 0,
+  "failed_count" integer NOT NULL DEFAULT 0,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "completed_at" timestamptz
+);
+
+CREATE TABLE "transfer_batch_items" (
+  "batch_id" bigint NOT NULL,
+  "position" integer NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "memo" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "error" varchar NOT NULL DEFAULT '',
+  PRIMARY KEY ("batch_id", "position")
+);
+
+CREATE INDEX "transfer_batches_owner_idx" ON "transfer_batches" ("owner");
+
+COMMENT ON COLUMN "transfer_batches"."mode" IS 'atomic or per_item';
+
+COMMENT ON COLUMN "transfer_batches"."status" IS 'processing, completed, partially_completed or failed';
+
+COMMENT ON COLUMN "transfer_batch_items"."status" IS 'pending, succeeded or failed';
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "bank_accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("to_account_id") REFERENCES "bank_accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
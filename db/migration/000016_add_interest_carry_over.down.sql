@@ -0,0 +1,7 @@
+DELETE FROM "interest_accruals" WHERE "carry_over";
+
+ALTER TABLE IF EXISTS "interest_accruals" DROP CONSTRAINT IF EXISTS "interest_accruals_pkey";
+
+ALTER TABLE IF EXISTS "interest_accruals" ADD PRIMARY KEY ("account_id", "accrual_date");
+
+ALTER TABLE IF EXISTS "interest_accruals" DROP COLUMN IF EXISTS "carry_over";
@@ -0,0 +1,7 @@
+ALTER TABLE "interest_accruals" ADD COLUMN "carry_over" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "interest_accruals" DROP CONSTRAINT "interest_accruals_pkey";
+
+ALTER TABLE "interest_accruals" ADD PRIMARY KEY ("account_id", "accrual_date", "carry_over");
+
+COMMENT ON COLUMN "interest_accruals"."carry_over" IS 'part of a posting below the minor unit, carried to the next posting';
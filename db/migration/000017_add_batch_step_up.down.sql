@@ -0,0 +1,9 @@
+ALTER TABLE IF EXISTS "transfer_batches" DROP COLUMN IF EXISTS "step_up_challenge_id";
+
+DELETE FROM "step_up_challenges" WHERE "batch_hash" IS NOT NULL;
+
+ALTER TABLE IF EXISTS "step_up_challenges" DROP CONSTRAINT IF EXISTS "step_up_challenges_target_check";
+
+ALTER TABLE IF EXISTS "step_up_challenges" DROP COLUMN IF EXISTS "batch_hash";
+
+ALTER TABLE IF EXISTS "step_up_challenges" ALTER COLUMN "to_account_id" SET NOT NULL;
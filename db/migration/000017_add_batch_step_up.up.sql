@@ -0,0 +1,12 @@
+ALTER TABLE "step_up_challenges" ALTER COLUMN "to_account_id" DROP NOT NULL;
+
+ALTER TABLE "step_up_challenges" ADD COLUMN "batch_hash" varchar;
+
+ALTER TABLE "step_up_challenges" ADD CONSTRAINT "step_up_challenges_target_check"
+  CHECK (("to_account_id" IS NULL) <> ("batch_hash" IS NULL));
+
+COMMENT ON COLUMN "step_up_challenges"."batch_hash" IS 'SHA-256 of the transfer batch the challenge confirms, null for a single transfer';
+
+ALTER TABLE "transfer_batches" ADD COLUMN "step_up_challenge_id" uuid UNIQUE;
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("step_up_challenge_id") REFERENCES "step_up_challenges" ("id");
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "totp_last_step";
+
+ALTER TABLE IF EXISTS "step_up_challenges" DROP COLUMN IF EXISTS "attempts";
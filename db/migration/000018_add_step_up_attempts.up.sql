@@ -0,0 +1,7 @@
+ALTER TABLE "step_up_challenges" ADD COLUMN "attempts" integer NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "step_up_challenges"."attempts" IS 'verification attempts so far, the challenge cannot be verified once they reach STEP_UP_MAX_ATTEMPTS';
+
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "users"."totp_last_step" IS 'time step of the last accepted totp code, codes of this step or an earlier one are rejected';
@@ -0,0 +1,9 @@
+CREATE TABLE "interest_expense_accounts" (
+  "currency" varchar PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "interest_expense_accounts"."account_id" IS 'bank-owned account paying the interest posted in the currency';
+
+ALTER TABLE "interest_expense_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "bank_accounts" ("id");
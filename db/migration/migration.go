@@ -0,0 +1,117 @@
+// Package migration embeds the schema migrations so the binary can apply
+// them itself instead of relying on the migrate CLI and files on disk.
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// SchemaTooOldError is returned by CheckVersion when the schema
+// is older than the migrations embedded in the binary
+type SchemaTooOldError struct {
+	Version  uint
+	Expected uint
+}
+
+func (err *SchemaTooOldError) Error() string {
+	return fmt.Sprintf("schema version %d is older than the expected version %d, run the migrations first", err.Version, err.Expected)
+}
+
+// New creates a migrate instance applying the embedded migrations to the database
+func New(dbSource string) (*migrate.Migrate, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, dbSource)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create migrate instance: %w", err)
+	}
+	return m, nil
+}
+
+// Up applies all migrations that have not been applied yet
+func Up(m *migrate.Migrate) error {
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// CheckVersion returns a *SchemaTooOldError if the schema is older than the expected version,
+// and an error if the schema is dirty. A newer schema is accepted, so that the previous
+// release keeps running while the next one is rolled out.
+func CheckVersion(m *migrate.Migrate) error {
+	expected, err := ExpectedVersion()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return &SchemaTooOldError{Version: 0, Expected: expected}
+		}
+		return fmt.Errorf("cannot get schema version: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty, fix it and force the version", version)
+	}
+	if version < expected {
+		return &SchemaTooOldError{Version: version, Expected: expected}
+	}
+	return nil
+}
+
+// ExpectedVersion returns the version of the latest embedded migration
+func ExpectedVersion() (uint, error) {
+	return latestVersion(files)
+}
+
+// latestVersion returns the highest version among the migration
+// files of the directory, named as in "000001_init_schema.up.sql"
+func latestVersion(fsys fs.FS) (uint, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+
+	if latest == 0 {
+		return 0, errors.New("no migrations found")
+	}
+	return latest, nil
+}
@@ -0,0 +1,40 @@
+package migration
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_init_schema.up.sql":   {},
+		"000001_init_schema.down.sql": {},
+		"000012_add_payees.up.sql":    {},
+		"000012_add_payees.down.sql":  {},
+		"000003_add_sessions.up.sql":  {},
+		"README.md":                   {},
+		"not_a_version.up.sql":        {},
+	}
+
+	version, err := latestVersion(fsys)
+	require.NoError(t, err)
+	require.Equal(t, uint(12), version)
+
+	_, err = latestVersion(fstest.MapFS{})
+	require.Error(t, err)
+}
+func TestExpectedVersion(t *testing.T) {
+	version, err := ExpectedVersion()
+	require.NoError(t, err)
+
+	// the latest embedded migration can be applied and rolled back
+	for _, suffix := range []string{"up", "down"} {
+		matches, err := fs.Glob(files, fmt.Sprintf("%06d_*.%s.sql", version, suffix))
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+	}
+}
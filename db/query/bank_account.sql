@@ -1,20 +1,40 @@
 -- name: CreateBankAccount :one
-INSERT INTO bank_accounts (owner, balance, currency) VALUES ($1, $2, $3) RETURNING *;
+INSERT INTO bank_accounts (owner, balance, currency, nickname, account_number, product)
+VALUES ($1, $2, $3, $4, $5, COALESCE(sqlc.narg(product)::varchar, 'checking'))
+RETURNING *;
 
 -- name: GetBankAccount :one
 SELECT * FROM bank_accounts WHERE id = $1 LIMIT 1;
 
+-- name: GetBankAccountByNumber :one
+SELECT * FROM bank_accounts WHERE account_number = $1 LIMIT 1;
+
 -- name: GetBankAccountForUpdate :one
 SELECT * FROM bank_accounts WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
 
 -- name: ListBankAccounts :many
-SELECT * FROM bank_accounts WHERE owner = $1 ORDER BY id LIMIT $2 OFFSET $3;
+SELECT * FROM bank_accounts
+WHERE owner = sqlc.arg(owner)
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.arg(cursor_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateBankAccount :one
 UPDATE bank_accounts SET balance = $2 WHERE id = $1 RETURNING *;
 
 -- name: AddBankAccountBalance :one
-UPDATE bank_accounts SET balance = balance + sqlc.arg(amount) WHERE id = sqlc.arg(id) RETURNING *;
+UPDATE bank_accounts SET balance = balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id) AND status = 'active'
+RETURNING *;
+
+-- name: UpdateBankAccountNickname :one
+UPDATE bank_accounts SET nickname = sqlc.arg(nickname) WHERE id = sqlc.arg(id) RETURNING *;
 
--- name: DeleteBankAccount :exec
-DELETE FROM bank_accounts WHERE id = $1;
+-- name: UpdateBankAccountStatus :one
+UPDATE bank_accounts
+SET status = sqlc.arg(status),
+    closed_at = CASE WHEN sqlc.arg(status)::varchar = 'closed' THEN now() END
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -5,4 +5,29 @@ INSERT INTO entries (account_id, amount) VALUES ($1, $2) RETURNING *;
 SELECT * FROM entries WHERE id = $1 LIMIT 1;
 
 -- name: ListEntries :many
-SELECT * FROM entries WHERE account_id = $1 ORDER BY id LIMIT $2 OFFSET $3;
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(amount) >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(amount) <= sqlc.narg(max_amount))
+  AND (sqlc.narg(category)::varchar IS NULL OR category = sqlc.narg(category))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.arg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateEntryCategory :one
+UPDATE entries SET category = sqlc.arg(category) WHERE id = sqlc.arg(id) RETURNING *;
+
+-- name: CreateEntryWithCategory :one
+INSERT INTO entries (account_id, amount, category) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: ListStatementEntries :many
+-- The entries of an account over a period, oldest first, for the statement export.
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+  AND (sqlc.narg(category)::varchar IS NULL OR category = sqlc.narg(category))
+ORDER BY created_at, id;
@@ -0,0 +1,25 @@
+-- name: GetTransferFeeSchedule :one
+SELECT fee_schedules.*
+FROM bank_accounts sender
+JOIN bank_accounts recipient ON recipient.id = sqlc.arg(to_account_id)
+JOIN users ON users.username = sender.owner
+JOIN fee_schedules ON fee_schedules.currency = sender.currency
+  AND fee_schedules.tier = users.tier
+  AND fee_schedules.transfer_type = CASE WHEN sender.owner = recipient.owner THEN 'own' ELSE 'cross_owner' END
+WHERE sender.id = sqlc.arg(from_account_id)
+LIMIT 1;
+
+-- name: SetFeeSchedule :one
+INSERT INTO fee_schedules (
+  currency, transfer_type, tier, flat_fee, percent_bps, min_fee, max_fee, revenue_account_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (currency, transfer_type, tier) DO UPDATE
+SET flat_fee = EXCLUDED.flat_fee,
+    percent_bps = EXCLUDED.percent_bps,
+    min_fee = EXCLUDED.min_fee,
+    max_fee = EXCLUDED.max_fee,
+    revenue_account_id = EXCLUDED.revenue_account_id,
+    updated_at = now()
+RETURNING *;
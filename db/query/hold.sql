@@ -0,0 +1,31 @@
+-- name: CreateHold :one
+INSERT INTO holds (from_account_id, to_account_id, amount, memo, reference, step_up_challenge_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: ListExpiredHolds :many
+SELECT * FROM holds
+WHERE status = 'authorized' AND expires_at <= sqlc.arg(now)
+ORDER BY id
+LIMIT sqlc.arg('limit')
+FOR UPDATE SKIP LOCKED;
+
+-- name: ReleaseHold :one
+UPDATE holds
+SET status = sqlc.arg(status),
+    captured_amount = sqlc.arg(captured_amount),
+    transfer_id = sqlc.narg(transfer_id),
+    released_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: AddBankAccountHeld :one
+UPDATE bank_accounts SET held = held + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,82 @@
+-- name: GetAccountProduct :one
+SELECT * FROM account_products WHERE code = $1 LIMIT 1;
+
+-- name: ListAccountProducts :many
+SELECT * FROM account_products ORDER BY code;
+
+-- name: SetAccountProduct :one
+INSERT INTO account_products (code, name, annual_rate_bps, day_count) VALUES ($1, $2, $3, $4)
+ON CONFLICT (code) DO UPDATE
+SET name = EXCLUDED.name,
+    annual_rate_bps = EXCLUDED.annual_rate_bps,
+    day_count = EXCLUDED.day_count,
+    updated_at = now()
+RETURNING *;
+
+-- name: StartInterestRun :one
+INSERT INTO interest_runs (kind, run_date) VALUES ($1, $2)
+ON CONFLICT (kind, run_date) DO NOTHING
+RETURNING *;
+
+-- name: GetLastInterestRun :one
+SELECT * FROM interest_runs WHERE kind = $1 ORDER BY run_date DESC LIMIT 1;
+
+-- name: ListInterestBearingBalances :many
+-- The balance of every account earning interest at the end of the day,
+-- worked back from the current balance and the entries made since then.
+SELECT
+  bank_accounts.id AS account_id,
+  (bank_accounts.balance - COALESCE((
+    SELECT SUM(entries.amount) FROM entries
+    WHERE entries.account_id = bank_accounts.id AND entries.created_at >= sqlc.arg(day_end)::timestamptz
+  ), 0))::bigint AS balance,
+  account_products.annual_rate_bps,
+  account_products.day_count
+FROM bank_accounts
+JOIN account_products ON account_products.code = bank_accounts.product
+WHERE account_products.annual_rate_bps > 0
+  AND bank_accounts.created_at < sqlc.arg(day_end)::timestamptz
+  AND (bank_accounts.closed_at IS NULL OR bank_accounts.closed_at >= sqlc.arg(day_end)::timestamptz)
+ORDER BY bank_accounts.id;
+
+-- name: CreateInterestAccrual :exec
+INSERT INTO interest_accruals (account_id, accrual_date, balance, annual_rate_bps, amount_micros)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (account_id, accrual_date, carry_over) DO NOTHING;
+
+-- name: CreateInterestCarryOver :exec
+-- Keeps the part of a posting below the minor unit, which can be negative when the posting
+-- was rounded up, to be added to the next posting.
+INSERT INTO interest_accruals (account_id, accrual_date, balance, annual_rate_bps, amount_micros, carry_over)
+VALUES (sqlc.arg(account_id), sqlc.arg(posting_date), 0, 0, sqlc.arg(amount_micros), true);
+
+-- name: ListInterestAccruals :many
+SELECT * FROM interest_accruals
+WHERE account_id = $1
+ORDER BY accrual_date, carry_over;
+
+-- name: ListUnpostedInterest :many
+-- The interest to post to every account, with the account paying it in the currency of the account.
+SELECT
+  interest_accruals.account_id,
+  SUM(interest_accruals.amount_micros)::bigint AS amount_micros,
+  interest_expense_accounts.account_id AS expense_account_id
+FROM interest_accruals
+JOIN bank_accounts ON bank_accounts.id = interest_accruals.account_id
+LEFT JOIN interest_expense_accounts ON interest_expense_accounts.currency = bank_accounts.currency
+WHERE interest_accruals.posted_at IS NULL AND interest_accruals.accrual_date < sqlc.arg(before)
+GROUP BY interest_accruals.account_id, interest_expense_accounts.account_id
+ORDER BY interest_accruals.account_id;
+
+-- name: MarkInterestPosted :exec
+UPDATE interest_accruals
+SET posted_at = now(), entry_id = sqlc.arg(entry_id)
+WHERE account_id = sqlc.arg(account_id) AND posted_at IS NULL AND accrual_date < sqlc.arg(before);
+
+-- name: SetInterestExpenseAccount :one
+INSERT INTO interest_expense_accounts (currency, account_id)
+VALUES ($1, $2)
+ON CONFLICT (currency) DO UPDATE
+SET account_id = EXCLUDED.account_id,
+    updated_at = now()
+RETURNING *;
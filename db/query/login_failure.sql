@@ -0,0 +1,71 @@
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE scope = $1 AND identifier = $2 LIMIT 1;
+
+-- name: StartLoginAttempt :one
+-- Counts an attempt and locks the key until the attempt is resolved.
+-- No row is returned while the key is locked out.
+INSERT INTO login_failures (
+  scope,
+  identifier,
+  failed_attempts,
+  last_failed_at,
+  locked_until
+) VALUES (
+  sqlc.arg(scope), sqlc.arg(identifier), 1, now(), sqlc.arg(locked_until)::timestamptz
+)
+ON CONFLICT (scope, identifier) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_failures.last_failed_at < sqlc.arg(reset_before)::timestamptz THEN 1
+    ELSE login_failures.failed_attempts + 1
+  END,
+  last_failed_at = now(),
+  locked_until = sqlc.arg(locked_until)::timestamptz
+WHERE login_failures.locked_until IS NULL OR login_failures.locked_until <= now()
+RETURNING *;
+
+-- name: CountLoginAttempt :one
+-- Counts an attempt without locking the key, for a key shared by concurrent clients like an IP.
+-- No row is returned while the key is locked out.
+INSERT INTO login_failures (
+  scope,
+  identifier,
+  failed_attempts,
+  last_failed_at
+) VALUES (
+  sqlc.arg(scope), sqlc.arg(identifier), 1, now()
+)
+ON CONFLICT (scope, identifier) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_failures.last_failed_at < sqlc.arg(reset_before)::timestamptz THEN 1
+    ELSE login_failures.failed_attempts + 1
+  END,
+  last_failed_at = now()
+WHERE login_failures.locked_until IS NULL OR login_failures.locked_until <= now()
+RETURNING *;
+
+-- name: LockLogin :one
+UPDATE login_failures
+SET locked_until = sqlc.arg(locked_until)::timestamptz
+WHERE scope = sqlc.arg(scope) AND identifier = sqlc.arg(identifier)
+RETURNING *;
+
+-- name: ReleaseLoginAttempt :exec
+-- Uncounts an attempt that did not fail, and unlocks the key it locked.
+UPDATE login_failures
+SET
+  failed_attempts = GREATEST(failed_attempts - 1, 0),
+  locked_until = NULL
+WHERE scope = $1 AND identifier = $2;
+
+-- name: UncountLoginAttempt :exec
+-- Uncounts an attempt that did not fail, leaving the lockout of the key to the other attempts.
+UPDATE login_failures
+SET failed_attempts = GREATEST(failed_attempts - 1, 0)
+WHERE scope = $1 AND identifier = $2;
+
+-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE scope = $1 AND identifier = $2;
@@ -0,0 +1,48 @@
+-- name: CreatePayee :one
+INSERT INTO payees (
+  owner,
+  display_name,
+  account_number,
+  currency,
+  note,
+  confirmed_at
+) VALUES (
+  sqlc.arg(owner),
+  sqlc.arg(display_name),
+  sqlc.arg(account_number),
+  sqlc.arg(currency),
+  sqlc.arg(note),
+  CASE WHEN sqlc.arg(confirmed)::bool THEN now() END
+) RETURNING *;
+
+-- name: GetPayee :one
+SELECT sqlc.embed(payees), bank_accounts.status = 'closed' AS target_closed
+FROM payees
+JOIN bank_accounts ON bank_accounts.account_number = payees.account_number
+WHERE payees.id = $1 LIMIT 1;
+
+-- name: ListPayees :many
+SELECT sqlc.embed(payees), bank_accounts.status = 'closed' AS target_closed
+FROM payees
+JOIN bank_accounts ON bank_accounts.account_number = payees.account_number
+WHERE payees.owner = sqlc.arg(owner)
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (payees.created_at, payees.id) > (sqlc.narg(cursor_created_at), sqlc.arg(cursor_id)::bigint))
+ORDER BY payees.created_at, payees.id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdatePayee :one
+UPDATE payees
+SET
+  display_name = COALESCE(sqlc.narg(display_name), display_name),
+  note = COALESCE(sqlc.narg(note), note)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ConfirmPayee :one
+UPDATE payees SET confirmed_at = now()
+WHERE id = $1 AND confirmed_at IS NULL
+RETURNING *;
+
+-- name: DeletePayee :exec
+DELETE FROM payees WHERE id = $1;
@@ -0,0 +1,55 @@
+-- name: CreateStepUpChallenge :one
+INSERT INTO step_up_challenges (
+  id,
+  username,
+  from_account_id,
+  to_account_id,
+  batch_hash,
+  amount,
+  currency,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetStepUpChallenge :one
+SELECT * FROM step_up_challenges
+WHERE id = $1 LIMIT 1;
+
+-- name: AttemptStepUpChallenge :one
+-- Counts an attempt to verify the challenge, none is left once max_attempts were made.
+UPDATE step_up_challenges
+SET attempts = attempts + 1
+WHERE
+  id = sqlc.arg(id) AND verified_at IS NULL AND attempts < sqlc.arg(max_attempts)::integer
+RETURNING *;
+
+-- name: VerifyStepUpChallenge :one
+UPDATE step_up_challenges
+SET
+  method = sqlc.arg(method)::varchar,
+  verified_at = now()
+WHERE
+  id = sqlc.arg(id) AND verified_at IS NULL
+RETURNING *;
+
+-- name: ConsumeStepUpChallenge :one
+UPDATE step_up_challenges
+SET consumed_at = now()
+WHERE
+  id = $1 AND verified_at IS NOT NULL AND consumed_at IS NULL AND expires_at > now()
+RETURNING *;
+
+-- name: ConsumeBatchStepUpChallenge :one
+-- Uses up a verified challenge issued to the owner for exactly the batch of the hash.
+UPDATE step_up_challenges
+SET consumed_at = now()
+WHERE
+  id = sqlc.arg(id)
+  AND username = sqlc.arg(username)
+  AND from_account_id = sqlc.arg(from_account_id)
+  AND batch_hash = sqlc.arg(batch_hash)::varchar
+  AND amount = sqlc.arg(amount)
+  AND currency = sqlc.arg(currency)
+  AND verified_at IS NOT NULL AND consumed_at IS NULL AND expires_at > now()
+RETURNING *;
@@ -1,8 +1,49 @@
 -- name: CreateTransfer :one
-INSERT INTO transfers (from_account_id, to_account_id, amount) VALUES ($1, $2, $3) RETURNING *;
+INSERT INTO transfers (from_account_id, to_account_id, amount, step_up_challenge_id, memo, reference, fee) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers WHERE id = $1 LIMIT 1;
 
 -- name: ListTransfers :many
-SELECT * FROM transfers WHERE  from_account_id = $1 OR to_account_id = $2 ORDER BY id LIMIT $3 OFFSET $4;
+SELECT * FROM transfers
+WHERE (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id))
+  AND (sqlc.narg(counterparty_id)::bigint IS NULL
+    OR from_account_id = sqlc.narg(counterparty_id) OR to_account_id = sqlc.narg(counterparty_id))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.arg(cursor_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetOwnerTransfer :one
+SELECT transfers.* FROM transfers
+JOIN bank_accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN bank_accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE transfers.id = sqlc.arg(id)
+  AND (from_account.owner = sqlc.arg(owner) OR to_account.owner = sqlc.arg(owner))
+LIMIT 1;
+
+-- name: ListOwnerTransfers :many
+SELECT transfers.* FROM transfers
+JOIN bank_accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN bank_accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE (
+    (from_account.owner = sqlc.arg(owner)
+      AND (sqlc.narg(direction)::text IS NULL OR sqlc.narg(direction) = 'out')
+      AND (sqlc.narg(account_id)::bigint IS NULL OR from_account.id = sqlc.narg(account_id)))
+    OR (to_account.owner = sqlc.arg(owner)
+      AND (sqlc.narg(direction)::text IS NULL OR sqlc.narg(direction) = 'in')
+      AND (sqlc.narg(account_id)::bigint IS NULL OR to_account.id = sqlc.narg(account_id)))
+  )
+  AND (sqlc.narg(currency)::varchar IS NULL OR from_account.currency = sqlc.narg(currency))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR transfers.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR transfers.created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR transfers.amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR transfers.amount <= sqlc.narg(max_amount))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (transfers.created_at, transfers.id) < (sqlc.narg(cursor_created_at), sqlc.arg(cursor_id)::bigint))
+ORDER BY transfers.created_at DESC, transfers.id DESC
+LIMIT sqlc.arg('limit');
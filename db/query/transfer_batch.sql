@@ -0,0 +1,44 @@
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (owner, from_account_id, currency, mode, item_count, total_amount, step_up_challenge_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: CreateTransferBatchItem :one
+INSERT INTO transfer_batch_items (batch_id, position, to_account_id, amount, memo, reference)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetTransferBatch :one
+SELECT * FROM transfer_batches WHERE id = $1 LIMIT 1;
+
+-- name: ListTransferBatchItems :many
+SELECT * FROM transfer_batch_items WHERE batch_id = $1 ORDER BY position;
+
+-- name: ListTransferBatchRecipients :many
+-- The account numbers of the recipients of a batch, which the items are reported with.
+SELECT DISTINCT bank_accounts.id, bank_accounts.account_number
+FROM transfer_batch_items
+JOIN bank_accounts ON bank_accounts.id = transfer_batch_items.to_account_id
+WHERE transfer_batch_items.batch_id = $1;
+
+-- name: UpdateTransferBatchItem :one
+UPDATE transfer_batch_items
+SET status = sqlc.arg(status),
+    transfer_id = sqlc.narg(transfer_id),
+    error = sqlc.arg(error)
+WHERE batch_id = sqlc.arg(batch_id) AND position = sqlc.arg(position)
+RETURNING *;
+
+-- name: FailPendingTransferBatchItems :exec
+UPDATE transfer_batch_items SET status = 'failed'
+WHERE batch_id = $1 AND status = 'pending';
+
+-- name: FinishTransferBatch :one
+UPDATE transfer_batches
+SET status = sqlc.arg(status),
+    succeeded_count = sqlc.arg(succeeded_count),
+    failed_count = sqlc.arg(failed_count),
+    error = sqlc.arg(error),
+    completed_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,40 @@
+-- name: LockTransferSender :one
+SELECT bank_accounts.owner, bank_accounts.currency
+FROM bank_accounts
+JOIN users ON users.username = bank_accounts.owner
+WHERE bank_accounts.id = $1
+FOR NO KEY UPDATE OF users;
+
+-- name: GetOutgoingTransferTotals :one
+SELECT
+  COALESCE(SUM(t.amount) FILTER (
+    WHERE t.from_account_id = sqlc.arg(account_id) AND t.created_at >= sqlc.arg(day_start)::timestamptz
+  ), 0)::bigint AS account_day,
+  COALESCE(SUM(t.amount) FILTER (WHERE t.from_account_id = sqlc.arg(account_id)), 0)::bigint AS account_month,
+  COALESCE(SUM(t.amount) FILTER (WHERE t.created_at >= sqlc.arg(day_start)::timestamptz), 0)::bigint AS user_day,
+  COALESCE(SUM(t.amount), 0)::bigint AS user_month
+FROM transfers t
+JOIN bank_accounts a ON a.id = t.from_account_id
+WHERE a.owner = sqlc.arg(owner)
+  AND a.currency = sqlc.arg(currency)
+  AND t.created_at >= sqlc.arg(month_start)::timestamptz;
+
+-- name: GetTransferLimitOverride :one
+SELECT * FROM transfer_limit_overrides
+WHERE username = $1 AND currency = $2 LIMIT 1;
+
+-- name: SetTransferLimitOverride :one
+INSERT INTO transfer_limit_overrides (
+  username, currency, per_transfer, daily_account, monthly_account, daily_user, monthly_user, updated_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (username, currency) DO UPDATE
+SET per_transfer = EXCLUDED.per_transfer,
+    daily_account = EXCLUDED.daily_account,
+    monthly_account = EXCLUDED.monthly_account,
+    daily_user = EXCLUDED.daily_user,
+    monthly_user = EXCLUDED.monthly_user,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING *;
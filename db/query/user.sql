@@ -10,7 +10,21 @@ SET
   hashed_password = COALESCE(sqlc.narg(hashed_password), hashed_password),
   password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
   full_name = COALESCE(sqlc.narg(full_name), full_name),
-  email = COALESCE(sqlc.narg(email), email)
+  email = COALESCE(sqlc.narg(email), email),
+  tier = COALESCE(sqlc.narg(tier), tier)
 WHERE
   username = sqlc.arg(username)
 RETURNING *;
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = sqlc.arg(totp_secret)::varchar
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UseUserTOTPStep :one
+-- Records the time step of an accepted totp code, a code of that step or an earlier one is a replay.
+UPDATE users
+SET totp_last_step = sqlc.arg(step)::bigint
+WHERE username = sqlc.arg(username) AND totp_last_step < sqlc.arg(step)::bigint
+RETURNING *;
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Statuses of a bank account. Only active accounts send or receive money.
+// A frozen account can be reopened, a closed one is kept for its history only.
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// accountStatusTransitions lists the statuses every status can change to
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive},
+}
+
+var (
+	// ErrAccountNotActive is returned when money is moved from or to a frozen or closed account
+	ErrAccountNotActive = errors.New("bank account is frozen or closed")
+	// ErrInvalidStatusTransition is returned when an account cannot change from its status to the requested one
+	ErrInvalidStatusTransition = errors.New("bank account cannot change to this status")
+	// ErrAccountBalanceNotZero is returned when closing an account holding money without a sweep account
+	ErrAccountBalanceNotZero = errors.New("bank account balance must be zero or swept to another account")
+	// ErrAccountHasHolds is returned when closing an account with authorized holds, which must be captured or voided first
+	ErrAccountHasHolds = errors.New("bank account has authorized holds, capture or void them first")
+	// ErrInvalidSweepAccount is returned when the balance of a closing account cannot be swept to the account
+	ErrInvalidSweepAccount = errors.New("sweep account must be another active account of the owner in the same currency")
+)
+
+// CanChangeAccountStatus reports whether an account can change from one status to the other
+func CanChangeAccountStatus(from string, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateAccountStatusTx changes the status of an account, if the transition is allowed.
+// Closing an account goes through CloseAccountTx, which takes care of its balance.
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateBankAccountStatusParams) (BankAccount, error) {
+	var account BankAccount
+
+	if arg.Status == AccountStatusClosed {
+		return account, ErrInvalidStatusTransition
+	}
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		account, err = q.GetBankAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if !CanChangeAccountStatus(account.Status, arg.Status) {
+			return ErrInvalidStatusTransition
+		}
+
+		account, err = q.UpdateBankAccountStatus(ctx, arg)
+		return err
+	})
+
+	return account, err
+}
+
+// CloseAccountTxParams contains the input parameters of CloseAccountTx
+type CloseAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// SweepAccountID is the account of the same owner receiving the balance, 0 if the balance is zero
+	SweepAccountID int64 `json:"sweep_account_id"`
+}
+
+// CloseAccountTxResult is the result of CloseAccountTx
+type CloseAccountTxResult struct {
+	Account BankAccount `json:"account"`
+	// Sweep is the transfer of the balance to the sweep account, nil if the balance was zero
+	Sweep *TransferTxResult `json:"sweep,omitempty"`
+}
+
+// CloseAccountTx closes an active account. Its balance must be zero, or it is transferred to
+// the sweep account beforehand. It fails with ErrAccountHasHolds while amounts are held on the
+// account, as they are owed to the recipients of the holds. The entries and transfers of the
+// account are kept.
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error) {
+	var result CloseAccountTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		result = CloseAccountTxResult{}
+
+		account, sweepAccount, err := lockAccountsToClose(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		if !CanChangeAccountStatus(account.Status, AccountStatusClosed) {
+			return ErrInvalidStatusTransition
+		}
+
+		if account.Held > 0 {
+			return ErrAccountHasHolds
+		}
+
+		if account.Balance != 0 {
+			if arg.SweepAccountID == 0 || account.Balance < 0 {
+				return ErrAccountBalanceNotZero
+			}
+			if sweepAccount.ID == account.ID || sweepAccount.Owner != account.Owner ||
+				sweepAccount.Currency != account.Currency || sweepAccount.Status != AccountStatusActive {
+				return ErrInvalidSweepAccount
+			}
+
+			sweep, err := transfer(ctx, q, CreateTransferParams{
+				FromAccountID: account.ID,
+				ToAccountID:   sweepAccount.ID,
+				Amount:        account.Balance,
+			})
+			if err != nil {
+				return err
+			}
+			result.Sweep = &sweep
+		}
+
+		result.Account, err = q.UpdateBankAccountStatus(ctx, UpdateBankAccountStatusParams{
+			ID:     account.ID,
+			Status: AccountStatusClosed,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// lockAccountsToClose locks the account and the sweep account, if any, in the order of their IDs
+// like the transfers do, so that closing an account cannot deadlock with a transfer
+func lockAccountsToClose(ctx context.Context, q *Queries, arg CloseAccountTxParams) (account BankAccount, sweepAccount BankAccount, err error) {
+	if arg.SweepAccountID == 0 || arg.SweepAccountID == arg.AccountID {
+		account, err = q.GetBankAccountForUpdate(ctx, arg.AccountID)
+		return account, account, err
+	}
+
+	lockSweepAccount := func() error {
+		sweepAccount, err = q.GetBankAccountForUpdate(ctx, arg.SweepAccountID)
+		if errors.Is(err, ErrRecordNotFound) {
+			return ErrInvalidSweepAccount
+		}
+		return err
+	}
+
+	if arg.SweepAccountID < arg.AccountID {
+		if err = lockSweepAccount(); err != nil {
+			return
+		}
+	}
+
+	account, err = q.GetBankAccountForUpdate(ctx, arg.AccountID)
+	if err != nil {
+		return
+	}
+
+	if arg.SweepAccountID > arg.AccountID {
+		err = lockSweepAccount()
+	}
+	return
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanChangeAccountStatus(t *testing.T) {
+	require.True(t, CanChangeAccountStatus(AccountStatusActive, AccountStatusFrozen))
+	require.True(t, CanChangeAccountStatus(AccountStatusActive, AccountStatusClosed))
+	require.True(t, CanChangeAccountStatus(AccountStatusFrozen, AccountStatusActive))
+
+	require.False(t, CanChangeAccountStatus(AccountStatusActive, AccountStatusActive))
+	require.False(t, CanChangeAccountStatus(AccountStatusFrozen, AccountStatusClosed))
+	require.False(t, CanChangeAccountStatus(AccountStatusClosed, AccountStatusActive))
+	require.False(t, CanChangeAccountStatus(AccountStatusClosed, AccountStatusFrozen))
+	require.False(t, CanChangeAccountStatus("unknown", AccountStatusActive))
+}
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewStore(testPool)
+	account := createRandomAccount(t)
+
+	frozen, err := store.UpdateAccountStatusTx(context.Background(), UpdateBankAccountStatusParams{
+		ID:     account.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateBankAccountStatusParams{
+		ID:     account.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	reopened, err := store.UpdateAccountStatusTx(context.Background(), UpdateBankAccountStatusParams{
+		ID:     account.ID,
+		Status: AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, reopened.Status)
+	require.Equal(t, account.Balance, reopened.Balance)
+}
+
+func TestTransferTxInactiveAccount(t *testing.T) {
+	store := NewStore(testPool)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateBankAccountStatusParams{
+		ID:     account2.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	// nothing of the transfer is kept
+	updated1, err := testQueries.GetBankAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated1.Balance)
+}
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testPool)
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+
+	_, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+
+	// the sweep account must belong to the same owner
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: other.ID,
+	})
+	require.ErrorIs(t, err, ErrInvalidSweepAccount)
+
+	_, err = testQueries.UpdateBankAccount(context.Background(), UpdateBankAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.True(t, result.Account.ClosedAt.Valid)
+	require.Nil(t, result.Sweep)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+}
+
+func TestCloseAccountTxSweep(t *testing.T) {
+	store := NewStore(testPool)
+	account := createRandomAccount(t)
+	sweepAccount := createRandomAccountOf(t, account.Owner, account.Currency)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: sweepAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+
+	require.NotNil(t, result.Sweep)
+	require.Equal(t, account.Balance, result.Sweep.Transfer.Amount)
+	require.Equal(t, sweepAccount.Balance+account.Balance, result.Sweep.ToAccount.Balance)
+
+	// the history of the closed account is kept
+	transfer, err := testQueries.GetTransfer(context.Background(), result.Sweep.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, transfer.FromAccountID)
+}
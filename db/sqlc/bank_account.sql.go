@@ -1,16 +1,20 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 // source: bank_account.sql
 
 package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const addBankAccountBalance = `-- name: AddBankAccountBalance :one
-UPDATE bank_accounts SET balance = balance + $1 WHERE id = $2 RETURNING id, owner, balance, currency, created_at
+UPDATE bank_accounts SET balance = balance + $1
+WHERE id = $2 AND status = 'active'
+RETURNING id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance
 `
 
 type AddBankAccountBalanceParams struct {
@@ -19,7 +23,7 @@ type AddBankAccountBalanceParams struct {
 }
 
 func (q *Queries) AddBankAccountBalance(ctx context.Context, arg AddBankAccountBalanceParams) (BankAccount, error) {
-	row := q.queryRow(ctx, q.addBankAccountBalanceStmt, addBankAccountBalance, arg.Amount, arg.ID)
+	row := q.db.QueryRow(ctx, addBankAccountBalance, arg.Amount, arg.ID)
 	var i BankAccount
 	err := row.Scan(
 		&i.ID,
@@ -27,22 +31,41 @@ func (q *Queries) AddBankAccountBalance(ctx context.Context, arg AddBankAccountB
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const createBankAccount = `-- name: CreateBankAccount :one
-INSERT INTO bank_accounts (owner, balance, currency) VALUES ($1, $2, $3) RETURNING id, owner, balance, currency, created_at
+INSERT INTO bank_accounts (owner, balance, currency, nickname, account_number, product)
+VALUES ($1, $2, $3, $4, $5, COALESCE($6::varchar, 'checking'))
+RETURNING id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance
 `
 
 type CreateBankAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner         string      `json:"owner"`
+	Balance       int64       `json:"balance"`
+	Currency      string      `json:"currency"`
+	Nickname      string      `json:"nickname"`
+	AccountNumber string      `json:"accountNumber"`
+	Product       pgtype.Text `json:"product"`
 }
 
 func (q *Queries) CreateBankAccount(ctx context.Context, arg CreateBankAccountParams) (BankAccount, error) {
-	row := q.queryRow(ctx, q.createBankAccountStmt, createBankAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRow(ctx, createBankAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Nickname,
+		arg.AccountNumber,
+		arg.Product,
+	)
 	var i BankAccount
 	err := row.Scan(
 		&i.ID,
@@ -50,25 +73,47 @@ func (q *Queries) CreateBankAccount(ctx context.Context, arg CreateBankAccountPa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
-const deleteBankAccount = `-- name: DeleteBankAccount :exec
-DELETE FROM bank_accounts WHERE id = $1
+const getBankAccount = `-- name: GetBankAccount :one
+SELECT id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance FROM bank_accounts WHERE id = $1 LIMIT 1
 `
 
-func (q *Queries) DeleteBankAccount(ctx context.Context, id int64) error {
-	_, err := q.exec(ctx, q.deleteBankAccountStmt, deleteBankAccount, id)
-	return err
+func (q *Queries) GetBankAccount(ctx context.Context, id int64) (BankAccount, error) {
+	row := q.db.QueryRow(ctx, getBankAccount, id)
+	var i BankAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
+	)
+	return i, err
 }
 
-const getBankAccount = `-- name: GetBankAccount :one
-SELECT id, owner, balance, currency, created_at FROM bank_accounts WHERE id = $1 LIMIT 1
+const getBankAccountByNumber = `-- name: GetBankAccountByNumber :one
+SELECT id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance FROM bank_accounts WHERE account_number = $1 LIMIT 1
 `
 
-func (q *Queries) GetBankAccount(ctx context.Context, id int64) (BankAccount, error) {
-	row := q.queryRow(ctx, q.getBankAccountStmt, getBankAccount, id)
+func (q *Queries) GetBankAccountByNumber(ctx context.Context, accountNumber string) (BankAccount, error) {
+	row := q.db.QueryRow(ctx, getBankAccountByNumber, accountNumber)
 	var i BankAccount
 	err := row.Scan(
 		&i.ID,
@@ -76,16 +121,23 @@ func (q *Queries) GetBankAccount(ctx context.Context, id int64) (BankAccount, er
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const getBankAccountForUpdate = `-- name: GetBankAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM bank_accounts WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
+SELECT id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance FROM bank_accounts WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
 `
 
 func (q *Queries) GetBankAccountForUpdate(ctx context.Context, id int64) (BankAccount, error) {
-	row := q.queryRow(ctx, q.getBankAccountForUpdateStmt, getBankAccountForUpdate, id)
+	row := q.db.QueryRow(ctx, getBankAccountForUpdate, id)
 	var i BankAccount
 	err := row.Scan(
 		&i.ID,
@@ -93,22 +145,43 @@ func (q *Queries) GetBankAccountForUpdate(ctx context.Context, id int64) (BankAc
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const listBankAccounts = `-- name: ListBankAccounts :many
-SELECT id, owner, balance, currency, created_at FROM bank_accounts WHERE owner = $1 ORDER BY id LIMIT $2 OFFSET $3
+SELECT id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance FROM bank_accounts
+WHERE owner = $1
+  AND ($2::varchar IS NULL OR currency = $2)
+  AND ($3::timestamptz IS NULL
+    OR (created_at, id) > ($3, $4::bigint))
+ORDER BY created_at, id
+LIMIT $5
 `
 
 type ListBankAccountsParams struct {
-	Owner  string `json:"owner"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+	Owner           string             `json:"owner"`
+	Currency        pgtype.Text        `json:"currency"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        int64              `json:"cursorID"`
+	Limit           int32              `json:"limit"`
 }
 
 func (q *Queries) ListBankAccounts(ctx context.Context, arg ListBankAccountsParams) ([]BankAccount, error) {
-	rows, err := q.query(ctx, q.listBankAccountsStmt, listBankAccounts, arg.Owner, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listBankAccounts,
+		arg.Owner,
+		arg.Currency,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -122,14 +195,18 @@ func (q *Queries) ListBankAccounts(ctx context.Context, arg ListBankAccountsPara
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Status,
+			&i.ClosedAt,
+			&i.Nickname,
+			&i.AccountNumber,
+			&i.Product,
+			&i.Held,
+			&i.AvailableBalance,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
@@ -137,7 +214,7 @@ func (q *Queries) ListBankAccounts(ctx context.Context, arg ListBankAccountsPara
 }
 
 const updateBankAccount = `-- name: UpdateBankAccount :one
-UPDATE bank_accounts SET balance = $2 WHERE id = $1 RETURNING id, owner, balance, currency, created_at
+UPDATE bank_accounts SET balance = $2 WHERE id = $1 RETURNING id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance
 `
 
 type UpdateBankAccountParams struct {
@@ -146,7 +223,69 @@ type UpdateBankAccountParams struct {
 }
 
 func (q *Queries) UpdateBankAccount(ctx context.Context, arg UpdateBankAccountParams) (BankAccount, error) {
-	row := q.queryRow(ctx, q.updateBankAccountStmt, updateBankAccount, arg.ID, arg.Balance)
+	row := q.db.QueryRow(ctx, updateBankAccount, arg.ID, arg.Balance)
+	var i BankAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const updateBankAccountNickname = `-- name: UpdateBankAccountNickname :one
+UPDATE bank_accounts SET nickname = $1 WHERE id = $2 RETURNING id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance
+`
+
+type UpdateBankAccountNicknameParams struct {
+	Nickname string `json:"nickname"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) UpdateBankAccountNickname(ctx context.Context, arg UpdateBankAccountNicknameParams) (BankAccount, error) {
+	row := q.db.QueryRow(ctx, updateBankAccountNickname, arg.Nickname, arg.ID)
+	var i BankAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const updateBankAccountStatus = `-- name: UpdateBankAccountStatus :one
+UPDATE bank_accounts
+SET status = $1,
+    closed_at = CASE WHEN $1::varchar = 'closed' THEN now() END
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance
+`
+
+type UpdateBankAccountStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateBankAccountStatus(ctx context.Context, arg UpdateBankAccountStatusParams) (BankAccount, error) {
+	row := q.db.QueryRow(ctx, updateBankAccountStatus, arg.Status, arg.ID)
 	var i BankAccount
 	err := row.Scan(
 		&i.ID,
@@ -154,6 +293,13 @@ func (q *Queries) UpdateBankAccount(ctx context.Context, arg UpdateBankAccountPa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
@@ -11,11 +11,20 @@ import (
 
 func createRandomAccount(t *testing.T) BankAccount {
 	user := createRandomUser(t)
+	return createRandomAccountOf(t, user.Username, tools.RandomCurrency())
+}
+
+func createRandomAccountOf(t *testing.T, owner string, currency string) BankAccount {
+	accountNumber, err := tools.NewAccountNumber()
+	require.NoError(t, err)
 
+	// the balance is enough to fund the transfers of the tests, which cannot overdraw the account
 	arg := CreateBankAccountParams{
-		Owner:    user.Username,
-		Balance:  tools.RandomMoney(),
-		Currency: tools.RandomCurrency(),
+		Owner:         owner,
+		Balance:       tools.RandomInt(1000, 100000),
+		Currency:      currency,
+		Nickname:      tools.RandomString(8),
+		AccountNumber: accountNumber,
 	}
 	account, err := testQueries.CreateBankAccount(context.Background(), arg)
 	require.NoError(t, err)
@@ -23,7 +32,10 @@ func createRandomAccount(t *testing.T) BankAccount {
 
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
+	require.Equal(t, arg.Balance, account.AvailableBalance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.Nickname, account.Nickname)
+	require.Equal(t, arg.AccountNumber, account.AccountNumber)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -70,15 +82,56 @@ func TestUpdateBankAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
-func TestDeleteBankAccount(t *testing.T) {
+func TestSeveralAccountsPerCurrency(t *testing.T) {
 	account1 := createRandomAccount(t)
+	account2 := createRandomAccountOf(t, account1.Owner, account1.Currency)
+	require.NotEqual(t, account1.AccountNumber, account2.AccountNumber)
 
-	err := testQueries.DeleteBankAccount(context.Background(), account1.ID)
+	got, err := testQueries.GetBankAccountByNumber(context.Background(), account2.AccountNumber)
 	require.NoError(t, err)
+	require.Equal(t, account2.ID, got.ID)
+
+	// account numbers are unique
+	_, err = testQueries.CreateBankAccount(context.Background(), CreateBankAccountParams{
+		Owner:         account1.Owner,
+		Currency:      account1.Currency,
+		AccountNumber: account1.AccountNumber,
+	})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+}
 
-	account2, err := testQueries.GetBankAccount(context.Background(), account1.ID)
-	require.Error(t, err)
-	require.Empty(t, account2)
+func TestUpdateBankAccountNickname(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	account2, err := testQueries.UpdateBankAccountNickname(context.Background(), UpdateBankAccountNicknameParams{
+		ID:       account1.ID,
+		Nickname: "savings",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "savings", account2.Nickname)
+	require.Equal(t, account1.AccountNumber, account2.AccountNumber)
+}
+
+func TestUpdateBankAccountStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Equal(t, AccountStatusActive, account1.Status)
+	require.False(t, account1.ClosedAt.Valid)
+
+	account2, err := testQueries.UpdateBankAccountStatus(context.Background(), UpdateBankAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, account2.Status)
+	require.True(t, account2.ClosedAt.Valid)
+	require.WithinDuration(t, time.Now(), account2.ClosedAt.Time, time.Second)
+
+	// the money of a closed account cannot move anymore
+	_, err = testQueries.AddBankAccountBalance(context.Background(), AddBankAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: 10,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
 }
 
 func TestListBankAccounts(t *testing.T) {
@@ -89,9 +142,8 @@ func TestListBankAccounts(t *testing.T) {
 	}
 
 	arg := ListBankAccountsParams{
-		Owner:  lastAccount.Owner,
-		Limit:  5,
-		Offset: 0,
+		Owner: lastAccount.Owner,
+		Limit: 5,
 	}
 
 	accounts, err := testQueries.ListBankAccounts(context.Background(), arg)
@@ -102,4 +154,12 @@ func TestListBankAccounts(t *testing.T) {
 		require.NotEmpty(t, account)
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
+
+	// the page after the last account is empty
+	arg.CursorCreatedAt = NullTime(accounts[len(accounts)-1].CreatedAt)
+	arg.CursorID = accounts[len(accounts)-1].ID
+
+	accounts, err = testQueries.ListBankAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
 }
@@ -1,16 +1,19 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 // source: entry.sql
 
 package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createEntry = `-- name: CreateEntry :one
-INSERT INTO entries (account_id, amount) VALUES ($1, $2) RETURNING id, account_id, amount, created_at
+INSERT INTO entries (account_id, amount) VALUES ($1, $2) RETURNING id, account_id, amount, created_at, category
 `
 
 type CreateEntryParams struct {
@@ -19,45 +22,96 @@ type CreateEntryParams struct {
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.queryRow(ctx, q.createEntryStmt, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry, arg.AccountID, arg.Amount)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Category,
+	)
+	return i, err
+}
+
+const createEntryWithCategory = `-- name: CreateEntryWithCategory :one
+INSERT INTO entries (account_id, amount, category) VALUES ($1, $2, $3) RETURNING id, account_id, amount, created_at, category
+`
+
+type CreateEntryWithCategoryParams struct {
+	AccountID int64  `json:"accountID"`
+	Amount    int64  `json:"amount"`
+	Category  string `json:"category"`
+}
+
+func (q *Queries) CreateEntryWithCategory(ctx context.Context, arg CreateEntryWithCategoryParams) (Entry, error) {
+	row := q.db.QueryRow(ctx, createEntryWithCategory, arg.AccountID, arg.Amount, arg.Category)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Category,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries WHERE id = $1 LIMIT 1
+SELECT id, account_id, amount, created_at, category FROM entries WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
-	row := q.queryRow(ctx, q.getEntryStmt, getEntry, id)
+	row := q.db.QueryRow(ctx, getEntry, id)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Category,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries WHERE account_id = $1 ORDER BY id LIMIT $2 OFFSET $3
+SELECT id, account_id, amount, created_at, category FROM entries
+WHERE account_id = $1
+  AND ($2::timestamptz IS NULL OR created_at >= $2)
+  AND ($3::timestamptz IS NULL OR created_at < $3)
+  AND ($4::bigint IS NULL OR abs(amount) >= $4)
+  AND ($5::bigint IS NULL OR abs(amount) <= $5)
+  AND ($6::varchar IS NULL OR category = $6)
+  AND ($7::timestamptz IS NULL
+    OR (created_at, id) < ($7, $8::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
 type ListEntriesParams struct {
-	AccountID int64 `json:"accountID"`
-	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
+	AccountID       int64              `json:"accountID"`
+	FromTime        pgtype.Timestamptz `json:"fromTime"`
+	ToTime          pgtype.Timestamptz `json:"toTime"`
+	MinAmount       pgtype.Int8        `json:"minAmount"`
+	MaxAmount       pgtype.Int8        `json:"maxAmount"`
+	Category        pgtype.Text        `json:"category"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        int64              `json:"cursorID"`
+	Limit           int32              `json:"limit"`
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.query(ctx, q.listEntriesStmt, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Category,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -70,16 +124,84 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Category,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT id, account_id, amount, created_at, category FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+  AND ($4::varchar IS NULL OR category = $4)
+ORDER BY created_at, id
+`
+
+type ListStatementEntriesParams struct {
+	AccountID int64       `json:"accountID"`
+	FromTime  time.Time   `json:"fromTime"`
+	ToTime    time.Time   `json:"toTime"`
+	Category  pgtype.Text `json:"category"`
+}
+
+// The entries of an account over a period, oldest first, for the statement export.
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listStatementEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Category,
+	)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Category,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
+
+const updateEntryCategory = `-- name: UpdateEntryCategory :one
+UPDATE entries SET category = $1 WHERE id = $2 RETURNING id, account_id, amount, created_at, category
+`
+
+type UpdateEntryCategoryParams struct {
+	Category string `json:"category"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) UpdateEntryCategory(ctx context.Context, arg UpdateEntryCategoryParams) (Entry, error) {
+	row := q.db.QueryRow(ctx, updateEntryCategory, arg.Category, arg.ID)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Category,
+	)
+	return i, err
+}
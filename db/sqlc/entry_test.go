@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomEntry(t *testing.T, account BankAccount) Entry {
+	entry, err := testQueries.CreateEntry(context.Background(), CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    tools.RandomMoney(),
+	})
+	require.NoError(t, err)
+	require.Empty(t, entry.Category)
+
+	return entry
+}
+
+func TestUpdateEntryCategory(t *testing.T) {
+	account := createRandomAccount(t)
+	entry := createRandomEntry(t, account)
+
+	updated, err := testQueries.UpdateEntryCategory(context.Background(), UpdateEntryCategoryParams{
+		ID:       entry.ID,
+		Category: "groceries",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "groceries", updated.Category)
+	require.Equal(t, entry.Amount, updated.Amount)
+}
+
+func TestListEntriesByCategory(t *testing.T) {
+	account := createRandomAccount(t)
+	for i := 0; i < 3; i++ {
+		entry := createRandomEntry(t, account)
+		if i != 1 {
+			_, err := testQueries.UpdateEntryCategory(context.Background(), UpdateEntryCategoryParams{
+				ID:       entry.ID,
+				Category: "rent",
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account.ID,
+		Category:  NullString("rent"),
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		require.Equal(t, "rent", entry.Category)
+	}
+}
+
+func TestListStatementEntries(t *testing.T) {
+	account := createRandomAccount(t)
+	fromTime := time.Now().Add(-time.Minute)
+
+	var created []Entry
+	for i := 0; i < 3; i++ {
+		created = append(created, createRandomEntry(t, account))
+	}
+
+	_, err := testQueries.UpdateEntryCategory(context.Background(), UpdateEntryCategoryParams{
+		ID:       created[2].ID,
+		Category: "rent",
+	})
+	require.NoError(t, err)
+
+	entries, err := testQueries.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  fromTime,
+		ToTime:    time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	// oldest first, with the categories
+	for i, entry := range entries {
+		require.Equal(t, created[i].ID, entry.ID)
+	}
+	require.Equal(t, "rent", entries[2].Category)
+
+	entries, err = testQueries.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  fromTime,
+		ToTime:    time.Now().Add(time.Minute),
+		Category:  NullString("rent"),
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, created[2].ID, entries[0].ID)
+}
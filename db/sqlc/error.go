@@ -0,0 +1,90 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes the callers of the store react to
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// AccountNumberConstraint is the unique constraint violated by an account number already in use
+const AccountNumberConstraint = "bank_accounts_account_number_key"
+
+// Errors returned by the store, whatever the driver. The errors of the driver
+// are wrapped in an *Error that matches one of them with errors.Is.
+var (
+	// ErrRecordNotFound is returned by the queries of a single row when no row matches
+	ErrRecordNotFound      = pgx.ErrNoRows
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrSerialization is returned when a transaction still fails on a serialization
+	// failure or a deadlock after it has been retried
+	ErrSerialization = errors.New("serialization failure")
+)
+
+// Error is an error of the driver classified as one of the errors of the store
+type Error struct {
+	// Kind is the error of the store, such as ErrUniqueViolation
+	Kind error
+	// Constraint is the name of the violated constraint, if any
+	Constraint string
+	// Err is the error of the driver
+	Err error
+}
+
+func (err *Error) Error() string {
+	return err.Err.Error()
+}
+
+// Is reports whether the error is of the given kind
+func (err *Error) Is(target error) bool {
+	return target == err.Kind
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// ErrorCode returns the Postgres error code of the error,
+// or an empty string if the error was not raised by Postgres
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// classifyError wraps an error of the driver in an *Error of the matching kind.
+// Other errors, and errors already classified, are returned unchanged.
+func classifyError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var dbErr *Error
+	if errors.As(err, &dbErr) {
+		return err
+	}
+
+	var kind error
+	switch pgErr.Code {
+	case UniqueViolation:
+		kind = ErrUniqueViolation
+	case ForeignKeyViolation:
+		kind = ErrForeignKeyViolation
+	case serializationFailureCode, deadlockDetectedCode:
+		kind = ErrSerialization
+	default:
+		return err
+	}
+
+	return &Error{Kind: kind, Constraint: pgErr.ConstraintName, Err: err}
+}
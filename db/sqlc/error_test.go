@@ -0,0 +1,42 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	err := classifyError(&pgconn.PgError{Code: UniqueViolation, ConstraintName: "users_email_key"})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+	require.NotErrorIs(t, err, ErrForeignKeyViolation)
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	require.Equal(t, "users_email_key", dbErr.Constraint)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// classifying twice keeps the first classification
+	require.Same(t, err, classifyError(err))
+
+	err = classifyError(fmt.Errorf("tx err: %w", &pgconn.PgError{Code: ForeignKeyViolation}))
+	require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+	err = classifyError(&pgconn.PgError{Code: deadlockDetectedCode})
+	require.ErrorIs(t, err, ErrSerialization)
+	code, ok := retryableTxError(err)
+	require.True(t, ok)
+	require.Equal(t, deadlockDetectedCode, code)
+
+	other := &pgconn.PgError{Code: "22001"}
+	require.Same(t, other, classifyError(other))
+
+	require.NoError(t, classifyError(nil))
+	require.ErrorIs(t, classifyError(ErrRecordNotFound), ErrRecordNotFound)
+
+	plain := errors.New("connection refused")
+	require.Equal(t, plain, classifyError(plain))
+}
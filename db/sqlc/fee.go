@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrFeeRevenueAccountNotActive is returned when the revenue account of a fee schedule is frozen or closed.
+// It is an error of the fee schedules rather than of the transfer, so it is not reported as ErrAccountNotActive.
+var ErrFeeRevenueAccountNotActive = errors.New("fee revenue account is not active")
+
+// Types of transfer a fee schedule applies to
+const (
+	TransferTypeOwn        = "own"
+	TransferTypeCrossOwner = "cross_owner"
+)
+
+// Fee returns the fee of a transfer of the amount: the flat fee plus the percentage of the amount,
+// rounded half up, kept between the minimum and the maximum fee of the schedule
+func (schedule FeeSchedule) Fee(amount int64) int64 {
+	// split the amount so that multiplying by the basis points cannot overflow
+	percent := amount/10000*schedule.PercentBps + (amount%10000*schedule.PercentBps+5000)/10000
+
+	fee := schedule.FlatFee + percent
+	if fee < schedule.MinFee {
+		fee = schedule.MinFee
+	}
+	if schedule.MaxFee.Valid && fee > schedule.MaxFee.Int64 {
+		fee = schedule.MaxFee.Int64
+	}
+	return fee
+}
+
+// TransferFee returns the fee of the transfer, zero if no fee schedule applies to it
+func TransferFee(ctx context.Context, q Querier, arg CreateTransferParams) (fee int64, schedule FeeSchedule, err error) {
+	schedule, err = q.GetTransferFeeSchedule(ctx, GetTransferFeeScheduleParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			err = nil
+		}
+		return
+	}
+
+	return schedule.Fee(arg.Amount), schedule, nil
+}
+
+// chargeFee moves the fee of a transfer from the sender to the fee revenue account,
+// with an entry on each account. It returns the entry and the account of the sender.
+func chargeFee(ctx context.Context, q *Queries, fromAccountID int64, revenueAccountID int64, fee int64) (entry Entry, fromAccount BankAccount, err error) {
+	entry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: fromAccountID,
+		Amount:    -fee,
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: revenueAccountID,
+		Amount:    fee,
+	})
+	if err != nil {
+		return
+	}
+
+	// the accounts of the transfer are already locked, the revenue account is always updated last
+	fromAccount, err = q.AddBankAccountBalance(ctx, AddBankAccountBalanceParams{
+		ID:     fromAccountID,
+		Amount: -fee,
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = q.AddBankAccountBalance(ctx, AddBankAccountBalanceParams{
+		ID:     revenueAccountID,
+		Amount: fee,
+	})
+	if errors.Is(err, ErrRecordNotFound) {
+		err = ErrFeeRevenueAccountNotActive
+	}
+	return
+}
@@ -0,0 +1,101 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: fee_schedule.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getTransferFeeSchedule = `-- name: GetTransferFeeSchedule :one
+SELECT fee_schedules.id, fee_schedules.currency, fee_schedules.transfer_type, fee_schedules.tier, fee_schedules.flat_fee, fee_schedules.percent_bps, fee_schedules.min_fee, fee_schedules.max_fee, fee_schedules.revenue_account_id, fee_schedules.updated_at
+FROM bank_accounts sender
+JOIN bank_accounts recipient ON recipient.id = $1
+JOIN users ON users.username = sender.owner
+JOIN fee_schedules ON fee_schedules.currency = sender.currency
+  AND fee_schedules.tier = users.tier
+  AND fee_schedules.transfer_type = CASE WHEN sender.owner = recipient.owner THEN 'own' ELSE 'cross_owner' END
+WHERE sender.id = $2
+LIMIT 1
+`
+
+type GetTransferFeeScheduleParams struct {
+	ToAccountID   int64 `json:"toAccountID"`
+	FromAccountID int64 `json:"fromAccountID"`
+}
+
+func (q *Queries) GetTransferFeeSchedule(ctx context.Context, arg GetTransferFeeScheduleParams) (FeeSchedule, error) {
+	row := q.db.QueryRow(ctx, getTransferFeeSchedule, arg.ToAccountID, arg.FromAccountID)
+	var i FeeSchedule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.TransferType,
+		&i.Tier,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.RevenueAccountID,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setFeeSchedule = `-- name: SetFeeSchedule :one
+INSERT INTO fee_schedules (
+  currency, transfer_type, tier, flat_fee, percent_bps, min_fee, max_fee, revenue_account_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (currency, transfer_type, tier) DO UPDATE
+SET flat_fee = EXCLUDED.flat_fee,
+    percent_bps = EXCLUDED.percent_bps,
+    min_fee = EXCLUDED.min_fee,
+    max_fee = EXCLUDED.max_fee,
+    revenue_account_id = EXCLUDED.revenue_account_id,
+    updated_at = now()
+RETURNING id, currency, transfer_type, tier, flat_fee, percent_bps, min_fee, max_fee, revenue_account_id, updated_at
+`
+
+type SetFeeScheduleParams struct {
+	Currency         string      `json:"currency"`
+	TransferType     string      `json:"transferType"`
+	Tier             string      `json:"tier"`
+	FlatFee          int64       `json:"flatFee"`
+	PercentBps       int64       `json:"percentBps"`
+	MinFee           int64       `json:"minFee"`
+	MaxFee           pgtype.Int8 `json:"maxFee"`
+	RevenueAccountID int64       `json:"revenueAccountID"`
+}
+
+func (q *Queries) SetFeeSchedule(ctx context.Context, arg SetFeeScheduleParams) (FeeSchedule, error) {
+	row := q.db.QueryRow(ctx, setFeeSchedule,
+		arg.Currency,
+		arg.TransferType,
+		arg.Tier,
+		arg.FlatFee,
+		arg.PercentBps,
+		arg.MinFee,
+		arg.MaxFee,
+		arg.RevenueAccountID,
+	)
+	var i FeeSchedule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.TransferType,
+		&i.Tier,
+		&i.FlatFee,
+		&i.PercentBps,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.RevenueAccountID,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFee(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   int64
+		fee      int64
+	}{
+		{
+			name:     "Flat",
+			schedule: FeeSchedule{FlatFee: 25},
+			amount:   10000,
+			fee:      25,
+		},
+		{
+			name:     "Percent",
+			schedule: FeeSchedule{PercentBps: 150},
+			amount:   10000,
+			fee:      150,
+		},
+		{
+			name:     "RoundedHalfUp",
+			schedule: FeeSchedule{PercentBps: 150},
+			amount:   1234,
+			fee:      19,
+		},
+		{
+			name:     "Minimum",
+			schedule: FeeSchedule{PercentBps: 100, MinFee: 50},
+			amount:   1000,
+			fee:      50,
+		},
+		{
+			name:     "Maximum",
+			schedule: FeeSchedule{FlatFee: 10, PercentBps: 100, MaxFee: pgtype.Int8{Int64: 500, Valid: true}},
+			amount:   1000000,
+			fee:      500,
+		},
+		{
+			name:     "LargeAmount",
+			schedule: FeeSchedule{PercentBps: 10000},
+			amount:   1 << 60,
+			fee:      1 << 60,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.fee, tc.schedule.Fee(tc.amount))
+		})
+	}
+}
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testPool)
+
+	sender := createRandomUser(t)
+	account1 := createRandomAccountOf(t, sender.Username, tools.CAD)
+	account2 := createRandomAccountOf(t, sender.Username, tools.CAD)
+	recipient := createRandomAccountOf(t, createRandomUser(t).Username, tools.CAD)
+	revenue := createRandomAccountOf(t, createRandomUser(t).Username, tools.CAD)
+
+	_, err := store.UpdateUser(context.Background(), UpdateUserParams{
+		Username: sender.Username,
+		Tier:     pgtype.Text{String: tools.PremiumTier, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// the schedules are shared by the tests, the premium tier in CAD is only used here
+	_, err = store.SetFeeSchedule(context.Background(), SetFeeScheduleParams{
+		Currency:         tools.CAD,
+		TransferType:     TransferTypeCrossOwner,
+		Tier:             tools.PremiumTier,
+		FlatFee:          5,
+		RevenueAccountID: revenue.ID,
+	})
+	require.NoError(t, err)
+
+	amount := int64(10)
+	result, err := store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   recipient.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), result.Transfer.Fee)
+	require.NotNil(t, result.FeeEntry)
+	require.Equal(t, int64(-5), result.FeeEntry.Amount)
+	require.Equal(t, account1.Balance-amount-5, result.FromAccount.Balance)
+
+	updatedRevenue, err := store.GetBankAccount(context.Background(), revenue.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenue.Balance+5, updatedRevenue.Balance)
+
+	// no schedule for transfers between the accounts of the same user
+	result, err = store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Transfer.Fee)
+	require.Nil(t, result.FeeEntry)
+
+	// a frozen revenue account fails the transfer, without blaming the accounts of the customer
+	_, err = store.UpdateBankAccountStatus(context.Background(), UpdateBankAccountStatusParams{
+		ID:     revenue.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   recipient.ID,
+		Amount:        amount,
+	})
+	require.ErrorIs(t, err, ErrFeeRevenueAccountNotActive)
+	require.NotErrorIs(t, err, ErrAccountNotActive)
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Statuses of a hold. Only an authorized hold reduces the available balance of its account,
+// it is released when it is captured, voided or expires.
+const (
+	HoldStatusAuthorized = "authorized"
+	HoldStatusCaptured   = "captured"
+	HoldStatusVoided     = "voided"
+	HoldStatusExpired    = "expired"
+)
+
+var (
+	// ErrInsufficientFunds is returned when a transfer, its fee or a hold is larger than the available balance of the account
+	ErrInsufficientFunds = errors.New("available balance is insufficient")
+	// ErrHoldNotAuthorized is returned when a hold that was already released is captured or voided
+	ErrHoldNotAuthorized = errors.New("hold is already captured, voided or expired")
+	// ErrCaptureExceedsHold is returned when the amount captured is larger than the hold
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the hold")
+)
+
+// AuthorizeTransferTxResult is the result of AuthorizeTransferTx
+type AuthorizeTransferTxResult struct {
+	Hold        Hold        `json:"hold"`
+	FromAccount BankAccount `json:"from_account"`
+}
+
+// AuthorizeTransferTx places a hold of the amount on the from account, to be transferred to the
+// to account when it is captured. The hold reduces the available balance of the account but not its
+// ledger balance. It fails with ErrInsufficientFunds if the amount exceeds the available balance, and
+// with ErrAccountNotActive if the account is frozen or closed. The limits and the fee of the transfer
+// apply when the hold is captured.
+func (store *SQLStore) AuthorizeTransferTx(ctx context.Context, arg CreateHoldParams) (AuthorizeTransferTxResult, error) {
+	var result AuthorizeTransferTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		if err := useStepUpChallenge(ctx, q, arg.StepUpChallengeID); err != nil {
+			return err
+		}
+
+		account, err := q.GetBankAccountForUpdate(ctx, arg.FromAccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Status != AccountStatusActive {
+			return ErrAccountNotActive
+		}
+
+		if account.AvailableBalance < arg.Amount {
+			return fmt.Errorf("hold of %d on account [%d] available %d: %w", arg.Amount, account.ID, account.AvailableBalance, ErrInsufficientFunds)
+		}
+
+		result.Hold, err = q.CreateHold(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		result.FromAccount, err = q.AddBankAccountHeld(ctx, AddBankAccountHeldParams{
+			ID:     arg.FromAccountID,
+			Amount: arg.Amount,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// CaptureTransferTxParams contains the input parameters of CaptureTransferTx
+type CaptureTransferTxParams struct {
+	HoldID int64
+	// Amount is the amount to transfer, the whole hold if it is zero
+	Amount int64
+	// Now is the time the expiry of the hold is checked against
+	Now time.Time
+}
+
+// CaptureTransferTxResult is the result of CaptureTransferTx
+type CaptureTransferTxResult struct {
+	Hold Hold `json:"hold"`
+	TransferTxResult
+}
+
+// CaptureTransferTx settles an authorized hold: it transfers the whole hold or a part of it, like
+// TransferTx does, and releases the hold. The part of the hold that is not captured becomes available
+// again, a hold is only captured once. It fails with ErrHoldNotAuthorized if the hold was already
+// released or has expired, and with ErrCaptureExceedsHold if the amount is larger than the hold.
+func (store *SQLStore) CaptureTransferTx(ctx context.Context, arg CaptureTransferTxParams) (CaptureTransferTxResult, error) {
+	var result CaptureTransferTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		hold, err := lockAuthorizedHold(ctx, q, arg.HoldID, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		// the sender and the accounts are locked in the same order as by TransferTx,
+		// the capture can spend the amount of its own hold
+		limits, err := store.lockTransferLimits(ctx, q, hold.FromAccountID)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = store.executeTransfer(ctx, q, CreateTransferParams{
+			FromAccountID: hold.FromAccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        amount,
+			Memo:          hold.Memo,
+			Reference:     hold.Reference,
+		}, hold.Amount, limits)
+		if err != nil {
+			return err
+		}
+
+		result.FromAccount, err = q.AddBankAccountHeld(ctx, AddBankAccountHeldParams{
+			ID:     hold.FromAccountID,
+			Amount: -hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.ReleaseHold(ctx, ReleaseHoldParams{
+			ID:             hold.ID,
+			Status:         HoldStatusCaptured,
+			CapturedAmount: amount,
+			TransferID:     pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// VoidTransferTxParams contains the input parameters of VoidTransferTx
+type VoidTransferTxParams struct {
+	HoldID int64
+	// Now is the time the expiry of the hold is checked against
+	Now time.Time
+}
+
+// VoidTransferTxResult is the result of VoidTransferTx
+type VoidTransferTxResult struct {
+	Hold        Hold        `json:"hold"`
+	FromAccount BankAccount `json:"from_account"`
+}
+
+// VoidTransferTx releases an authorized hold without transferring anything.
+// It fails with ErrHoldNotAuthorized if the hold was already released or has expired.
+func (store *SQLStore) VoidTransferTx(ctx context.Context, arg VoidTransferTxParams) (VoidTransferTxResult, error) {
+	var result VoidTransferTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		hold, err := lockAuthorizedHold(ctx, q, arg.HoldID, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, result.FromAccount, err = releaseHeldAmount(ctx, q, hold, HoldStatusVoided)
+		return err
+	})
+
+	return result, err
+}
+
+// ExpireHoldsTx releases up to limit authorized holds that expired before the time. Holds locked
+// by another transaction are skipped, so several instances can expire holds at the same time.
+func (store *SQLStore) ExpireHoldsTx(ctx context.Context, now time.Time, limit int32) ([]Hold, error) {
+	var expired []Hold
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		expired = nil
+
+		holds, err := q.ListExpiredHolds(ctx, ListExpiredHoldsParams{Now: now, Limit: limit})
+		if err != nil {
+			return err
+		}
+
+		for _, hold := range holds {
+			hold, _, err := releaseHeldAmount(ctx, q, hold, HoldStatusExpired)
+			if err != nil {
+				return err
+			}
+			expired = append(expired, hold)
+		}
+		return nil
+	})
+
+	return expired, err
+}
+
+// lockAuthorizedHold locks the hold, it fails with ErrHoldNotAuthorized if the hold
+// was already released or has expired, even if the expiry did not release it yet
+func lockAuthorizedHold(ctx context.Context, q *Queries, id int64, now time.Time) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, id)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Status != HoldStatusAuthorized || !now.Before(hold.ExpiresAt) {
+		return hold, ErrHoldNotAuthorized
+	}
+	return hold, nil
+}
+
+// releaseHeldAmount gives the held amount back to the available balance and marks the hold with the status
+func releaseHeldAmount(ctx context.Context, q *Queries, hold Hold, status string) (Hold, BankAccount, error) {
+	// held amounts are released even when the account is frozen or closed
+	account, err := q.AddBankAccountHeld(ctx, AddBankAccountHeldParams{
+		ID:     hold.FromAccountID,
+		Amount: -hold.Amount,
+	})
+	if err != nil {
+		return hold, account, err
+	}
+
+	hold, err = q.ReleaseHold(ctx, ReleaseHoldParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+	return hold, account, err
+}
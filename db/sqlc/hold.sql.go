@@ -0,0 +1,230 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: hold.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addBankAccountHeld = `-- name: AddBankAccountHeld :one
+UPDATE bank_accounts SET held = held + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, status, closed_at, nickname, account_number, product, held, available_balance
+`
+
+type AddBankAccountHeldParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddBankAccountHeld(ctx context.Context, arg AddBankAccountHeldParams) (BankAccount, error) {
+	row := q.db.QueryRow(ctx, addBankAccountHeld, arg.Amount, arg.ID)
+	var i BankAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ClosedAt,
+		&i.Nickname,
+		&i.AccountNumber,
+		&i.Product,
+		&i.Held,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const createHold = `-- name: CreateHold :one
+INSERT INTO holds (from_account_id, to_account_id, amount, memo, reference, step_up_challenge_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, from_account_id, to_account_id, amount, memo, reference, status, captured_amount, transfer_id, step_up_challenge_id, expires_at, created_at, released_at
+`
+
+type CreateHoldParams struct {
+	FromAccountID     int64         `json:"fromAccountID"`
+	ToAccountID       int64         `json:"toAccountID"`
+	Amount            int64         `json:"amount"`
+	Memo              string        `json:"memo"`
+	Reference         string        `json:"reference"`
+	StepUpChallengeID uuid.NullUUID `json:"stepUpChallengeID"`
+	ExpiresAt         time.Time     `json:"expiresAt"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, createHold,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Memo,
+		arg.Reference,
+		arg.StepUpChallengeID,
+		arg.ExpiresAt,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Memo,
+		&i.Reference,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.StepUpChallengeID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
+
+const getHold = `-- name: GetHold :one
+SELECT id, from_account_id, to_account_id, amount, memo, reference, status, captured_amount, transfer_id, step_up_challenge_id, expires_at, created_at, released_at FROM holds WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetHold(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRow(ctx, getHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Memo,
+		&i.Reference,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.StepUpChallengeID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
+
+const getHoldForUpdate = `-- name: GetHoldForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, memo, reference, status, captured_amount, transfer_id, step_up_challenge_id, expires_at, created_at, released_at FROM holds WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetHoldForUpdate(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRow(ctx, getHoldForUpdate, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Memo,
+		&i.Reference,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.StepUpChallengeID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
+
+const listExpiredHolds = `-- name: ListExpiredHolds :many
+SELECT id, from_account_id, to_account_id, amount, memo, reference, status, captured_amount, transfer_id, step_up_challenge_id, expires_at, created_at, released_at FROM holds
+WHERE status = 'authorized' AND expires_at <= $1
+ORDER BY id
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type ListExpiredHoldsParams struct {
+	Now   time.Time `json:"now"`
+	Limit int32     `json:"limit"`
+}
+
+func (q *Queries) ListExpiredHolds(ctx context.Context, arg ListExpiredHoldsParams) ([]Hold, error) {
+	rows, err := q.db.Query(ctx, listExpiredHolds, arg.Now, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Hold{}
+	for rows.Next() {
+		var i Hold
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Memo,
+			&i.Reference,
+			&i.Status,
+			&i.CapturedAmount,
+			&i.TransferID,
+			&i.StepUpChallengeID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.ReleasedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseHold = `-- name: ReleaseHold :one
+UPDATE holds
+SET status = $1,
+    captured_amount = $2,
+    transfer_id = $3,
+    released_at = now()
+WHERE id = $4
+RETURNING id, from_account_id, to_account_id, amount, memo, reference, status, captured_amount, transfer_id, step_up_challenge_id, expires_at, created_at, released_at
+`
+
+type ReleaseHoldParams struct {
+	Status         string      `json:"status"`
+	CapturedAmount int64       `json:"capturedAmount"`
+	TransferID     pgtype.Int8 `json:"transferID"`
+	ID             int64       `json:"id"`
+}
+
+func (q *Queries) ReleaseHold(ctx context.Context, arg ReleaseHoldParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, releaseHold,
+		arg.Status,
+		arg.CapturedAmount,
+		arg.TransferID,
+		arg.ID,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Memo,
+		&i.Reference,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.StepUpChallengeID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ReleasedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,291 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+// createFundedAccountOf creates an account of the owner holding the balance
+func createFundedAccountOf(t *testing.T, owner string, currency string, balance int64) BankAccount {
+	account := createRandomAccountOf(t, owner, currency)
+
+	account, err := testQueries.UpdateBankAccount(context.Background(), UpdateBankAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestAuthorizeTransferTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.EUR, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.EUR)
+
+	result, err := store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        600,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusAuthorized, result.Hold.Status)
+	require.Equal(t, int64(600), result.Hold.Amount)
+
+	// the hold reduces the available balance only
+	require.Equal(t, int64(1000), result.FromAccount.Balance)
+	require.Equal(t, int64(600), result.FromAccount.Held)
+	require.Equal(t, int64(400), result.FromAccount.AvailableBalance)
+
+	_, err = store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        401,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateBankAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	_, err = store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+}
+
+func TestCaptureTransferTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.EUR, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.EUR)
+
+	authorized, err := store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        600,
+		Memo:          "hotel deposit",
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = store.CaptureTransferTx(context.Background(), CaptureTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Amount: 601,
+		Now:    time.Now(),
+	})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	// the rest of a partial capture is released
+	result, err := store.CaptureTransferTx(context.Background(), CaptureTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Amount: 250,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, int64(250), result.Hold.CapturedAmount)
+	require.True(t, result.Hold.TransferID.Valid)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.True(t, result.Hold.ReleasedAt.Valid)
+
+	require.Equal(t, int64(250), result.Transfer.Amount)
+	require.Equal(t, "hotel deposit", result.Transfer.Memo)
+
+	fee := result.Transfer.Fee
+	require.Equal(t, 1000-250-fee, result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.Held)
+	require.Equal(t, result.FromAccount.Balance, result.FromAccount.AvailableBalance)
+	require.Equal(t, account2.Balance+250, result.ToAccount.Balance)
+
+	// a hold is only captured once
+	_, err = store.CaptureTransferTx(context.Background(), CaptureTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Now:    time.Now(),
+	})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestVoidTransferTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.EUR, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.EUR)
+
+	authorized, err := store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        300,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	result, err := store.VoidTransferTx(context.Background(), VoidTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusVoided, result.Hold.Status)
+	require.Zero(t, result.Hold.CapturedAmount)
+	require.Equal(t, int64(1000), result.FromAccount.Balance)
+	require.Equal(t, int64(1000), result.FromAccount.AvailableBalance)
+
+	_, err = store.CaptureTransferTx(context.Background(), CaptureTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Now:    time.Now(),
+	})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestExpireHoldsTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.EUR, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.EUR)
+
+	now := time.Now()
+	authorized, err := store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        700,
+		ExpiresAt:     now.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	// an expired hold cannot be captured, even before the expiry releases it
+	later := now.Add(2 * time.Minute)
+	_, err = store.CaptureTransferTx(context.Background(), CaptureTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Now:    later,
+	})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+
+	var expired *Hold
+	for expired == nil {
+		holds, err := store.ExpireHoldsTx(context.Background(), later, 100)
+		require.NoError(t, err)
+		require.NotEmpty(t, holds)
+
+		for i := range holds {
+			require.Equal(t, HoldStatusExpired, holds[i].Status)
+			if holds[i].ID == authorized.Hold.ID {
+				expired = &holds[i]
+			}
+		}
+	}
+
+	account, err := store.GetBankAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), account.Balance)
+	require.Equal(t, int64(1000), account.AvailableBalance)
+}
+
+func TestTransferTxAfterHold(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.EUR, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.EUR)
+
+	authorized, err := store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        600,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// the held amount cannot be spent by a transfer
+	_, err = store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        401,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nor by the items of a batch
+	batch, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         owner.Username,
+		FromAccountID: account1.ID,
+		Currency:      tools.EUR,
+		Mode:          TransferBatchModeAtomic,
+		Items:         batchItems(account2.ID, 200, 201),
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferBatchStatusFailed, batch.Batch.Status)
+	require.Contains(t, batch.Batch.Error, "item 1")
+	require.Contains(t, batch.Batch.Error, ErrInsufficientFunds.Error())
+	require.Empty(t, batch.Items[0].Error)
+	require.Equal(t, TransferBatchItemStatusFailed, batch.Items[1].Status)
+	require.Contains(t, batch.Items[1].Error, ErrInsufficientFunds.Error())
+
+	result, err := store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        400,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(600), result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.AvailableBalance)
+
+	// the capture spends its own hold
+	captured, err := store.CaptureTransferTx(context.Background(), CaptureTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.Zero(t, captured.FromAccount.Balance)
+	require.Zero(t, captured.FromAccount.Held)
+}
+
+func TestCloseAccountTxWithHold(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.EUR, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.EUR)
+
+	authorized, err := store.AuthorizeTransferTx(context.Background(), CreateHoldParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        300,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// the held amount is owed to the recipient, it cannot be swept away
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account1.ID,
+		SweepAccountID: account2.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountHasHolds)
+
+	_, err = store.VoidTransferTx(context.Background(), VoidTransferTxParams{
+		HoldID: authorized.Hold.ID,
+		Now:    time.Now(),
+	})
+	require.NoError(t, err)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account1.ID,
+		SweepAccountID: account2.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.Equal(t, int64(1000), result.Sweep.Transfer.Amount)
+}
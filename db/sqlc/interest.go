@@ -0,0 +1,281 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultAccountProduct is the product of the accounts opened without one, it earns no interest
+const DefaultAccountProduct = "checking"
+
+// Day count conventions, the number of days of the year the annual rate of a product is divided by
+const (
+	DayCountActual365    = "actual/365"
+	DayCountActual360    = "actual/360"
+	DayCountActualActual = "actual/actual"
+)
+
+// Kinds of interest runs, each runs once per date
+const (
+	InterestRunAccrual = "accrual"
+	InterestRunPosting = "posting"
+)
+
+// InterestEntryCategory is the category of the entries crediting the interest
+const InterestEntryCategory = "interest"
+
+// microsPerUnit is the number of millionths of the minor unit the accruals are counted in
+const microsPerUnit = 1_000_000
+
+// IsSupportedDayCount returns true if the day count convention is supported
+func IsSupportedDayCount(dayCount string) bool {
+	switch dayCount {
+	case DayCountActual365, DayCountActual360, DayCountActualActual:
+		return true
+	}
+	return false
+}
+
+// daysInYear returns the number of days of the year of the date under the day count convention
+func daysInYear(dayCount string, date time.Time) int64 {
+	switch dayCount {
+	case DayCountActual360:
+		return 360
+	case DayCountActualActual:
+		year := date.Year()
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 366
+		}
+	}
+	return 365
+}
+
+// DailyInterest returns the interest of one day on the balance, in millionths of the minor unit,
+// rounded half to even. It is computed with exact integer arithmetic.
+func DailyInterest(balance int64, annualRateBps int64, dayCount string, date time.Time) int64 {
+	if balance <= 0 || annualRateBps <= 0 {
+		return 0
+	}
+
+	// balance * rate / 10000 / days, in millionths
+	numerator := new(big.Int).Mul(big.NewInt(balance), big.NewInt(annualRateBps))
+	numerator.Mul(numerator, big.NewInt(microsPerUnit))
+	denominator := big.NewInt(10000 * daysInYear(dayCount, date))
+	return roundHalfEven(numerator, denominator)
+}
+
+// roundHalfEven divides the non-negative numerator by the positive denominator,
+// rounding a remainder of exactly one half to the even quotient (banker's rounding)
+func roundHalfEven(numerator *big.Int, denominator *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+
+	switch remainder.Lsh(remainder, 1).Cmp(denominator) {
+	case 1:
+		quotient.Add(quotient, big.NewInt(1))
+	case 0:
+		if quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient.Int64()
+}
+
+// pgDate returns the day of the time as a date parameter
+func pgDate(t time.Time) pgtype.Date {
+	t = t.UTC()
+	return pgtype.Date{Time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
+// AccrueInterestTxResult is the result of AccrueInterestTx
+type AccrueInterestTxResult struct {
+	// AlreadyRun is set when the interest of the date was accrued before, nothing was done
+	AlreadyRun bool `json:"already_run"`
+	Accounts   int  `json:"accounts"`
+}
+
+// AccrueInterestTx stores the interest of the day on the balance at the end of the day, in UTC,
+// of every account earning interest. The balances are worked back from the entries, so past
+// days can be accrued later on. The interest of a date is only accrued once.
+func (store *SQLStore) AccrueInterestTx(ctx context.Context, date time.Time) (AccrueInterestTxResult, error) {
+	var result AccrueInterestTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		result = AccrueInterestTxResult{}
+
+		_, err := q.StartInterestRun(ctx, StartInterestRunParams{Kind: InterestRunAccrual, RunDate: pgDate(date)})
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				result.AlreadyRun = true
+				return nil
+			}
+			return err
+		}
+
+		day := pgDate(date).Time
+		balances, err := q.ListInterestBearingBalances(ctx, day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+
+		for _, balance := range balances {
+			amount := DailyInterest(balance.Balance, balance.AnnualRateBps, balance.DayCount, day)
+			if amount == 0 {
+				continue
+			}
+
+			err := q.CreateInterestAccrual(ctx, CreateInterestAccrualParams{
+				AccountID:     balance.AccountID,
+				AccrualDate:   pgDate(day),
+				Balance:       balance.Balance,
+				AnnualRateBps: balance.AnnualRateBps,
+				AmountMicros:  amount,
+			})
+			if err != nil {
+				return err
+			}
+			result.Accounts++
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// ErrInterestExpenseAccountNotActive is returned when the account paying the interest of a currency is frozen or closed
+var ErrInterestExpenseAccountNotActive = errors.New("interest expense account is not active")
+
+// PostInterestTxResult is the result of PostInterestTx
+type PostInterestTxResult struct {
+	// AlreadyRun is set when the interest was posted on the date before, nothing was done
+	AlreadyRun bool    `json:"already_run"`
+	Entries    []Entry `json:"entries"`
+	// ExpenseEntries debit the interest expense accounts, one for each entry crediting an account
+	ExpenseEntries []Entry `json:"expense_entries"`
+}
+
+// PostInterestTx credits every account with the interest accrued before the date, as an entry
+// of the interest category, rounded half to even to the minor unit, and debits the same amount
+// from the interest expense account of its currency. The rounding difference is carried to the
+// next posting, and so is the interest of the accounts that are not active, that rounds to zero or
+// whose currency has no interest expense account. The interest is only posted once on a date.
+func (store *SQLStore) PostInterestTx(ctx context.Context, date time.Time) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		result = PostInterestTxResult{}
+		expenses := map[int64]int64{}
+
+		_, err := q.StartInterestRun(ctx, StartInterestRunParams{Kind: InterestRunPosting, RunDate: pgDate(date)})
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				result.AlreadyRun = true
+				return nil
+			}
+			return err
+		}
+
+		unposted, err := q.ListUnpostedInterest(ctx, pgDate(date))
+		if err != nil {
+			return err
+		}
+
+		for _, interest := range unposted {
+			// a carry-over of a posting rounded up can leave less than nothing to post
+			if interest.AmountMicros <= 0 || !interest.ExpenseAccountID.Valid {
+				continue
+			}
+			amount := roundHalfEven(big.NewInt(interest.AmountMicros), big.NewInt(microsPerUnit))
+			if amount == 0 {
+				continue
+			}
+
+			// the balance of an account is only updated while it is active
+			_, err := q.AddBankAccountBalance(ctx, AddBankAccountBalanceParams{
+				ID:     interest.AccountID,
+				Amount: amount,
+			})
+			if err != nil {
+				if errors.Is(err, ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+
+			entry, err := q.CreateEntryWithCategory(ctx, CreateEntryWithCategoryParams{
+				AccountID: interest.AccountID,
+				Amount:    amount,
+				Category:  InterestEntryCategory,
+			})
+			if err != nil {
+				return err
+			}
+
+			expenseEntry, err := q.CreateEntryWithCategory(ctx, CreateEntryWithCategoryParams{
+				AccountID: interest.ExpenseAccountID.Int64,
+				Amount:    -amount,
+				Category:  InterestEntryCategory,
+			})
+			if err != nil {
+				return err
+			}
+			expenses[interest.ExpenseAccountID.Int64] += amount
+
+			err = q.MarkInterestPosted(ctx, MarkInterestPostedParams{
+				EntryID:   pgtype.Int8{Int64: entry.ID, Valid: true},
+				AccountID: interest.AccountID,
+				Before:    pgDate(date),
+			})
+			if err != nil {
+				return err
+			}
+
+			if remainder := interest.AmountMicros - amount*microsPerUnit; remainder != 0 {
+				err = q.CreateInterestCarryOver(ctx, CreateInterestCarryOverParams{
+					AccountID:    interest.AccountID,
+					PostingDate:  pgDate(date),
+					AmountMicros: remainder,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			result.Entries = append(result.Entries, entry)
+			result.ExpenseEntries = append(result.ExpenseEntries, expenseEntry)
+		}
+
+		// the credited accounts were locked in ID order, the expense accounts are updated last
+		return debitInterestExpenses(ctx, q, expenses)
+	})
+
+	return result, err
+}
+
+// debitInterestExpenses takes the interest posted from the expense accounts, in ID order
+func debitInterestExpenses(ctx context.Context, q *Queries, expenses map[int64]int64) error {
+	accountIDs := make([]int64, 0, len(expenses))
+	for accountID := range expenses {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Slice(accountIDs, func(i, j int) bool { return accountIDs[i] < accountIDs[j] })
+
+	for _, accountID := range accountIDs {
+		_, err := q.AddBankAccountBalance(ctx, AddBankAccountBalanceParams{
+			ID:     accountID,
+			Amount: -expenses[accountID],
+		})
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return fmt.Errorf("expense account [%d]: %w", accountID, ErrInterestExpenseAccountNotActive)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: interest.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createInterestAccrual = `-- name: CreateInterestAccrual :exec
+INSERT INTO interest_accruals (account_id, accrual_date, balance, annual_rate_bps, amount_micros)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (account_id, accrual_date, carry_over) DO NOTHING
+`
+
+type CreateInterestAccrualParams struct {
+	AccountID     int64       `json:"accountID"`
+	AccrualDate   pgtype.Date `json:"accrualDate"`
+	Balance       int64       `json:"balance"`
+	AnnualRateBps int64       `json:"annualRateBps"`
+	AmountMicros  int64       `json:"amountMicros"`
+}
+
+func (q *Queries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) error {
+	_, err := q.db.Exec(ctx, createInterestAccrual,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.AnnualRateBps,
+		arg.AmountMicros,
+	)
+	return err
+}
+
+const createInterestCarryOver = `-- name: CreateInterestCarryOver :exec
+INSERT INTO interest_accruals (account_id, accrual_date, balance, annual_rate_bps, amount_micros, carry_over)
+VALUES ($1, $2, 0, 0, $3, true)
+`
+
+type CreateInterestCarryOverParams struct {
+	AccountID    int64       `json:"accountID"`
+	PostingDate  pgtype.Date `json:"postingDate"`
+	AmountMicros int64       `json:"amountMicros"`
+}
+
+// Keeps the part of a posting below the minor unit, which can be negative when the posting
+// was rounded up, to be added to the next posting.
+func (q *Queries) CreateInterestCarryOver(ctx context.Context, arg CreateInterestCarryOverParams) error {
+	_, err := q.db.Exec(ctx, createInterestCarryOver, arg.AccountID, arg.PostingDate, arg.AmountMicros)
+	return err
+}
+
+const getAccountProduct = `-- name: GetAccountProduct :one
+SELECT code, name, annual_rate_bps, day_count, updated_at FROM account_products WHERE code = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountProduct(ctx context.Context, code string) (AccountProduct, error) {
+	row := q.db.QueryRow(ctx, getAccountProduct, code)
+	var i AccountProduct
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.AnnualRateBps,
+		&i.DayCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getLastInterestRun = `-- name: GetLastInterestRun :one
+SELECT kind, run_date, created_at FROM interest_runs WHERE kind = $1 ORDER BY run_date DESC LIMIT 1
+`
+
+func (q *Queries) GetLastInterestRun(ctx context.Context, kind string) (InterestRun, error) {
+	row := q.db.QueryRow(ctx, getLastInterestRun, kind)
+	var i InterestRun
+	err := row.Scan(&i.Kind, &i.RunDate, &i.CreatedAt)
+	return i, err
+}
+
+const listAccountProducts = `-- name: ListAccountProducts :many
+SELECT code, name, annual_rate_bps, day_count, updated_at FROM account_products ORDER BY code
+`
+
+func (q *Queries) ListAccountProducts(ctx context.Context) ([]AccountProduct, error) {
+	rows, err := q.db.Query(ctx, listAccountProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountProduct{}
+	for rows.Next() {
+		var i AccountProduct
+		if err := rows.Scan(
+			&i.Code,
+			&i.Name,
+			&i.AnnualRateBps,
+			&i.DayCount,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInterestAccruals = `-- name: ListInterestAccruals :many
+SELECT account_id, accrual_date, balance, annual_rate_bps, amount_micros, posted_at, entry_id, carry_over FROM interest_accruals
+WHERE account_id = $1
+ORDER BY accrual_date, carry_over
+`
+
+func (q *Queries) ListInterestAccruals(ctx context.Context, accountID int64) ([]InterestAccrual, error) {
+	rows, err := q.db.Query(ctx, listInterestAccruals, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InterestAccrual{}
+	for rows.Next() {
+		var i InterestAccrual
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.AccrualDate,
+			&i.Balance,
+			&i.AnnualRateBps,
+			&i.AmountMicros,
+			&i.PostedAt,
+			&i.EntryID,
+			&i.CarryOver,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInterestBearingBalances = `-- name: ListInterestBearingBalances :many
+SELECT
+  bank_accounts.id AS account_id,
+  (bank_accounts.balance - COALESCE((
+    SELECT SUM(entries.amount) FROM entries
+    WHERE entries.account_id = bank_accounts.id AND entries.created_at >= $1::timestamptz
+  ), 0))::bigint AS balance,
+  account_products.annual_rate_bps,
+  account_products.day_count
+FROM bank_accounts
+JOIN account_products ON account_products.code = bank_accounts.product
+WHERE account_products.annual_rate_bps > 0
+  AND bank_accounts.created_at < $1::timestamptz
+  AND (bank_accounts.closed_at IS NULL OR bank_accounts.closed_at >= $1::timestamptz)
+ORDER BY bank_accounts.id
+`
+
+type ListInterestBearingBalancesRow struct {
+	AccountID     int64  `json:"accountID"`
+	Balance       int64  `json:"balance"`
+	AnnualRateBps int64  `json:"annualRateBps"`
+	DayCount      string `json:"dayCount"`
+}
+
+// The balance of every account earning interest at the end of the day,
+// worked back from the current balance and the entries made since then.
+func (q *Queries) ListInterestBearingBalances(ctx context.Context, dayEnd time.Time) ([]ListInterestBearingBalancesRow, error) {
+	rows, err := q.db.Query(ctx, listInterestBearingBalances, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListInterestBearingBalancesRow{}
+	for rows.Next() {
+		var i ListInterestBearingBalancesRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Balance,
+			&i.AnnualRateBps,
+			&i.DayCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpostedInterest = `-- name: ListUnpostedInterest :many
+SELECT
+  interest_accruals.account_id,
+  SUM(interest_accruals.amount_micros)::bigint AS amount_micros,
+  interest_expense_accounts.account_id AS expense_account_id
+FROM interest_accruals
+JOIN bank_accounts ON bank_accounts.id = interest_accruals.account_id
+LEFT JOIN interest_expense_accounts ON interest_expense_accounts.currency = bank_accounts.currency
+WHERE interest_accruals.posted_at IS NULL AND interest_accruals.accrual_date < $1
+GROUP BY interest_accruals.account_id, interest_expense_accounts.account_id
+ORDER BY interest_accruals.account_id
+`
+
+type ListUnpostedInterestRow struct {
+	AccountID        int64       `json:"accountID"`
+	AmountMicros     int64       `json:"amountMicros"`
+	ExpenseAccountID pgtype.Int8 `json:"expenseAccountID"`
+}
+
+// The interest to post to every account, with the account paying it in the currency of the account.
+func (q *Queries) ListUnpostedInterest(ctx context.Context, before pgtype.Date) ([]ListUnpostedInterestRow, error) {
+	rows, err := q.db.Query(ctx, listUnpostedInterest, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnpostedInterestRow{}
+	for rows.Next() {
+		var i ListUnpostedInterestRow
+		if err := rows.Scan(&i.AccountID, &i.AmountMicros, &i.ExpenseAccountID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markInterestPosted = `-- name: MarkInterestPosted :exec
+UPDATE interest_accruals
+SET posted_at = now(), entry_id = $1
+WHERE account_id = $2 AND posted_at IS NULL AND accrual_date < $3
+`
+
+type MarkInterestPostedParams struct {
+	EntryID   pgtype.Int8 `json:"entryID"`
+	AccountID int64       `json:"accountID"`
+	Before    pgtype.Date `json:"before"`
+}
+
+func (q *Queries) MarkInterestPosted(ctx context.Context, arg MarkInterestPostedParams) error {
+	_, err := q.db.Exec(ctx, markInterestPosted, arg.EntryID, arg.AccountID, arg.Before)
+	return err
+}
+
+const setAccountProduct = `-- name: SetAccountProduct :one
+INSERT INTO account_products (code, name, annual_rate_bps, day_count) VALUES ($1, $2, $3, $4)
+ON CONFLICT (code) DO UPDATE
+SET name = EXCLUDED.name,
+    annual_rate_bps = EXCLUDED.annual_rate_bps,
+    day_count = EXCLUDED.day_count,
+    updated_at = now()
+RETURNING code, name, annual_rate_bps, day_count, updated_at
+`
+
+type SetAccountProductParams struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	AnnualRateBps int64  `json:"annualRateBps"`
+	DayCount      string `json:"dayCount"`
+}
+
+func (q *Queries) SetAccountProduct(ctx context.Context, arg SetAccountProductParams) (AccountProduct, error) {
+	row := q.db.QueryRow(ctx, setAccountProduct,
+		arg.Code,
+		arg.Name,
+		arg.AnnualRateBps,
+		arg.DayCount,
+	)
+	var i AccountProduct
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.AnnualRateBps,
+		&i.DayCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setInterestExpenseAccount = `-- name: SetInterestExpenseAccount :one
+INSERT INTO interest_expense_accounts (currency, account_id)
+VALUES ($1, $2)
+ON CONFLICT (currency) DO UPDATE
+SET account_id = EXCLUDED.account_id,
+    updated_at = now()
+RETURNING currency, account_id, updated_at
+`
+
+type SetInterestExpenseAccountParams struct {
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"accountID"`
+}
+
+func (q *Queries) SetInterestExpenseAccount(ctx context.Context, arg SetInterestExpenseAccountParams) (InterestExpenseAccount, error) {
+	row := q.db.QueryRow(ctx, setInterestExpenseAccount, arg.Currency, arg.AccountID)
+	var i InterestExpenseAccount
+	err := row.Scan(&i.Currency, &i.AccountID, &i.UpdatedAt)
+	return i, err
+}
+
+const startInterestRun = `-- name: StartInterestRun :one
+INSERT INTO interest_runs (kind, run_date) VALUES ($1, $2)
+ON CONFLICT (kind, run_date) DO NOTHING
+RETURNING kind, run_date, created_at
+`
+
+type StartInterestRunParams struct {
+	Kind    string      `json:"kind"`
+	RunDate pgtype.Date `json:"runDate"`
+}
+
+func (q *Queries) StartInterestRun(ctx context.Context, arg StartInterestRunParams) (InterestRun, error) {
+	row := q.db.QueryRow(ctx, startInterestRun, arg.Kind, arg.RunDate)
+	var i InterestRun
+	err := row.Scan(&i.Kind, &i.RunDate, &i.CreatedAt)
+	return i, err
+}
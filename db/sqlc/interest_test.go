@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundHalfEven(t *testing.T) {
+	testCases := []struct {
+		numerator   int64
+		denominator int64
+		quotient    int64
+	}{
+		{numerator: 5, denominator: 2, quotient: 2},
+		{numerator: 7, denominator: 2, quotient: 4},
+		{numerator: 9, denominator: 4, quotient: 2},
+		{numerator: 11, denominator: 4, quotient: 3},
+		{numerator: 2_500_000, denominator: microsPerUnit, quotient: 2},
+		{numerator: 3_500_000, denominator: microsPerUnit, quotient: 4},
+		{numerator: 0, denominator: 7, quotient: 0},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.quotient, roundHalfEven(big.NewInt(tc.numerator), big.NewInt(tc.denominator)),
+			"%d/%d", tc.numerator, tc.denominator)
+	}
+}
+
+func TestDailyInterest(t *testing.T) {
+	date := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	leapDate := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	// 5% a year on 1000.00
+	require.Equal(t, int64(13_698_630), DailyInterest(100000, 500, DayCountActual365, date))
+	require.Equal(t, int64(13_888_889), DailyInterest(100000, 500, DayCountActual360, date))
+	require.Equal(t, int64(13_698_630), DailyInterest(100000, 500, DayCountActualActual, date))
+	require.Equal(t, int64(13_661_202), DailyInterest(100000, 500, DayCountActualActual, leapDate))
+
+	// no interest on an empty or overdrawn balance
+	require.Zero(t, DailyInterest(0, 500, DayCountActual365, date))
+	require.Zero(t, DailyInterest(-100000, 500, DayCountActual365, date))
+
+	// balance * rate * 1000000 does not fit in an int64
+	require.Equal(t, int64(136_986_301_369_863_014), DailyInterest(1_000_000_000_000_000, 500, DayCountActual365, date))
+}
+
+func TestAccrueAndPostInterestTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	// a product only used by this test, so that the other accounts earn nothing
+	product, err := store.SetAccountProduct(context.Background(), SetAccountProductParams{
+		Code:          "savings-" + tools.RandomString(8),
+		Name:          "Savings",
+		AnnualRateBps: 500,
+		DayCount:      DayCountActual365,
+	})
+	require.NoError(t, err)
+
+	accountNumber, err := tools.NewAccountNumber()
+	require.NoError(t, err)
+	account, err := store.CreateBankAccount(context.Background(), CreateBankAccountParams{
+		Owner:         createRandomUser(t).Username,
+		Balance:       100000,
+		Currency:      tools.USD,
+		AccountNumber: accountNumber,
+		Product:       NullString(product.Code),
+	})
+	require.NoError(t, err)
+	require.Equal(t, product.Code, account.Product)
+
+	// every date is only accrued once in the database, pick one no other run used
+	date := time.Now().UTC().AddDate(0, 0, int(tools.RandomInt(1000, 100000)))
+
+	for _, day := range []time.Time{date, date.AddDate(0, 0, 1)} {
+		result, err := store.AccrueInterestTx(context.Background(), day)
+		require.NoError(t, err)
+		require.False(t, result.AlreadyRun)
+		require.GreaterOrEqual(t, result.Accounts, 1)
+	}
+
+	// accruing a date again does nothing
+	result, err := store.AccrueInterestTx(context.Background(), date)
+	require.NoError(t, err)
+	require.True(t, result.AlreadyRun)
+
+	accruals, err := store.ListInterestAccruals(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, accruals, 2)
+	for _, accrual := range accruals {
+		require.Equal(t, account.Balance, accrual.Balance)
+		require.Equal(t, int64(13_698_630), accrual.AmountMicros)
+	}
+
+	// the interest is paid by the bank, from the expense account of the currency
+	expenseAccount := createRandomAccountOf(t, createRandomUser(t).Username, tools.USD)
+	_, err = store.SetInterestExpenseAccount(context.Background(), SetInterestExpenseAccountParams{
+		Currency:  tools.USD,
+		AccountID: expenseAccount.ID,
+	})
+	require.NoError(t, err)
+
+	posting, err := store.PostInterestTx(context.Background(), date.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.False(t, posting.AlreadyRun)
+
+	// every credit has its debit
+	require.Len(t, posting.ExpenseEntries, len(posting.Entries))
+	var credited, expensed int64
+	for i := range posting.Entries {
+		credited += posting.Entries[i].Amount
+		require.Equal(t, -posting.Entries[i].Amount, posting.ExpenseEntries[i].Amount)
+		if posting.ExpenseEntries[i].AccountID == expenseAccount.ID {
+			expensed += posting.ExpenseEntries[i].Amount
+		}
+	}
+	require.Positive(t, credited)
+
+	updatedExpense, err := store.GetBankAccount(context.Background(), expenseAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, expenseAccount.Balance+expensed, updatedExpense.Balance)
+
+	var entry Entry
+	for _, e := range posting.Entries {
+		if e.AccountID == account.ID {
+			entry = e
+		}
+	}
+	require.Equal(t, int64(27), entry.Amount)
+	require.Equal(t, InterestEntryCategory, entry.Category)
+
+	updated, err := store.GetBankAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+27, updated.Balance)
+
+	// the part below the minor unit is carried to the next posting
+	accruals, err = store.ListInterestAccruals(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, accruals, 3)
+	for _, accrual := range accruals[:2] {
+		require.True(t, accrual.PostedAt.Valid)
+		require.Equal(t, entry.ID, accrual.EntryID.Int64)
+	}
+	carryOver := accruals[2]
+	require.True(t, carryOver.CarryOver)
+	require.False(t, carryOver.PostedAt.Valid)
+	require.Equal(t, int64(2*13_698_630-27_000_000), carryOver.AmountMicros)
+
+	posting, err = store.PostInterestTx(context.Background(), date.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.True(t, posting.AlreadyRun)
+
+	// the carry-over accrues with the interest of the days after the posting
+	_, err = store.AccrueInterestTx(context.Background(), date.AddDate(0, 0, 2))
+	require.NoError(t, err)
+
+	unposted, err := store.ListUnpostedInterest(context.Background(), pgDate(date.AddDate(0, 0, 3)))
+	require.NoError(t, err)
+	nextDay := DailyInterest(updated.Balance, product.AnnualRateBps, product.DayCount, date.AddDate(0, 0, 2))
+	var found bool
+	for _, interest := range unposted {
+		if interest.AccountID == account.ID {
+			require.Equal(t, carryOver.AmountMicros+nextDay, interest.AmountMicros)
+			found = true
+		}
+	}
+	require.True(t, found)
+}
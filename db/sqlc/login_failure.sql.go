@@ -0,0 +1,201 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: login_failure.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const countLoginAttempt = `-- name: CountLoginAttempt :one
+INSERT INTO login_failures (
+  scope,
+  identifier,
+  failed_attempts,
+  last_failed_at
+) VALUES (
+  $1, $2, 1, now()
+)
+ON CONFLICT (scope, identifier) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_failures.last_failed_at < $3::timestamptz THEN 1
+    ELSE login_failures.failed_attempts + 1
+  END,
+  last_failed_at = now()
+WHERE login_failures.locked_until IS NULL OR login_failures.locked_until <= now()
+RETURNING scope, identifier, failed_attempts, last_failed_at, locked_until
+`
+
+type CountLoginAttemptParams struct {
+	Scope       string    `json:"scope"`
+	Identifier  string    `json:"identifier"`
+	ResetBefore time.Time `json:"resetBefore"`
+}
+
+// Counts an attempt without locking the key, for a key shared by concurrent clients like an IP.
+// No row is returned while the key is locked out.
+func (q *Queries) CountLoginAttempt(ctx context.Context, arg CountLoginAttemptParams) (LoginFailure, error) {
+	row := q.db.QueryRow(ctx, countLoginAttempt, arg.Scope, arg.Identifier, arg.ResetBefore)
+	var i LoginFailure
+	err := row.Scan(
+		&i.Scope,
+		&i.Identifier,
+		&i.FailedAttempts,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const deleteLoginFailure = `-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE scope = $1 AND identifier = $2
+`
+
+type DeleteLoginFailureParams struct {
+	Scope      string `json:"scope"`
+	Identifier string `json:"identifier"`
+}
+
+func (q *Queries) DeleteLoginFailure(ctx context.Context, arg DeleteLoginFailureParams) error {
+	_, err := q.db.Exec(ctx, deleteLoginFailure, arg.Scope, arg.Identifier)
+	return err
+}
+
+const getLoginFailure = `-- name: GetLoginFailure :one
+SELECT scope, identifier, failed_attempts, last_failed_at, locked_until FROM login_failures
+WHERE scope = $1 AND identifier = $2 LIMIT 1
+`
+
+type GetLoginFailureParams struct {
+	Scope      string `json:"scope"`
+	Identifier string `json:"identifier"`
+}
+
+func (q *Queries) GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error) {
+	row := q.db.QueryRow(ctx, getLoginFailure, arg.Scope, arg.Identifier)
+	var i LoginFailure
+	err := row.Scan(
+		&i.Scope,
+		&i.Identifier,
+		&i.FailedAttempts,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const lockLogin = `-- name: LockLogin :one
+UPDATE login_failures
+SET locked_until = $1::timestamptz
+WHERE scope = $2 AND identifier = $3
+RETURNING scope, identifier, failed_attempts, last_failed_at, locked_until
+`
+
+type LockLoginParams struct {
+	LockedUntil time.Time `json:"lockedUntil"`
+	Scope       string    `json:"scope"`
+	Identifier  string    `json:"identifier"`
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) (LoginFailure, error) {
+	row := q.db.QueryRow(ctx, lockLogin, arg.LockedUntil, arg.Scope, arg.Identifier)
+	var i LoginFailure
+	err := row.Scan(
+		&i.Scope,
+		&i.Identifier,
+		&i.FailedAttempts,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const releaseLoginAttempt = `-- name: ReleaseLoginAttempt :exec
+UPDATE login_failures
+SET
+  failed_attempts = GREATEST(failed_attempts - 1, 0),
+  locked_until = NULL
+WHERE scope = $1 AND identifier = $2
+`
+
+type ReleaseLoginAttemptParams struct {
+	Scope      string `json:"scope"`
+	Identifier string `json:"identifier"`
+}
+
+// Uncounts an attempt that did not fail, and unlocks the key it locked.
+func (q *Queries) ReleaseLoginAttempt(ctx context.Context, arg ReleaseLoginAttemptParams) error {
+	_, err := q.db.Exec(ctx, releaseLoginAttempt, arg.Scope, arg.Identifier)
+	return err
+}
+
+const startLoginAttempt = `-- name: StartLoginAttempt :one
+INSERT INTO login_failures (
+  scope,
+  identifier,
+  failed_attempts,
+  last_failed_at,
+  locked_until
+) VALUES (
+  $1, $2, 1, now(), $3::timestamptz
+)
+ON CONFLICT (scope, identifier) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_failures.last_failed_at < $4::timestamptz THEN 1
+    ELSE login_failures.failed_attempts + 1
+  END,
+  last_failed_at = now(),
+  locked_until = $3::timestamptz
+WHERE login_failures.locked_until IS NULL OR login_failures.locked_until <= now()
+RETURNING scope, identifier, failed_attempts, last_failed_at, locked_until
+`
+
+type StartLoginAttemptParams struct {
+	Scope       string    `json:"scope"`
+	Identifier  string    `json:"identifier"`
+	LockedUntil time.Time `json:"lockedUntil"`
+	ResetBefore time.Time `json:"resetBefore"`
+}
+
+// Counts an attempt and locks the key until the attempt is resolved.
+// No row is returned while the key is locked out.
+func (q *Queries) StartLoginAttempt(ctx context.Context, arg StartLoginAttemptParams) (LoginFailure, error) {
+	row := q.db.QueryRow(ctx, startLoginAttempt,
+		arg.Scope,
+		arg.Identifier,
+		arg.LockedUntil,
+		arg.ResetBefore,
+	)
+	var i LoginFailure
+	err := row.Scan(
+		&i.Scope,
+		&i.Identifier,
+		&i.FailedAttempts,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const uncountLoginAttempt = `-- name: UncountLoginAttempt :exec
+UPDATE login_failures
+SET failed_attempts = GREATEST(failed_attempts - 1, 0)
+WHERE scope = $1 AND identifier = $2
+`
+
+type UncountLoginAttemptParams struct {
+	Scope      string `json:"scope"`
+	Identifier string `json:"identifier"`
+}
+
+// Uncounts an attempt that did not fail, leaving the lockout of the key to the other attempts.
+func (q *Queries) UncountLoginAttempt(ctx context.Context, arg UncountLoginAttemptParams) error {
+	_, err := q.db.Exec(ctx, uncountLoginAttempt, arg.Scope, arg.Identifier)
+	return err
+}
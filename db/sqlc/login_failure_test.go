@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartLoginAttempt(t *testing.T) {
+	arg := StartLoginAttemptParams{
+		Scope:       "username",
+		Identifier:  tools.RandomOwner(),
+		LockedUntil: time.Now().Add(-time.Second),
+		ResetBefore: time.Now().Add(-time.Hour),
+	}
+
+	for i := 1; i <= 3; i++ {
+		failure, err := testQueries.StartLoginAttempt(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, int32(i), failure.FailedAttempts)
+	}
+
+	// attempts older than the window start a new count
+	arg.ResetBefore = time.Now().Add(time.Minute)
+	failure, err := testQueries.StartLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.FailedAttempts)
+
+	// a pending attempt locks the key until it is resolved
+	arg.LockedUntil = time.Now().Add(time.Minute)
+	failure, err = testQueries.StartLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), failure.FailedAttempts)
+
+	_, err = testQueries.StartLoginAttempt(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	err = testQueries.ReleaseLoginAttempt(context.Background(), ReleaseLoginAttemptParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+
+	failure, err = testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.FailedAttempts)
+	require.False(t, failure.LockedUntil.Valid)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	failure, err = testQueries.LockLogin(context.Background(), LockLoginParams{
+		Scope:       arg.Scope,
+		Identifier:  arg.Identifier,
+		LockedUntil: lockedUntil,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, lockedUntil, failure.LockedUntil.Time, time.Second)
+
+	err = testQueries.DeleteLoginFailure(context.Background(), DeleteLoginFailureParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestCountLoginAttempt(t *testing.T) {
+	arg := CountLoginAttemptParams{
+		Scope:       "ip",
+		Identifier:  tools.RandomOwner(),
+		ResetBefore: time.Now().Add(-time.Hour),
+	}
+
+	// concurrent attempts of an IP are counted without locking it
+	for i := 1; i <= 3; i++ {
+		failure, err := testQueries.CountLoginAttempt(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, int32(i), failure.FailedAttempts)
+		require.False(t, failure.LockedUntil.Valid)
+	}
+
+	err := testQueries.UncountLoginAttempt(context.Background(), UncountLoginAttemptParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+
+	// a lockout set by a failed attempt is kept when another attempt is uncounted
+	_, err = testQueries.LockLogin(context.Background(), LockLoginParams{
+		Scope:       arg.Scope,
+		Identifier:  arg.Identifier,
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.CountLoginAttempt(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	err = testQueries.UncountLoginAttempt(context.Background(), UncountLoginAttemptParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+
+	failure, err := testQueries.GetLoginFailure(context.Background(), GetLoginFailureParams{
+		Scope:      arg.Scope,
+		Identifier: arg.Identifier,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.FailedAttempts)
+	require.True(t, failure.LockedUntil.Valid)
+}
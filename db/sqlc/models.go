@@ -1,6 +1,6 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 
 package db
 
@@ -8,14 +8,36 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AccountProduct struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// annual interest rate, in basis points
+	AnnualRateBps int64 `json:"annualRateBps"`
+	// actual/365, actual/360 or actual/actual
+	DayCount  string    `json:"dayCount"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
 type BankAccount struct {
 	ID        int64     `json:"id"`
 	Owner     string    `json:"owner"`
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"createdAt"`
+	// active, frozen or closed
+	Status   string             `json:"status"`
+	ClosedAt pgtype.Timestamptz `json:"closedAt"`
+	Nickname string             `json:"nickname"`
+	// public number with mod-97 check digits
+	AccountNumber string `json:"accountNumber"`
+	Product       string `json:"product"`
+	// sum of the authorized holds, not available to spend
+	Held int64 `json:"held"`
+	// ledger balance minus the held amount
+	AvailableBalance int64 `json:"availableBalance"`
 }
 
 type Entry struct {
@@ -24,6 +46,94 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"createdAt"`
+	// chosen by the owner of the account
+	Category string `json:"category"`
+}
+
+type FeeSchedule struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// own or cross_owner
+	TransferType string `json:"transferType"`
+	Tier         string `json:"tier"`
+	FlatFee      int64  `json:"flatFee"`
+	// percentage of the amount, in basis points
+	PercentBps int64 `json:"percentBps"`
+	MinFee     int64 `json:"minFee"`
+	// null for no maximum
+	MaxFee pgtype.Int8 `json:"maxFee"`
+	// bank-owned account receiving the fees
+	RevenueAccountID int64     `json:"revenueAccountID"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type Hold struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"fromAccountID"`
+	ToAccountID   int64 `json:"toAccountID"`
+	// must be positive
+	Amount    int64  `json:"amount"`
+	Memo      string `json:"memo"`
+	Reference string `json:"reference"`
+	// authorized, captured, voided or expired
+	Status         string `json:"status"`
+	CapturedAmount int64  `json:"capturedAmount"`
+	// transfer settling the captured amount
+	TransferID        pgtype.Int8        `json:"transferID"`
+	StepUpChallengeID uuid.NullUUID      `json:"stepUpChallengeID"`
+	ExpiresAt         time.Time          `json:"expiresAt"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	ReleasedAt        pgtype.Timestamptz `json:"releasedAt"`
+}
+
+type InterestAccrual struct {
+	AccountID   int64       `json:"accountID"`
+	AccrualDate pgtype.Date `json:"accrualDate"`
+	// balance of the account at the end of the day
+	Balance       int64 `json:"balance"`
+	AnnualRateBps int64 `json:"annualRateBps"`
+	// interest of the day, in millionths of the minor unit
+	AmountMicros int64              `json:"amountMicros"`
+	PostedAt     pgtype.Timestamptz `json:"postedAt"`
+	// interest entry the accrual was posted with
+	EntryID pgtype.Int8 `json:"entryID"`
+	// part of a posting below the minor unit, carried to the next posting
+	CarryOver bool `json:"carryOver"`
+}
+
+type InterestExpenseAccount struct {
+	Currency string `json:"currency"`
+	// bank-owned account paying the interest posted in the currency
+	AccountID int64     `json:"accountID"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type InterestRun struct {
+	// accrual or posting, each runs once per date
+	Kind      string      `json:"kind"`
+	RunDate   pgtype.Date `json:"runDate"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+type LoginFailure struct {
+	// username or ip
+	Scope          string             `json:"scope"`
+	Identifier     string             `json:"identifier"`
+	FailedAttempts int32              `json:"failedAttempts"`
+	LastFailedAt   time.Time          `json:"lastFailedAt"`
+	LockedUntil    pgtype.Timestamptz `json:"lockedUntil"`
+}
+
+type Payee struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	DisplayName   string `json:"displayName"`
+	AccountNumber string `json:"accountNumber"`
+	Currency      string `json:"currency"`
+	Note          string `json:"note"`
+	// null until the payee is confirmed with step-up authentication
+	ConfirmedAt pgtype.Timestamptz `json:"confirmedAt"`
+	CreatedAt   time.Time          `json:"createdAt"`
 }
 
 type Session struct {
@@ -37,20 +147,96 @@ type Session struct {
 	CreatedAt    time.Time `json:"createdAt"`
 }
 
+type StepUpChallenge struct {
+	ID            uuid.UUID   `json:"id"`
+	Username      string      `json:"username"`
+	FromAccountID int64       `json:"fromAccountID"`
+	ToAccountID   pgtype.Int8 `json:"toAccountID"`
+	Amount        int64       `json:"amount"`
+	Currency      string      `json:"currency"`
+	// password or totp, set once the challenge is verified
+	Method     pgtype.Text        `json:"method"`
+	VerifiedAt pgtype.Timestamptz `json:"verifiedAt"`
+	ConsumedAt pgtype.Timestamptz `json:"consumedAt"`
+	ExpiresAt  time.Time          `json:"expiresAt"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	// SHA-256 of the transfer batch the challenge confirms, null for a single transfer
+	BatchHash pgtype.Text `json:"batchHash"`
+	// verification attempts so far, the challenge cannot be verified once they reach STEP_UP_MAX_ATTEMPTS
+	Attempts int32 `json:"attempts"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"fromAccountID"`
 	ToAccountID   int64 `json:"toAccountID"`
 	// must be positive
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"createdAt"`
+	Amount            int64         `json:"amount"`
+	CreatedAt         time.Time     `json:"createdAt"`
+	StepUpChallengeID uuid.NullUUID `json:"stepUpChallengeID"`
+	Memo              string        `json:"memo"`
+	// ISO 11649 RF creditor reference
+	Reference string `json:"reference"`
+	// charged to the sender on top of the amount
+	Fee int64 `json:"fee"`
+}
+
+type TransferBatch struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"fromAccountID"`
+	Currency      string `json:"currency"`
+	// atomic or per_item
+	Mode string `json:"mode"`
+	// processing, completed, partially_completed or failed
+	Status            string             `json:"status"`
+	ItemCount         int32              `json:"itemCount"`
+	TotalAmount       int64              `json:"totalAmount"`
+	SucceededCount    int32              `json:"succeededCount"`
+	FailedCount       int32              `json:"failedCount"`
+	Error             string             `json:"error"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	CompletedAt       pgtype.Timestamptz `json:"completedAt"`
+	StepUpChallengeID uuid.NullUUID      `json:"stepUpChallengeID"`
+}
+
+type TransferBatchItem struct {
+	BatchID     int64  `json:"batchID"`
+	Position    int32  `json:"position"`
+	ToAccountID int64  `json:"toAccountID"`
+	Amount      int64  `json:"amount"`
+	Memo        string `json:"memo"`
+	Reference   string `json:"reference"`
+	// pending, succeeded or failed
+	Status     string      `json:"status"`
+	TransferID pgtype.Int8 `json:"transferID"`
+	Error      string      `json:"error"`
+}
+
+type TransferLimitOverride struct {
+	Username string `json:"username"`
+	Currency string `json:"currency"`
+	// null limits fall back to the configured default of the currency
+	PerTransfer    pgtype.Int8 `json:"perTransfer"`
+	DailyAccount   pgtype.Int8 `json:"dailyAccount"`
+	MonthlyAccount pgtype.Int8 `json:"monthlyAccount"`
+	DailyUser      pgtype.Int8 `json:"dailyUser"`
+	MonthlyUser    pgtype.Int8 `json:"monthlyUser"`
+	UpdatedBy      string      `json:"updatedBy"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
 }
 
 type User struct {
-	Username          string    `json:"username"`
-	HashedPassword    string    `json:"hashedPassword"`
-	FullName          string    `json:"fullName"`
-	Email             string    `json:"email"`
-	PasswordChangedAt time.Time `json:"passwordChangedAt"`
-	CreatedAt         time.Time `json:"createdAt"`
+	Username          string      `json:"username"`
+	HashedPassword    string      `json:"hashedPassword"`
+	FullName          string      `json:"fullName"`
+	Email             string      `json:"email"`
+	PasswordChangedAt time.Time   `json:"passwordChangedAt"`
+	CreatedAt         time.Time   `json:"createdAt"`
+	TotpSecret        pgtype.Text `json:"totpSecret"`
+	Role              string      `json:"role"`
+	// selects the fee schedule of the transfers of the user
+	Tier string `json:"tier"`
+	// time step of the last accepted totp code, codes of this step or an earlier one are rejected
+	TotpLastStep int64 `json:"totpLastStep"`
 }
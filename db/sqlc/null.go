@@ -0,0 +1,25 @@
+package db
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The helpers below build the optional parameters of the queries,
+// the zero value of the Go type standing for NULL, that is for no filter.
+
+// NullString returns the string as a nullable parameter, NULL if it is empty
+func NullString(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// NullInt64 returns the integer as a nullable parameter, NULL if it is zero
+func NullInt64(v int64) pgtype.Int8 {
+	return pgtype.Int8{Int64: v, Valid: v != 0}
+}
+
+// NullTime returns the time as a nullable parameter, NULL if it is the zero time
+func NullTime(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
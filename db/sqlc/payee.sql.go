@@ -0,0 +1,211 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: payee.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const confirmPayee = `-- name: ConfirmPayee :one
+UPDATE payees SET confirmed_at = now()
+WHERE id = $1 AND confirmed_at IS NULL
+RETURNING id, owner, display_name, account_number, currency, note, confirmed_at, created_at
+`
+
+func (q *Queries) ConfirmPayee(ctx context.Context, id int64) (Payee, error) {
+	row := q.db.QueryRow(ctx, confirmPayee, id)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.DisplayName,
+		&i.AccountNumber,
+		&i.Currency,
+		&i.Note,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPayee = `-- name: CreatePayee :one
+INSERT INTO payees (
+  owner,
+  display_name,
+  account_number,
+  currency,
+  note,
+  confirmed_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  CASE WHEN $6::bool THEN now() END
+) RETURNING id, owner, display_name, account_number, currency, note, confirmed_at, created_at
+`
+
+type CreatePayeeParams struct {
+	Owner         string `json:"owner"`
+	DisplayName   string `json:"displayName"`
+	AccountNumber string `json:"accountNumber"`
+	Currency      string `json:"currency"`
+	Note          string `json:"note"`
+	Confirmed     bool   `json:"confirmed"`
+}
+
+func (q *Queries) CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, createPayee,
+		arg.Owner,
+		arg.DisplayName,
+		arg.AccountNumber,
+		arg.Currency,
+		arg.Note,
+		arg.Confirmed,
+	)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.DisplayName,
+		&i.AccountNumber,
+		&i.Currency,
+		&i.Note,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deletePayee = `-- name: DeletePayee :exec
+DELETE FROM payees WHERE id = $1
+`
+
+func (q *Queries) DeletePayee(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deletePayee, id)
+	return err
+}
+
+const getPayee = `-- name: GetPayee :one
+SELECT payees.id, payees.owner, payees.display_name, payees.account_number, payees.currency, payees.note, payees.confirmed_at, payees.created_at, bank_accounts.status = 'closed' AS target_closed
+FROM payees
+JOIN bank_accounts ON bank_accounts.account_number = payees.account_number
+WHERE payees.id = $1 LIMIT 1
+`
+
+type GetPayeeRow struct {
+	Payee        Payee `json:"payee"`
+	TargetClosed bool  `json:"targetClosed"`
+}
+
+func (q *Queries) GetPayee(ctx context.Context, id int64) (GetPayeeRow, error) {
+	row := q.db.QueryRow(ctx, getPayee, id)
+	var i GetPayeeRow
+	err := row.Scan(
+		&i.Payee.ID,
+		&i.Payee.Owner,
+		&i.Payee.DisplayName,
+		&i.Payee.AccountNumber,
+		&i.Payee.Currency,
+		&i.Payee.Note,
+		&i.Payee.ConfirmedAt,
+		&i.Payee.CreatedAt,
+		&i.TargetClosed,
+	)
+	return i, err
+}
+
+const listPayees = `-- name: ListPayees :many
+SELECT payees.id, payees.owner, payees.display_name, payees.account_number, payees.currency, payees.note, payees.confirmed_at, payees.created_at, bank_accounts.status = 'closed' AS target_closed
+FROM payees
+JOIN bank_accounts ON bank_accounts.account_number = payees.account_number
+WHERE payees.owner = $1
+  AND ($2::timestamptz IS NULL
+    OR (payees.created_at, payees.id) > ($2, $3::bigint))
+ORDER BY payees.created_at, payees.id
+LIMIT $4
+`
+
+type ListPayeesParams struct {
+	Owner           string             `json:"owner"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        int64              `json:"cursorID"`
+	Limit           int32              `json:"limit"`
+}
+
+type ListPayeesRow struct {
+	Payee        Payee `json:"payee"`
+	TargetClosed bool  `json:"targetClosed"`
+}
+
+func (q *Queries) ListPayees(ctx context.Context, arg ListPayeesParams) ([]ListPayeesRow, error) {
+	rows, err := q.db.Query(ctx, listPayees,
+		arg.Owner,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPayeesRow{}
+	for rows.Next() {
+		var i ListPayeesRow
+		if err := rows.Scan(
+			&i.Payee.ID,
+			&i.Payee.Owner,
+			&i.Payee.DisplayName,
+			&i.Payee.AccountNumber,
+			&i.Payee.Currency,
+			&i.Payee.Note,
+			&i.Payee.ConfirmedAt,
+			&i.Payee.CreatedAt,
+			&i.TargetClosed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePayee = `-- name: UpdatePayee :one
+UPDATE payees
+SET
+  display_name = COALESCE($1, display_name),
+  note = COALESCE($2, note)
+WHERE id = $3
+RETURNING id, owner, display_name, account_number, currency, note, confirmed_at, created_at
+`
+
+type UpdatePayeeParams struct {
+	DisplayName pgtype.Text `json:"displayName"`
+	Note        pgtype.Text `json:"note"`
+	ID          int64       `json:"id"`
+}
+
+func (q *Queries) UpdatePayee(ctx context.Context, arg UpdatePayeeParams) (Payee, error) {
+	row := q.db.QueryRow(ctx, updatePayee, arg.DisplayName, arg.Note, arg.ID)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.DisplayName,
+		&i.AccountNumber,
+		&i.Currency,
+		&i.Note,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
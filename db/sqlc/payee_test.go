@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPayee(t *testing.T, owner string, target BankAccount, confirmed bool) Payee {
+	arg := CreatePayeeParams{
+		Owner:         owner,
+		DisplayName:   tools.RandomOwner(),
+		AccountNumber: target.AccountNumber,
+		Currency:      target.Currency,
+		Note:          tools.RandomString(12),
+		Confirmed:     confirmed,
+	}
+
+	payee, err := testQueries.CreatePayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, payee.ID)
+	require.Equal(t, arg.Owner, payee.Owner)
+	require.Equal(t, arg.DisplayName, payee.DisplayName)
+	require.Equal(t, arg.AccountNumber, payee.AccountNumber)
+	require.Equal(t, arg.Currency, payee.Currency)
+	require.Equal(t, arg.Note, payee.Note)
+	require.Equal(t, confirmed, payee.ConfirmedAt.Valid)
+
+	return payee
+}
+
+func TestCreatePayeeTwice(t *testing.T) {
+	store := NewStore(testPool)
+	user := createRandomUser(t)
+	target := createRandomAccount(t)
+	createRandomPayee(t, user.Username, target, true)
+
+	_, err := store.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:         user.Username,
+		DisplayName:   tools.RandomOwner(),
+		AccountNumber: target.AccountNumber,
+		Currency:      target.Currency,
+	})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	require.Equal(t, "payees_owner_account_number_key", dbErr.Constraint)
+}
+
+func TestGetPayeeTargetClosed(t *testing.T) {
+	user := createRandomUser(t)
+	target := createRandomAccount(t)
+	payee := createRandomPayee(t, user.Username, target, true)
+
+	row, err := testQueries.GetPayee(context.Background(), payee.ID)
+	require.NoError(t, err)
+	require.Equal(t, payee, row.Payee)
+	require.False(t, row.TargetClosed)
+
+	_, err = testQueries.UpdateBankAccountStatus(context.Background(), UpdateBankAccountStatusParams{
+		ID:     target.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+
+	row, err = testQueries.GetPayee(context.Background(), payee.ID)
+	require.NoError(t, err)
+	require.True(t, row.TargetClosed)
+}
+
+func TestUpdatePayee(t *testing.T) {
+	user := createRandomUser(t)
+	payee := createRandomPayee(t, user.Username, createRandomAccount(t), true)
+
+	// only the given fields change
+	updated, err := testQueries.UpdatePayee(context.Background(), UpdatePayeeParams{
+		ID:   payee.ID,
+		Note: pgtype.Text{String: "", Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, payee.DisplayName, updated.DisplayName)
+	require.Empty(t, updated.Note)
+}
+
+func TestConfirmPayee(t *testing.T) {
+	user := createRandomUser(t)
+	payee := createRandomPayee(t, user.Username, createRandomAccount(t), false)
+
+	confirmed, err := testQueries.ConfirmPayee(context.Background(), payee.ID)
+	require.NoError(t, err)
+	require.True(t, confirmed.ConfirmedAt.Valid)
+
+	// a payee can only be confirmed once
+	_, err = testQueries.ConfirmPayee(context.Background(), payee.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListPayees(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomPayee(t, user.Username, createRandomAccount(t), true)
+	}
+	// someone else's payee
+	createRandomPayee(t, createRandomUser(t).Username, createRandomAccount(t), true)
+
+	rows, err := testQueries.ListPayees(context.Background(), ListPayeesParams{
+		Owner: user.Username,
+		Limit: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	last := rows[len(rows)-1].Payee
+	rows, err = testQueries.ListPayees(context.Background(), ListPayeesParams{
+		Owner:           user.Username,
+		CursorCreatedAt: NullTime(last.CreatedAt),
+		CursorID:        last.ID,
+		Limit:           2,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, user.Username, rows[0].Payee.Owner)
+}
+
+func TestDeletePayee(t *testing.T) {
+	user := createRandomUser(t)
+	payee := createRandomPayee(t, user.Username, createRandomAccount(t), true)
+
+	err := testQueries.DeletePayee(context.Background(), payee.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetPayee(context.Background(), payee.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Statement cache modes of the connections of the pool
+const (
+	// StatementCacheModePrepare prepares every query once per connection and caches the
+	// prepared statement, which is the fastest but not supported by PgBouncer in transaction mode
+	StatementCacheModePrepare = "prepare"
+	// StatementCacheModeDescribe caches only the description of every query,
+	// and runs it with an unnamed prepared statement
+	StatementCacheModeDescribe = "describe"
+	// StatementCacheModeNone describes every query before running it, without any cache
+	StatementCacheModeNone = "none"
+)
+
+// PoolConfig tunes the connection pool, zero values keep the pgx defaults
+type PoolConfig struct {
+	MaxConns           int32
+	MinConns           int32
+	MaxConnLifetime    time.Duration
+	MaxConnIdleTime    time.Duration
+	StatementCacheMode string
+}
+
+// NewPool creates a connection pool to the database. Connections are opened lazily,
+// so the database does not have to be reachable yet.
+func NewPool(ctx context.Context, dbSource string, config PoolConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dbSource)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse db source: %w", err)
+	}
+
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+	if config.MinConns > 0 {
+		poolConfig.MinConns = config.MinConns
+	}
+	if config.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	}
+	if config.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
+	}
+
+	execMode, err := queryExecMode(config.StatementCacheMode)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.ConnConfig.DefaultQueryExecMode = execMode
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+// queryExecMode returns how pgx runs queries in the statement cache mode
+func queryExecMode(mode string) (pgx.QueryExecMode, error) {
+	switch mode {
+	case "", StatementCacheModePrepare:
+		return pgx.QueryExecModeCacheStatement, nil
+	case StatementCacheModeDescribe:
+		return pgx.QueryExecModeCacheDescribe, nil
+	case StatementCacheModeNone:
+		return pgx.QueryExecModeDescribeExec, nil
+	default:
+		return 0, fmt.Errorf("unsupported statement cache mode %q: must be %s, %s or %s",
+			mode, StatementCacheModePrepare, StatementCacheModeDescribe, StatementCacheModeNone)
+	}
+}
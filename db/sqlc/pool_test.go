@@ -0,0 +1,24 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryExecMode(t *testing.T) {
+	for mode, expected := range map[string]pgx.QueryExecMode{
+		"":                         pgx.QueryExecModeCacheStatement,
+		StatementCacheModePrepare:  pgx.QueryExecModeCacheStatement,
+		StatementCacheModeDescribe: pgx.QueryExecModeCacheDescribe,
+		StatementCacheModeNone:     pgx.QueryExecModeDescribeExec,
+	} {
+		execMode, err := queryExecMode(mode)
+		require.NoError(t, err)
+		require.Equal(t, expected, execMode)
+	}
+
+	_, err := queryExecMode("always")
+	require.Error(t, err)
+}
@@ -1,34 +1,112 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 
 package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	AddBankAccountBalance(ctx context.Context, arg AddBankAccountBalanceParams) (BankAccount, error)
+	AddBankAccountHeld(ctx context.Context, arg AddBankAccountHeldParams) (BankAccount, error)
+	// Counts an attempt to verify the challenge, none is left once max_attempts were made.
+	AttemptStepUpChallenge(ctx context.Context, arg AttemptStepUpChallengeParams) (StepUpChallenge, error)
+	ConfirmPayee(ctx context.Context, id int64) (Payee, error)
+	// Uses up a verified challenge issued to the owner for exactly the batch of the hash.
+	ConsumeBatchStepUpChallenge(ctx context.Context, arg ConsumeBatchStepUpChallengeParams) (StepUpChallenge, error)
+	ConsumeStepUpChallenge(ctx context.Context, id uuid.UUID) (StepUpChallenge, error)
+	// Counts an attempt without locking the key, for a key shared by concurrent clients like an IP.
+	// No row is returned while the key is locked out.
+	CountLoginAttempt(ctx context.Context, arg CountLoginAttemptParams) (LoginFailure, error)
 	CreateBankAccount(ctx context.Context, arg CreateBankAccountParams) (BankAccount, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateEntryWithCategory(ctx context.Context, arg CreateEntryWithCategoryParams) (Entry, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) error
+	// Keeps the part of a posting below the minor unit, which can be negative when the posting
+	// was rounded up, to be added to the next posting.
+	CreateInterestCarryOver(ctx context.Context, arg CreateInterestCarryOverParams) error
+	CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateStepUpChallenge(ctx context.Context, arg CreateStepUpChallengeParams) (StepUpChallenge, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error)
+	CreateTransferBatchItem(ctx context.Context, arg CreateTransferBatchItemParams) (TransferBatchItem, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteBankAccount(ctx context.Context, id int64) error
+	DeleteLoginFailure(ctx context.Context, arg DeleteLoginFailureParams) error
+	DeletePayee(ctx context.Context, id int64) error
+	FailPendingTransferBatchItems(ctx context.Context, batchID int64) error
+	FinishTransferBatch(ctx context.Context, arg FinishTransferBatchParams) (TransferBatch, error)
+	GetAccountProduct(ctx context.Context, code string) (AccountProduct, error)
 	GetBankAccount(ctx context.Context, id int64) (BankAccount, error)
+	GetBankAccountByNumber(ctx context.Context, accountNumber string) (BankAccount, error)
 	GetBankAccountForUpdate(ctx context.Context, id int64) (BankAccount, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetLastInterestRun(ctx context.Context, kind string) (InterestRun, error)
+	GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error)
+	GetOutgoingTransferTotals(ctx context.Context, arg GetOutgoingTransferTotalsParams) (GetOutgoingTransferTotalsRow, error)
+	GetOwnerTransfer(ctx context.Context, arg GetOwnerTransferParams) (Transfer, error)
+	GetPayee(ctx context.Context, id int64) (GetPayeeRow, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetStepUpChallenge(ctx context.Context, id uuid.UUID) (StepUpChallenge, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error)
+	GetTransferFeeSchedule(ctx context.Context, arg GetTransferFeeScheduleParams) (FeeSchedule, error)
+	GetTransferLimitOverride(ctx context.Context, arg GetTransferLimitOverrideParams) (TransferLimitOverride, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	ListAccountProducts(ctx context.Context) ([]AccountProduct, error)
 	ListBankAccounts(ctx context.Context, arg ListBankAccountsParams) ([]BankAccount, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListExpiredHolds(ctx context.Context, arg ListExpiredHoldsParams) ([]Hold, error)
+	ListInterestAccruals(ctx context.Context, accountID int64) ([]InterestAccrual, error)
+	// The balance of every account earning interest at the end of the day,
+	// worked back from the current balance and the entries made since then.
+	ListInterestBearingBalances(ctx context.Context, dayEnd time.Time) ([]ListInterestBearingBalancesRow, error)
+	ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]Transfer, error)
+	ListPayees(ctx context.Context, arg ListPayeesParams) ([]ListPayeesRow, error)
+	// The entries of an account over a period, oldest first, for the statement export.
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]Entry, error)
+	ListTransferBatchItems(ctx context.Context, batchID int64) ([]TransferBatchItem, error)
+	// The account numbers of the recipients of a batch, which the items are reported with.
+	ListTransferBatchRecipients(ctx context.Context, batchID int64) ([]ListTransferBatchRecipientsRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	// The interest to post to every account, with the account paying it in the currency of the account.
+	ListUnpostedInterest(ctx context.Context, before pgtype.Date) ([]ListUnpostedInterestRow, error)
+	LockLogin(ctx context.Context, arg LockLoginParams) (LoginFailure, error)
+	LockTransferSender(ctx context.Context, id int64) (LockTransferSenderRow, error)
+	MarkInterestPosted(ctx context.Context, arg MarkInterestPostedParams) error
+	ReleaseHold(ctx context.Context, arg ReleaseHoldParams) (Hold, error)
+	// Uncounts an attempt that did not fail, and unlocks the key it locked.
+	ReleaseLoginAttempt(ctx context.Context, arg ReleaseLoginAttemptParams) error
+	SetAccountProduct(ctx context.Context, arg SetAccountProductParams) (AccountProduct, error)
+	SetFeeSchedule(ctx context.Context, arg SetFeeScheduleParams) (FeeSchedule, error)
+	SetInterestExpenseAccount(ctx context.Context, arg SetInterestExpenseAccountParams) (InterestExpenseAccount, error)
+	SetTransferLimitOverride(ctx context.Context, arg SetTransferLimitOverrideParams) (TransferLimitOverride, error)
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
+	StartInterestRun(ctx context.Context, arg StartInterestRunParams) (InterestRun, error)
+	// Counts an attempt and locks the key until the attempt is resolved.
+	// No row is returned while the key is locked out.
+	StartLoginAttempt(ctx context.Context, arg StartLoginAttemptParams) (LoginFailure, error)
+	// Uncounts an attempt that did not fail, leaving the lockout of the key to the other attempts.
+	UncountLoginAttempt(ctx context.Context, arg UncountLoginAttemptParams) error
 	UpdateBankAccount(ctx context.Context, arg UpdateBankAccountParams) (BankAccount, error)
+	UpdateBankAccountNickname(ctx context.Context, arg UpdateBankAccountNicknameParams) (BankAccount, error)
+	UpdateBankAccountStatus(ctx context.Context, arg UpdateBankAccountStatusParams) (BankAccount, error)
+	UpdateEntryCategory(ctx context.Context, arg UpdateEntryCategoryParams) (Entry, error)
+	UpdatePayee(ctx context.Context, arg UpdatePayeeParams) (Payee, error)
+	UpdateTransferBatchItem(ctx context.Context, arg UpdateTransferBatchItemParams) (TransferBatchItem, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	// Records the time step of an accepted totp code, a code of that step or an earlier one is a replay.
+	UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error)
+	VerifyStepUpChallenge(ctx context.Context, arg VerifyStepUpChallengeParams) (StepUpChallenge, error)
 }
 
 var _ Querier = (*Queries)(nil)
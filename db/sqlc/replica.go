@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// replicaQueries are the read-only queries served by the replica while it is healthy:
+// the statement and history listings, which can be a little behind. Everything else,
+// including the reads that must see the latest balance or what the caller has just
+// written such as accounts, transfers, sessions and step-up challenges, stays on the primary.
+var replicaQueries = map[string]bool{
+	"ListEntries":          true,
+	"ListStatementEntries": true,
+	"ListTransfers":        true,
+	"ListOwnerTransfers":   true,
+}
+
+// replicationLag returns 0 when the server is not a standby or has replayed everything it has
+// received, otherwise the time since the last transaction it replayed
+const replicationLag = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END::float8`
+
+// Replica is a read replica of the database. It is used only while it is reachable and
+// lags behind the primary by less than the maximum lag, as found by Monitor, and the
+// queries fall back to the primary otherwise.
+type Replica struct {
+	db     DBTX
+	maxLag time.Duration
+
+	healthy atomic.Bool
+	lag     atomic.Int64
+}
+
+// NewReplica creates a replica on the connection pool. It is not used until Monitor has
+// checked it once.
+func NewReplica(pool *pgxpool.Pool, maxLag time.Duration) *Replica {
+	return &Replica{db: pool, maxLag: maxLag}
+}
+
+// Monitor checks the replication lag every interval until the context is done
+func (replica *Replica) Monitor(ctx context.Context, interval time.Duration) {
+	replica.check(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			replica.check(ctx)
+		}
+	}
+}
+
+// Status returns the last replication lag measured and whether the replica is in use
+func (replica *Replica) Status() (lag time.Duration, healthy bool) {
+	return time.Duration(replica.lag.Load()), replica.healthy.Load()
+}
+
+func (replica *Replica) check(ctx context.Context) {
+	var seconds float64
+	err := replica.db.QueryRow(ctx, replicationLag).Scan(&seconds)
+	if err != nil {
+		replica.setHealthy(false, err)
+		return
+	}
+
+	lag := time.Duration(seconds * float64(time.Second))
+	replica.lag.Store(int64(lag))
+	if lag > replica.maxLag {
+		replica.setHealthy(false, errors.New("replication lag is above the maximum"))
+		return
+	}
+	replica.setHealthy(true, nil)
+}
+
+// setHealthy logs when the replica starts or stops being used
+func (replica *Replica) setHealthy(healthy bool, err error) {
+	if replica.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	lag, _ := replica.Status()
+	if healthy {
+		log.Info().Dur("lag", lag).Msg("read replica in use")
+		return
+	}
+	log.Warn().Err(err).Dur("lag", lag).Msg("read replica not in use, falling back to the primary")
+}
+
+// routedDBTX runs the replicaQueries on the replica while it is healthy, and on the
+// primary otherwise. A query that cannot reach the replica is run again on the primary.
+// Transactions never go through it, so everything inside execTx stays on the primary.
+type routedDBTX struct {
+	primary DBTX
+	replica *Replica
+}
+
+func newRoutedDBTX(primary DBTX, replica *Replica) DBTX {
+	if replica == nil {
+		return primary
+	}
+	return &routedDBTX{primary: primary, replica: replica}
+}
+
+// onReplica reports whether the query is run on the replica, and records it on the span
+func (r *routedDBTX) onReplica(ctx context.Context, query string) bool {
+	if !replicaQueries[queryName(query)] || !r.replica.healthy.Load() {
+		return false
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("db.replica", true))
+	return true
+}
+
+func (r *routedDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	return r.primary.Exec(ctx, query, args...)
+}
+
+func (r *routedDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	if !r.onReplica(ctx, query) {
+		return r.primary.Query(ctx, query, args...)
+	}
+
+	rows, err := r.replica.db.Query(ctx, query, args...)
+	if r.unavailable(ctx, err) {
+		return r.primary.Query(ctx, query, args...)
+	}
+	return rows, err
+}
+
+func (r *routedDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	if !r.onReplica(ctx, query) {
+		return r.primary.QueryRow(ctx, query, args...)
+	}
+
+	return &fallbackRow{
+		row: r.replica.db.QueryRow(ctx, query, args...),
+		fallback: func() pgx.Row {
+			return r.primary.QueryRow(ctx, query, args...)
+		},
+		unavailable: func(err error) bool {
+			return r.unavailable(ctx, err)
+		},
+	}
+}
+
+// unavailable reports whether the replica could not be reached, rather than running the
+// query and returning an error or no rows, and stops using it until Monitor checks it again
+func (r *routedDBTX) unavailable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return false
+	}
+
+	r.replica.setHealthy(false, err)
+	return true
+}
+
+// fallbackRow scans the row of the primary when the replica could not be reached
+type fallbackRow struct {
+	row         pgx.Row
+	fallback    func() pgx.Row
+	unavailable func(err error) bool
+}
+
+func (r *fallbackRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if r.unavailable(err) {
+		return r.fallback().Scan(dest...)
+	}
+	return err
+}
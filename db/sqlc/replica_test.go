@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDBTX records the name of the queries it runs and fails them with err
+type fakeDBTX struct {
+	queries []string
+	err     error
+	lag     float64
+}
+
+func (f *fakeDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	f.queries = append(f.queries, queryName(query))
+	return pgconn.CommandTag{}, f.err
+}
+
+func (f *fakeDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	f.queries = append(f.queries, queryName(query))
+	return nil, f.err
+}
+
+func (f *fakeDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	f.queries = append(f.queries, queryName(query))
+	return &fakeRow{err: f.err, lag: f.lag}
+}
+
+type fakeRow struct {
+	err error
+	lag float64
+}
+
+func (r *fakeRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(dest) == 1 {
+		if lag, ok := dest[0].(*float64); ok {
+			*lag = r.lag
+		}
+	}
+	return nil
+}
+
+func newTestReplica(db DBTX, healthy bool) *Replica {
+	replica := &Replica{db: db, maxLag: time.Second}
+	replica.healthy.Store(healthy)
+	return replica
+}
+
+func TestRoutedDBTX(t *testing.T) {
+	ctx := context.Background()
+	primary := &fakeDBTX{}
+	replicaDB := &fakeDBTX{}
+	routed := newRoutedDBTX(primary, newTestReplica(replicaDB, true))
+
+	_, err := routed.Query(ctx, listTransfers)
+	require.NoError(t, err)
+	_, err = routed.Query(ctx, listEntries)
+	require.NoError(t, err)
+	require.NoError(t, routed.QueryRow(ctx, getSession).Scan())
+	require.NoError(t, routed.QueryRow(ctx, getBankAccount).Scan())
+	require.NoError(t, routed.QueryRow(ctx, getTransfer).Scan())
+	_, err = routed.Query(ctx, listBankAccounts)
+	require.NoError(t, err)
+	_, err = routed.Exec(ctx, updateBankAccountStatus)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"ListTransfers", "ListEntries"}, replicaDB.queries)
+	require.Equal(t, []string{"GetSession", "GetBankAccount", "GetTransfer", "ListBankAccounts", "UpdateBankAccountStatus"}, primary.queries)
+}
+
+func TestRoutedDBTXUnhealthyReplica(t *testing.T) {
+	primary := &fakeDBTX{}
+	replicaDB := &fakeDBTX{}
+	routed := newRoutedDBTX(primary, newTestReplica(replicaDB, false))
+
+	_, err := routed.Query(context.Background(), listEntries)
+	require.NoError(t, err)
+
+	require.Empty(t, replicaDB.queries)
+	require.Equal(t, []string{"ListEntries"}, primary.queries)
+}
+
+func TestRoutedDBTXFallback(t *testing.T) {
+	ctx := context.Background()
+	primary := &fakeDBTX{}
+	replicaDB := &fakeDBTX{err: errors.New("dial tcp: connection refused")}
+	replica := newTestReplica(replicaDB, true)
+	routed := newRoutedDBTX(primary, replica)
+
+	_, err := routed.Query(ctx, listOwnerTransfers)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ListOwnerTransfers"}, replicaDB.queries)
+	require.Equal(t, []string{"ListOwnerTransfers"}, primary.queries)
+
+	// the replica is not used again until it has been checked
+	_, healthy := replica.Status()
+	require.False(t, healthy)
+
+	_, err = routed.Query(ctx, listEntries)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ListOwnerTransfers"}, replicaDB.queries)
+	require.Equal(t, []string{"ListOwnerTransfers", "ListEntries"}, primary.queries)
+}
+
+func TestRoutedDBTXNoFallback(t *testing.T) {
+	ctx := context.Background()
+
+	for _, replicaErr := range []error{
+		ErrRecordNotFound,
+		&pgconn.PgError{Code: "57014"},
+	} {
+		primary := &fakeDBTX{}
+		replica := newTestReplica(&fakeDBTX{err: replicaErr}, true)
+		routed := newRoutedDBTX(primary, replica)
+
+		_, err := routed.Query(ctx, listEntries)
+		require.ErrorIs(t, err, replicaErr)
+		require.Empty(t, primary.queries)
+
+		_, healthy := replica.Status()
+		require.True(t, healthy)
+	}
+}
+
+func TestReplicaCheck(t *testing.T) {
+	replicaDB := &fakeDBTX{lag: 0.5}
+	replica := newTestReplica(replicaDB, false)
+
+	replica.check(context.Background())
+	lag, healthy := replica.Status()
+	require.True(t, healthy)
+	require.Equal(t, 500*time.Millisecond, lag)
+
+	replicaDB.lag = 2
+	replica.check(context.Background())
+	lag, healthy = replica.Status()
+	require.False(t, healthy)
+	require.Equal(t, 2*time.Second, lag)
+
+	replicaDB.lag = 0
+	replicaDB.err = errors.New("dial tcp: connection refused")
+	replica.check(context.Background())
+	_, healthy = replica.Status()
+	require.False(t, healthy)
+}
+
+func TestReplicaCheckDB(t *testing.T) {
+	// a database that is not a standby has no lag
+	replica := NewReplica(testPool, time.Second)
+	replica.check(context.Background())
+
+	lag, healthy := replica.Status()
+	require.True(t, healthy)
+	require.Zero(t, lag)
+}
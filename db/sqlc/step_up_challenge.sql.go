@@ -0,0 +1,248 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: step_up_challenge.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const attemptStepUpChallenge = `-- name: AttemptStepUpChallenge :one
+UPDATE step_up_challenges
+SET attempts = attempts + 1
+WHERE
+  id = $1 AND verified_at IS NULL AND attempts < $2::integer
+RETURNING id, username, from_account_id, to_account_id, amount, currency, method, verified_at, consumed_at, expires_at, created_at, batch_hash, attempts
+`
+
+type AttemptStepUpChallengeParams struct {
+	ID          uuid.UUID `json:"id"`
+	MaxAttempts int32     `json:"maxAttempts"`
+}
+
+// Counts an attempt to verify the challenge, none is left once max_attempts were made.
+func (q *Queries) AttemptStepUpChallenge(ctx context.Context, arg AttemptStepUpChallengeParams) (StepUpChallenge, error) {
+	row := q.db.QueryRow(ctx, attemptStepUpChallenge, arg.ID, arg.MaxAttempts)
+	var i StepUpChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Method,
+		&i.VerifiedAt,
+		&i.ConsumedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.BatchHash,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const consumeBatchStepUpChallenge = `-- name: ConsumeBatchStepUpChallenge :one
+UPDATE step_up_challenges
+SET consumed_at = now()
+WHERE
+  id = $1
+  AND username = $2
+  AND from_account_id = $3
+  AND batch_hash = $4::varchar
+  AND amount = $5
+  AND currency = $6
+  AND verified_at IS NOT NULL AND consumed_at IS NULL AND expires_at > now()
+RETURNING id, username, from_account_id, to_account_id, amount, currency, method, verified_at, consumed_at, expires_at, created_at, batch_hash, attempts
+`
+
+type ConsumeBatchStepUpChallengeParams struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	FromAccountID int64     `json:"fromAccountID"`
+	BatchHash     string    `json:"batchHash"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+}
+
+// Uses up a verified challenge issued to the owner for exactly the batch of the hash.
+func (q *Queries) ConsumeBatchStepUpChallenge(ctx context.Context, arg ConsumeBatchStepUpChallengeParams) (StepUpChallenge, error) {
+	row := q.db.QueryRow(ctx, consumeBatchStepUpChallenge,
+		arg.ID,
+		arg.Username,
+		arg.FromAccountID,
+		arg.BatchHash,
+		arg.Amount,
+		arg.Currency,
+	)
+	var i StepUpChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Method,
+		&i.VerifiedAt,
+		&i.ConsumedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.BatchHash,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const consumeStepUpChallenge = `-- name: ConsumeStepUpChallenge :one
+UPDATE step_up_challenges
+SET consumed_at = now()
+WHERE
+  id = $1 AND verified_at IS NOT NULL AND consumed_at IS NULL AND expires_at > now()
+RETURNING id, username, from_account_id, to_account_id, amount, currency, method, verified_at, consumed_at, expires_at, created_at, batch_hash, attempts
+`
+
+func (q *Queries) ConsumeStepUpChallenge(ctx context.Context, id uuid.UUID) (StepUpChallenge, error) {
+	row := q.db.QueryRow(ctx, consumeStepUpChallenge, id)
+	var i StepUpChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Method,
+		&i.VerifiedAt,
+		&i.ConsumedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.BatchHash,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const createStepUpChallenge = `-- name: CreateStepUpChallenge :one
+INSERT INTO step_up_challenges (
+  id,
+  username,
+  from_account_id,
+  to_account_id,
+  batch_hash,
+  amount,
+  currency,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, username, from_account_id, to_account_id, amount, currency, method, verified_at, consumed_at, expires_at, created_at, batch_hash, attempts
+`
+
+type CreateStepUpChallengeParams struct {
+	ID            uuid.UUID   `json:"id"`
+	Username      string      `json:"username"`
+	FromAccountID int64       `json:"fromAccountID"`
+	ToAccountID   pgtype.Int8 `json:"toAccountID"`
+	BatchHash     pgtype.Text `json:"batchHash"`
+	Amount        int64       `json:"amount"`
+	Currency      string      `json:"currency"`
+	ExpiresAt     time.Time   `json:"expiresAt"`
+}
+
+func (q *Queries) CreateStepUpChallenge(ctx context.Context, arg CreateStepUpChallengeParams) (StepUpChallenge, error) {
+	row := q.db.QueryRow(ctx, createStepUpChallenge,
+		arg.ID,
+		arg.Username,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.BatchHash,
+		arg.Amount,
+		arg.Currency,
+		arg.ExpiresAt,
+	)
+	var i StepUpChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Method,
+		&i.VerifiedAt,
+		&i.ConsumedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.BatchHash,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const getStepUpChallenge = `-- name: GetStepUpChallenge :one
+SELECT id, username, from_account_id, to_account_id, amount, currency, method, verified_at, consumed_at, expires_at, created_at, batch_hash, attempts FROM step_up_challenges
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetStepUpChallenge(ctx context.Context, id uuid.UUID) (StepUpChallenge, error) {
+	row := q.db.QueryRow(ctx, getStepUpChallenge, id)
+	var i StepUpChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Method,
+		&i.VerifiedAt,
+		&i.ConsumedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.BatchHash,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const verifyStepUpChallenge = `-- name: VerifyStepUpChallenge :one
+UPDATE step_up_challenges
+SET
+  method = $1::varchar,
+  verified_at = now()
+WHERE
+  id = $2 AND verified_at IS NULL
+RETURNING id, username, from_account_id, to_account_id, amount, currency, method, verified_at, consumed_at, expires_at, created_at, batch_hash, attempts
+`
+
+type VerifyStepUpChallengeParams struct {
+	Method string    `json:"method"`
+	ID     uuid.UUID `json:"id"`
+}
+
+func (q *Queries) VerifyStepUpChallenge(ctx context.Context, arg VerifyStepUpChallengeParams) (StepUpChallenge, error) {
+	row := q.db.QueryRow(ctx, verifyStepUpChallenge, arg.Method, arg.ID)
+	var i StepUpChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Method,
+		&i.VerifiedAt,
+		&i.ConsumedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.BatchHash,
+		&i.Attempts,
+	)
+	return i, err
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomStepUpChallenge(t *testing.T, from BankAccount, to BankAccount) StepUpChallenge {
+	arg := CreateStepUpChallengeParams{
+		ID:            uuid.New(),
+		Username:      from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   pgtype.Int8{Int64: to.ID, Valid: true},
+		Amount:        10,
+		Currency:      from.Currency,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	challenge, err := testQueries.CreateStepUpChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, challenge.ID)
+	require.Equal(t, arg.Username, challenge.Username)
+	require.Equal(t, arg.Amount, challenge.Amount)
+	require.False(t, challenge.VerifiedAt.Valid)
+	require.False(t, challenge.ConsumedAt.Valid)
+
+	return challenge
+}
+
+func TestVerifyStepUpChallenge(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	challenge := createRandomStepUpChallenge(t, account1, account2)
+
+	verified, err := testQueries.VerifyStepUpChallenge(context.Background(), VerifyStepUpChallengeParams{
+		ID:     challenge.ID,
+		Method: "password",
+	})
+	require.NoError(t, err)
+	require.True(t, verified.VerifiedAt.Valid)
+	require.Equal(t, "password", verified.Method.String)
+
+	// a challenge can only be verified once
+	_, err = testQueries.VerifyStepUpChallenge(context.Background(), VerifyStepUpChallengeParams{
+		ID:     challenge.ID,
+		Method: "totp",
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestAttemptStepUpChallenge(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	challenge := createRandomStepUpChallenge(t, account1, account2)
+
+	arg := AttemptStepUpChallengeParams{ID: challenge.ID, MaxAttempts: 2}
+	for i := int32(1); i <= arg.MaxAttempts; i++ {
+		attempted, err := testQueries.AttemptStepUpChallenge(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, i, attempted.Attempts)
+	}
+
+	// no attempt is left, nor is the challenge verified
+	_, err := testQueries.AttemptStepUpChallenge(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	unverified, err := testQueries.GetStepUpChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.MaxAttempts, unverified.Attempts)
+	require.False(t, unverified.VerifiedAt.Valid)
+}
+
+func TestUseUserTOTPStep(t *testing.T) {
+	user := createRandomUser(t)
+	step := time.Now().Unix() / 30
+
+	updated, err := testQueries.UseUserTOTPStep(context.Background(), UseUserTOTPStepParams{Username: user.Username, Step: step})
+	require.NoError(t, err)
+	require.Equal(t, step, updated.TotpLastStep)
+
+	// a code of the same step or of an earlier one is a replay
+	_, err = testQueries.UseUserTOTPStep(context.Background(), UseUserTOTPStepParams{Username: user.Username, Step: step})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = testQueries.UseUserTOTPStep(context.Background(), UseUserTOTPStepParams{Username: user.Username, Step: step - 1})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = testQueries.UseUserTOTPStep(context.Background(), UseUserTOTPStepParams{Username: user.Username, Step: step + 1})
+	require.NoError(t, err)
+}
+
+func TestTransferTxConsumesStepUpChallenge(t *testing.T) {
+	store := NewStore(testPool)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	challenge := createRandomStepUpChallenge(t, account1, account2)
+
+	arg := CreateTransferParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            challenge.Amount,
+		StepUpChallengeID: uuid.NullUUID{UUID: challenge.ID, Valid: true},
+	}
+
+	// an unverified challenge cannot authorize a transfer
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrStepUpChallengeUnusable)
+
+	_, err = testQueries.VerifyStepUpChallenge(context.Background(), VerifyStepUpChallengeParams{
+		ID:     challenge.ID,
+		Method: "password",
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.StepUpChallengeID, result.Transfer.StepUpChallengeID)
+
+	consumed, err := testQueries.GetStepUpChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.True(t, consumed.ConsumedAt.Valid)
+
+	// the same confirmation cannot be used twice
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrStepUpChallengeUnusable)
+}
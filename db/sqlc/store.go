@@ -2,49 +2,212 @@ package db
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/radugaf/simplebank/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg CreateTransferParams) (TransferTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateBankAccountStatusParams) (BankAccount, error)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error)
+	AccrueInterestTx(ctx context.Context, date time.Time) (AccrueInterestTxResult, error)
+	PostInterestTx(ctx context.Context, date time.Time) (PostInterestTxResult, error)
+	AuthorizeTransferTx(ctx context.Context, arg CreateHoldParams) (AuthorizeTransferTxResult, error)
+	CaptureTransferTx(ctx context.Context, arg CaptureTransferTxParams) (CaptureTransferTxResult, error)
+	VoidTransferTx(ctx context.Context, arg VoidTransferTxParams) (VoidTransferTxResult, error)
+	ExpireHoldsTx(ctx context.Context, now time.Time, limit int32) ([]Hold, error)
+	TransferBatchTx(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error)
 }
 
 // Store provides all functions to execute db queries and transactions
 type SQLStore struct {
 	*Queries
-	db *sql.DB // Required to create a new DB transaction
+	connPool *pgxpool.Pool // Required to create a new DB transaction
+
+	maxTxRetries     int
+	txRetryBaseDelay time.Duration
+	onTxRetry        func(code string)
+	replica          *Replica
+	transferLimits   TransferLimits
+}
+
+// Defaults for retrying transactions that failed on a serialization failure or a deadlock
+const (
+	DefaultMaxTxRetries     = 3
+	DefaultTxRetryBaseDelay = 10 * time.Millisecond
+	maxTxRetryDelay         = time.Second
+)
+
+// StoreOption configures a SQLStore
+type StoreOption func(store *SQLStore)
+
+// WithTxRetries sets how many times a transaction is retried after a serialization failure
+// or a deadlock, and the delay before the first retry, which doubles with every retry
+func WithTxRetries(maxRetries int, baseDelay time.Duration) StoreOption {
+	return func(store *SQLStore) {
+		store.maxTxRetries = maxRetries
+		store.txRetryBaseDelay = baseDelay
+	}
+}
+
+// WithTxRetryHook sets a function called with the Postgres error code before every retry
+func WithTxRetryHook(hook func(code string)) StoreOption {
+	return func(store *SQLStore) {
+		store.onTxRetry = hook
+	}
 }
 
-// NewStore creates a new store
-func NewStore(db *sql.DB) Store {
-	return &SQLStore{
-		Queries: New(db),
-		db:      db,
+// WithReplica runs the read-only queries that tolerate a small lag on the replica
+func WithReplica(replica *Replica) StoreOption {
+	return func(store *SQLStore) {
+		store.replica = replica
+	}
+}
+
+// NewStore creates a new store running its queries on the connection pool
+func NewStore(connPool *pgxpool.Pool, options ...StoreOption) Store {
+	store := &SQLStore{
+		connPool:         connPool,
+		maxTxRetries:     DefaultMaxTxRetries,
+		txRetryBaseDelay: DefaultTxRetryBaseDelay,
+	}
+
+	for _, option := range options {
+		option(store)
+	}
+
+	store.Queries = New(newTracedDBTX(newRoutedDBTX(connPool, store.replica), nil))
+	return store
+}
+
+// execTx executes a function within a database transaction with the given options, the zero
+// value for the defaults. Serialization failures and deadlocks are retried in a new transaction after
+// a jittered backoff, so fn must not have side effects outside of the transaction.
+func (store *SQLStore) execTx(ctx context.Context, opts pgx.TxOptions, fn func(queries *Queries) error) error {
+	logger := logging.FromContext(ctx)
+	startTime := time.Now()
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "SQLStore.execTx")
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, span, opts, fn)
+		if err == nil {
+			logger.Debug().Dur("duration", time.Since(startTime)).Int("attempts", attempt).Msg("transaction committed")
+			return nil
+		}
+
+		code, retryable := retryableTxError(err)
+		if !retryable || attempt > store.maxTxRetries {
+			recordError(span, err)
+			return classifyError(err)
+		}
+
+		backoff := store.txRetryBackoff(attempt)
+		logger.Warn().Err(err).Str("code", code).Int("attempt", attempt).Dur("backoff", backoff).Msg("retrying transaction")
+		span.AddEvent("retry", trace.WithAttributes(attribute.String("code", code), attribute.Int("attempt", attempt)))
+		if store.onTxRetry != nil {
+			store.onTxRetry(code)
+		}
+
+		select {
+		case <-ctx.Done():
+			recordError(span, err)
+			return classifyError(err)
+		case <-time.After(backoff):
+		}
 	}
 }
 
-// execTx executes a function within a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) error) error {
+// runTx runs the function in a single transaction
+func (store *SQLStore) runTx(ctx context.Context, span trace.Span, opts pgx.TxOptions, fn func(queries *Queries) error) error {
+	logger := logging.FromContext(ctx)
+
 	// Starts a new DB transaction
-	tx, err := store.db.BeginTx(ctx, nil)
+	tx, err := store.connPool.BeginTx(ctx, opts)
 	if err != nil {
+		logger.Error().Err(err).Msg("cannot begin transaction")
 		return err
 	}
 
-	// Create a new queries object with the transaction then run the function
-	query := New(tx)
+	// Create a new queries object with the transaction then run the function.
+	// Its queries are traced as children of the span of the transaction.
+	query := New(newTracedDBTX(tx, span))
 	err = fn(query)
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			logger.Error().Err(err).AnErr("rollback_error", rbErr).Msg("cannot roll back transaction")
 			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
 		}
+		logger.Warn().Err(err).Msg("transaction rolled back")
 		return err
 	}
 
 	// Commit the transaction
-	return tx.Commit()
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error().Err(err).Msg("cannot commit transaction")
+		return err
+	}
+
+	return nil
+}
+
+// Postgres error codes of transactions that can safely be retried
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+// retryableTxError returns the error code if the transaction failed on a serialization failure or a deadlock
+func retryableTxError(err error) (string, bool) {
+	code := ErrorCode(err)
+	return code, code == serializationFailureCode || code == deadlockDetectedCode
+}
+
+// txRetryBackoff doubles the base delay with every attempt up to a maximum,
+// and picks a random delay between half of it and all of it
+func (store *SQLStore) txRetryBackoff(attempt int) time.Duration {
+	delay := store.txRetryBaseDelay
+	for i := 1; i < attempt && delay < maxTxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxTxRetryDelay {
+		delay = maxTxRetryDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// ErrStepUpChallengeUnusable is returned by TransferTx and AuthorizeTransferTx when the step-up challenge attached
+// to the transfer is not verified, has expired or was already used by another transfer.
+var ErrStepUpChallengeUnusable = errors.New("step-up challenge is not verified, expired or already used")
+
+// useStepUpChallenge uses up the step-up confirmation so it cannot authorize a second transfer
+func useStepUpChallenge(ctx context.Context, q *Queries, challengeID uuid.NullUUID) error {
+	if !challengeID.Valid {
+		return nil
+	}
+
+	_, err := q.ConsumeStepUpChallenge(ctx, challengeID.UUID)
+	if errors.Is(err, ErrRecordNotFound) {
+		return ErrStepUpChallengeUnusable
+	}
+	return err
 }
 
 // TransferTxResult represents the result of a TransferTx operation
@@ -54,57 +217,125 @@ type TransferTxResult struct {
 	Transfer    Transfer    `json:"transfer"`
 	FromEntry   Entry       `json:"from_entry"`
 	ToEntry     Entry       `json:"to_entry"`
+	// FeeEntry is the entry of the fee on the from account, if the transfer was charged one
+	FeeEntry *Entry `json:"fee_entry,omitempty"`
 }
 
 // TransferTx performs a money transfer from one account to the other.
 // It creates a transfer record, add account entries and update account balances within a database transaction.
+// It fails with a TransferLimitError if the transfer exceeds a limit of the sender.
+// The fee of the transfer is computed from the fee schedules, the Fee of arg is ignored.
 func (store *SQLStore) TransferTx(ctx context.Context, arg CreateTransferParams) (TransferTxResult, error) {
 	// Create an empty result
 	var result TransferTxResult
 
 	// Create and run a new DB transaction
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
-
-		// Create a new transfer record
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		if err := useStepUpChallenge(ctx, q, arg.StepUpChallengeID); err != nil {
 			return err
 		}
 
-		// Create a new entry for the "from" account
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
+		limits, err := store.lockTransferLimits(ctx, q, arg.FromAccountID)
 		if err != nil {
 			return err
 		}
 
-		// Create a new entry for the "to" account
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+		result, err = store.executeTransfer(ctx, q, arg, 0, limits)
+		return err
+	})
+
+	return result, err
+}
+
+// executeTransfer checks the limits of the sender, locked by the caller, then transfers the amount
+// and charges the fee of the transfer, within the transaction of q. The amount is counted against
+// the limits once the transfer succeeded. It fails with ErrInsufficientFunds if the amount and
+// the fee exceed the available balance of the sender plus held, the amount held for this very transfer.
+func (store *SQLStore) executeTransfer(ctx context.Context, q *Queries, arg CreateTransferParams, held int64, limits *senderLimits) (TransferTxResult, error) {
+	if err := limits.check(arg.Amount); err != nil {
+		return TransferTxResult{}, err
+	}
+
+	fee, schedule, err := TransferFee(ctx, q, arg)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	arg.Fee = fee
+
+	result, err := transfer(ctx, q, arg)
+	if err != nil {
+		return result, err
+	}
+
+	if fee != 0 {
+		feeEntry, fromAccount, err := chargeFee(ctx, q, arg.FromAccountID, schedule.RevenueAccountID, fee)
 		if err != nil {
-			return err
+			// the balances are only updated while the accounts are active
+			if errors.Is(err, ErrRecordNotFound) {
+				return result, ErrAccountNotActive
+			}
+			return result, err
 		}
+		result.FeeEntry = &feeEntry
+		result.FromAccount = fromAccount
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+	// the sender is locked by now, the amounts held for other transfers cannot be spent
+	if result.FromAccount.AvailableBalance+held < 0 {
+		return result, fmt.Errorf("transfer of %d with a fee of %d from account [%d] available %d: %w",
+			arg.Amount, fee, arg.FromAccountID, result.FromAccount.AvailableBalance+held+arg.Amount+fee, ErrInsufficientFunds)
+	}
 
-		return err
+	limits.add(arg.Amount)
+	return result, nil
+}
 
+// transfer creates the transfer record and the entries of both accounts, and updates their balances.
+// It fails with ErrAccountNotActive if one of the accounts is frozen or closed.
+func transfer(ctx context.Context, q *Queries, arg CreateTransferParams) (result TransferTxResult, err error) {
+	// Create a new transfer record
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:     arg.FromAccountID,
+		ToAccountID:       arg.ToAccountID,
+		Amount:            arg.Amount,
+		StepUpChallengeID: arg.StepUpChallengeID,
+		Memo:              arg.Memo,
+		Reference:         arg.Reference,
+		Fee:               arg.Fee,
 	})
+	if err != nil {
+		return
+	}
 
-	return result, err
+	// Create a new entry for the "from" account
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	// Create a new entry for the "to" account
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+
+	// the balance of an account is only updated while it is active
+	if errors.Is(err, ErrRecordNotFound) {
+		err = ErrAccountNotActive
+	}
+	return
 }
 
 func addMoney(
@@ -3,13 +3,17 @@ package db
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
-	store := NewStore(testDB)
+	store := NewStore(testPool)
 
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
@@ -105,7 +109,7 @@ func TestTransferTx(t *testing.T) {
 }
 
 func TestTransferTxDeadlock(t *testing.T) {
-	store := NewStore(testDB)
+	store := NewStore(testPool)
 
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
@@ -151,3 +155,77 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestExecTxSerializableRetry(t *testing.T) {
+	n := 10
+	amount := int64(10)
+
+	var retries int32
+	store := NewStore(testPool,
+		WithTxRetries(n, time.Millisecond),
+		WithTxRetryHook(func(code string) {
+			atomic.AddInt32(&retries, 1)
+		}),
+	).(*SQLStore)
+
+	account := createRandomAccount(t)
+
+	// Every transaction reads the balance and writes it back increased, which loses
+	// updates under concurrency unless the conflicting transactions are retried.
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- store.execTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.Serializable}, func(q *Queries) error {
+				current, err := q.GetBankAccount(context.Background(), account.ID)
+				if err != nil {
+					return err
+				}
+
+				_, err = q.UpdateBankAccount(context.Background(), UpdateBankAccountParams{
+					ID:      account.ID,
+					Balance: current.Balance + amount,
+				})
+				return err
+			})
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updated, err := testQueries.GetBankAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+int64(n)*amount, updated.Balance)
+	require.Positive(t, atomic.LoadInt32(&retries))
+}
+
+func TestRetryableTxError(t *testing.T) {
+	code, ok := retryableTxError(&pgconn.PgError{Code: serializationFailureCode})
+	require.True(t, ok)
+	require.Equal(t, serializationFailureCode, code)
+
+	_, ok = retryableTxError(fmt.Errorf("tx err: %w", &pgconn.PgError{Code: deadlockDetectedCode}))
+	require.True(t, ok)
+
+	_, ok = retryableTxError(&pgconn.PgError{Code: UniqueViolation})
+	require.False(t, ok)
+
+	_, ok = retryableTxError(ErrRecordNotFound)
+	require.False(t, ok)
+}
+
+func TestTxRetryBackoff(t *testing.T) {
+	store := &SQLStore{txRetryBaseDelay: 10 * time.Millisecond}
+
+	for attempt, delay := range map[int]time.Duration{
+		1:  10 * time.Millisecond,
+		2:  20 * time.Millisecond,
+		3:  40 * time.Millisecond,
+		20: maxTxRetryDelay,
+	} {
+		backoff := store.txRetryBackoff(attempt)
+		require.GreaterOrEqual(t, backoff, delay/2)
+		require.LessOrEqual(t, backoff, delay)
+	}
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/radugaf/simplebank/db/sqlc"
+
+// tracedDBTX starts a span for every query run through it, named after the Queries method
+// of the query. Inside a transaction the spans are children of the span of the transaction.
+// The errors of the driver are classified on the way out, see classifyError.
+type tracedDBTX struct {
+	db     DBTX
+	txSpan trace.Span
+}
+
+func newTracedDBTX(db DBTX, txSpan trace.Span) DBTX {
+	return &tracedDBTX{db: db, txSpan: txSpan}
+}
+
+func (t *tracedDBTX) startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if t.txSpan != nil {
+		ctx = trace.ContextWithSpan(ctx, t.txSpan)
+	}
+
+	name := queryName(query)
+	return otel.Tracer(tracerName).Start(ctx, "Queries."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(name),
+			semconv.DBStatementKey.String(query),
+		),
+	)
+}
+
+func (t *tracedDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+
+	tag, err := t.db.Exec(ctx, query, args...)
+	recordError(span, err)
+	return tag, classifyError(err)
+}
+
+func (t *tracedDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+
+	rows, err := t.db.Query(ctx, query, args...)
+	recordError(span, err)
+	if err != nil {
+		return rows, classifyError(err)
+	}
+	return &classifiedRows{Rows: rows}, nil
+}
+
+// classifiedRows classifies the error that ended the iteration over the rows
+type classifiedRows struct {
+	pgx.Rows
+}
+
+func (r *classifiedRows) Err() error {
+	return classifyError(r.Rows.Err())
+}
+
+// QueryRow defers the query until the row is scanned, so the span ends on Scan
+func (t *tracedDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	ctx, span := t.startSpan(ctx, query)
+	return &tracedRow{row: t.db.QueryRow(ctx, query, args...), span: span}
+}
+
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	defer r.span.End()
+
+	err := r.row.Scan(dest...)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		recordError(r.span, err)
+	}
+	return classifyError(err)
+}
+
+// queryName returns the name sqlc puts at the top of every query, as in "-- name: GetUser :one"
+func queryName(query string) string {
+	const prefix = "-- name: "
+
+	line := query
+	if i := strings.IndexByte(query, '\n'); i >= 0 {
+		line = query[:i]
+	}
+	if !strings.HasPrefix(line, prefix) {
+		return "query"
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, prefix))
+	if len(fields) == 0 {
+		return "query"
+	}
+	return fields[0]
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetUser", queryName(getUser))
+	require.Equal(t, "AddBankAccountBalance", queryName(addBankAccountBalance))
+	require.Equal(t, "query", queryName("SELECT 1"))
+	require.Equal(t, "query", queryName("-- name: \nSELECT 1"))
+}
+
+func TestTransferTxSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(provider)
+
+	store := NewStore(testPool)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	var txSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "SQLStore.execTx" {
+			txSpan = span
+		}
+	}
+	require.NotNil(t, txSpan)
+
+	var children []string
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == txSpan.SpanContext().SpanID() {
+			children = append(children, span.Name())
+		}
+	}
+	require.ElementsMatch(t, []string{
+		"Queries.CreateTransfer",
+		"Queries.CreateEntry",
+		"Queries.CreateEntry",
+		"Queries.AddBankAccountBalance",
+		"Queries.AddBankAccountBalance",
+	}, children)
+}
@@ -0,0 +1,8 @@
+package db
+
+// Directions of a transfer from the point of view of the owner of the
+// accounts, to filter ListOwnerTransfers with
+const (
+	TransferDirectionIn  = "in"
+	TransferDirectionOut = "out"
+)
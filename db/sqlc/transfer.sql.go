@@ -1,26 +1,41 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 // source: transfer.sql
 
 package db
 
 import (
 	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers (from_account_id, to_account_id, amount) VALUES ($1, $2, $3) RETURNING id, from_account_id, to_account_id, amount, created_at
+INSERT INTO transfers (from_account_id, to_account_id, amount, step_up_challenge_id, memo, reference, fee) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, from_account_id, to_account_id, amount, created_at, step_up_challenge_id, memo, reference, fee
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"fromAccountID"`
-	ToAccountID   int64 `json:"toAccountID"`
-	Amount        int64 `json:"amount"`
+	FromAccountID     int64         `json:"fromAccountID"`
+	ToAccountID       int64         `json:"toAccountID"`
+	Amount            int64         `json:"amount"`
+	StepUpChallengeID uuid.NullUUID `json:"stepUpChallengeID"`
+	Memo              string        `json:"memo"`
+	Reference         string        `json:"reference"`
+	Fee               int64         `json:"fee"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.queryRow(ctx, q.createTransferStmt, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.StepUpChallengeID,
+		arg.Memo,
+		arg.Reference,
+		arg.Fee,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -28,16 +43,51 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.StepUpChallengeID,
+		&i.Memo,
+		&i.Reference,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const getOwnerTransfer = `-- name: GetOwnerTransfer :one
+SELECT transfers.id, transfers.from_account_id, transfers.to_account_id, transfers.amount, transfers.created_at, transfers.step_up_challenge_id, transfers.memo, transfers.reference, transfers.fee FROM transfers
+JOIN bank_accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN bank_accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE transfers.id = $1
+  AND (from_account.owner = $2 OR to_account.owner = $2)
+LIMIT 1
+`
+
+type GetOwnerTransferParams struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+}
+
+func (q *Queries) GetOwnerTransfer(ctx context.Context, arg GetOwnerTransferParams) (Transfer, error) {
+	row := q.db.QueryRow(ctx, getOwnerTransfer, arg.ID, arg.Owner)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.StepUpChallengeID,
+		&i.Memo,
+		&i.Reference,
+		&i.Fee,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers WHERE id = $1 LIMIT 1
+SELECT id, from_account_id, to_account_id, amount, created_at, step_up_challenge_id, memo, reference, fee FROM transfers WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
-	row := q.queryRow(ctx, q.getTransferStmt, getTransfer, id)
+	row := q.db.QueryRow(ctx, getTransfer, id)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -45,27 +95,131 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.StepUpChallengeID,
+		&i.Memo,
+		&i.Reference,
+		&i.Fee,
 	)
 	return i, err
 }
 
+const listOwnerTransfers = `-- name: ListOwnerTransfers :many
+SELECT transfers.id, transfers.from_account_id, transfers.to_account_id, transfers.amount, transfers.created_at, transfers.step_up_challenge_id, transfers.memo, transfers.reference, transfers.fee FROM transfers
+JOIN bank_accounts AS from_account ON from_account.id = transfers.from_account_id
+JOIN bank_accounts AS to_account ON to_account.id = transfers.to_account_id
+WHERE (
+    (from_account.owner = $1
+      AND ($2::text IS NULL OR $2 = 'out')
+      AND ($3::bigint IS NULL OR from_account.id = $3))
+    OR (to_account.owner = $1
+      AND ($2::text IS NULL OR $2 = 'in')
+      AND ($3::bigint IS NULL OR to_account.id = $3))
+  )
+  AND ($4::varchar IS NULL OR from_account.currency = $4)
+  AND ($5::timestamptz IS NULL OR transfers.created_at >= $5)
+  AND ($6::timestamptz IS NULL OR transfers.created_at < $6)
+  AND ($7::bigint IS NULL OR transfers.amount >= $7)
+  AND ($8::bigint IS NULL OR transfers.amount <= $8)
+  AND ($9::timestamptz IS NULL
+    OR (transfers.created_at, transfers.id) < ($9, $10::bigint))
+ORDER BY transfers.created_at DESC, transfers.id DESC
+LIMIT $11
+`
+
+type ListOwnerTransfersParams struct {
+	Owner           string             `json:"owner"`
+	Direction       pgtype.Text        `json:"direction"`
+	AccountID       pgtype.Int8        `json:"accountID"`
+	Currency        pgtype.Text        `json:"currency"`
+	FromTime        pgtype.Timestamptz `json:"fromTime"`
+	ToTime          pgtype.Timestamptz `json:"toTime"`
+	MinAmount       pgtype.Int8        `json:"minAmount"`
+	MaxAmount       pgtype.Int8        `json:"maxAmount"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        int64              `json:"cursorID"`
+	Limit           int32              `json:"limit"`
+}
+
+func (q *Queries) ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listOwnerTransfers,
+		arg.Owner,
+		arg.Direction,
+		arg.AccountID,
+		arg.Currency,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.StepUpChallengeID,
+			&i.Memo,
+			&i.Reference,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers WHERE  from_account_id = $1 OR to_account_id = $2 ORDER BY id LIMIT $3 OFFSET $4
+SELECT id, from_account_id, to_account_id, amount, created_at, step_up_challenge_id, memo, reference, fee FROM transfers
+WHERE (from_account_id = $1 OR to_account_id = $1)
+  AND ($2::bigint IS NULL
+    OR from_account_id = $2 OR to_account_id = $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::bigint IS NULL OR amount >= $5)
+  AND ($6::bigint IS NULL OR amount <= $6)
+  AND ($7::timestamptz IS NULL
+    OR (created_at, id) < ($7, $8::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"fromAccountID"`
-	ToAccountID   int64 `json:"toAccountID"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	AccountID       int64              `json:"accountID"`
+	CounterpartyID  pgtype.Int8        `json:"counterpartyID"`
+	FromTime        pgtype.Timestamptz `json:"fromTime"`
+	ToTime          pgtype.Timestamptz `json:"toTime"`
+	MinAmount       pgtype.Int8        `json:"minAmount"`
+	MaxAmount       pgtype.Int8        `json:"maxAmount"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        int64              `json:"cursorID"`
+	Limit           int32              `json:"limit"`
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.query(ctx, q.listTransfersStmt, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
+	rows, err := q.db.Query(ctx, listTransfers,
+		arg.AccountID,
+		arg.CounterpartyID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CursorCreatedAt,
+		arg.CursorID,
 		arg.Limit,
-		arg.Offset,
 	)
 	if err != nil {
 		return nil, err
@@ -80,14 +234,15 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.StepUpChallengeID,
+			&i.Memo,
+			&i.Reference,
+			&i.Fee,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,465 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Modes of a transfer batch. An atomic batch transfers all of its items or none,
+// a per item batch transfers every item that can be and reports the others as failed.
+const (
+	TransferBatchModeAtomic  = "atomic"
+	TransferBatchModePerItem = "per_item"
+)
+
+// Statuses of a transfer batch
+const (
+	TransferBatchStatusProcessing         = "processing"
+	TransferBatchStatusCompleted          = "completed"
+	TransferBatchStatusPartiallyCompleted = "partially_completed"
+	TransferBatchStatusFailed             = "failed"
+)
+
+// Statuses of an item of a transfer batch
+const (
+	TransferBatchItemStatusPending   = "pending"
+	TransferBatchItemStatusSucceeded = "succeeded"
+	TransferBatchItemStatusFailed    = "failed"
+)
+
+// MaxTransferBatchItems is the largest number of transfers of a batch
+const MaxTransferBatchItems = 1000
+
+// ErrTransferBatchTooLarge is returned when the total amount of a batch does not fit in an amount
+var ErrTransferBatchTooLarge = errors.New("total amount of the transfer batch is too large")
+
+// ErrInvalidTransferBatch is returned when items of a batch cannot be transferred, before any of them is
+var ErrInvalidTransferBatch = errors.New("transfer batch has invalid items")
+
+// TransferBatchItemError is the reason an item of a batch is invalid
+type TransferBatchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// TransferBatchValidationError lists the invalid items of a batch
+type TransferBatchValidationError struct {
+	Items []TransferBatchItemError `json:"items"`
+}
+
+func (e *TransferBatchValidationError) Error() string {
+	reasons := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		reasons[i] = fmt.Sprintf("item %d: %s", item.Index, item.Error)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidTransferBatch, strings.Join(reasons, "; "))
+}
+
+func (e *TransferBatchValidationError) Unwrap() error {
+	return ErrInvalidTransferBatch
+}
+
+// TransferBatchItemParams is a transfer of a batch. The recipient account is given
+// by its ID, by its normalized account number or by a payee of the owner of the batch.
+type TransferBatchItemParams struct {
+	ToAccountID     int64
+	ToAccountNumber string
+	PayeeID         int64
+	Amount          int64
+	Memo            string
+	Reference       string
+}
+
+// TransferBatchTxParams contains the input parameters of TransferBatchTx
+type TransferBatchTxParams struct {
+	Owner         string
+	FromAccountID int64
+	Currency      string
+	Mode          string
+	Items         []TransferBatchItemParams
+	// StepUpChallengeID is the verified challenge issued for the hash of the batch, if it needs one
+	StepUpChallengeID uuid.NullUUID
+}
+
+// TotalAmount returns the sum of the amounts of the items, false if it overflows
+func (arg TransferBatchTxParams) TotalAmount() (int64, bool) {
+	var total int64
+	for _, item := range arg.Items {
+		if item.Amount > math.MaxInt64-total {
+			return 0, false
+		}
+		total += item.Amount
+	}
+	return total, true
+}
+
+// Hash identifies the batch for a step-up challenge. A batch above the step-up threshold is confirmed
+// as a whole by a challenge bound to its hash, as checking the items one by one would let a stolen
+// token move up to MaxTransferBatchItems times the threshold in a single call. The hash covers the
+// from account, the currency, the mode, every item in order and the total, so the challenge cannot
+// confirm any other batch.
+func (arg TransferBatchTxParams) Hash() string {
+	total, _ := arg.TotalAmount()
+	data, _ := json.Marshal(struct {
+		FromAccountID int64
+		Currency      string
+		Mode          string
+		Items         []TransferBatchItemParams
+		Total         int64
+	}{arg.FromAccountID, arg.Currency, arg.Mode, arg.Items, total})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// TransferBatchTxResult is the result of TransferBatchTx
+type TransferBatchTxResult struct {
+	Batch TransferBatch       `json:"batch"`
+	Items []TransferBatchItem `json:"items"`
+}
+
+// batchItemFailure stops an atomic batch on the item that failed
+type batchItemFailure struct {
+	index int
+	err   error
+}
+
+func (e *batchItemFailure) Error() string {
+	return fmt.Sprintf("item %d: %s", e.index, e.err)
+}
+
+func (e *batchItemFailure) Unwrap() error {
+	return e.err
+}
+
+// TransferBatchTx transfers every item of the batch from the from account, which must belong to
+// the owner of the batch. All the recipients are checked up front, it fails with a
+// TransferBatchValidationError before storing anything if one of them cannot receive the transfer.
+// The batch is stored before it runs, in a single transaction holding the lock of the sender and
+// of all the accounts of the batch throughout. An atomic batch is rolled back entirely when an item fails, the
+// other items run in a savepoint each so that a failure only undoes its own transfer. The limits
+// and the fees apply to every item like to a single transfer. The outcome, also when the batch
+// failed, is stored and returned as the batch and its items.
+func (store *SQLStore) TransferBatchTx(ctx context.Context, arg TransferBatchTxParams) (TransferBatchTxResult, error) {
+	toAccountIDs, err := store.validateTransferBatch(ctx, arg)
+	if err != nil {
+		return TransferBatchTxResult{}, err
+	}
+
+	batch, err := store.createTransferBatch(ctx, arg, toAccountIDs)
+	if err != nil {
+		return TransferBatchTxResult{}, err
+	}
+
+	var result TransferBatchTxResult
+	err = store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		var err error
+		result, err = store.runTransferBatch(ctx, q, batch)
+		return err
+	})
+
+	var failure *batchItemFailure
+	if errors.As(err, &failure) {
+		return store.failTransferBatch(ctx, batch, failure.Error(), failure)
+	}
+	if err != nil {
+		// the batch is reported as failed even when it could not run at all
+		if _, failErr := store.failTransferBatch(ctx, batch, err.Error(), nil); failErr != nil {
+			return result, fmt.Errorf("run batch err: %v, fail batch err: %w", err, failErr)
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// validateTransferBatch resolves the recipient account of every item,
+// and checks that it is active, in the currency of the batch and not the from account
+func (store *SQLStore) validateTransferBatch(ctx context.Context, arg TransferBatchTxParams) ([]int64, error) {
+	var invalid []TransferBatchItemError
+	toAccountIDs := make([]int64, len(arg.Items))
+
+	for i, item := range arg.Items {
+		account, reason, err := store.batchRecipient(ctx, arg.Owner, item)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case reason != "":
+		case account.ID == arg.FromAccountID:
+			reason = "cannot transfer to the from account"
+		case account.Currency != arg.Currency:
+			reason = fmt.Sprintf("recipient account currency mismatch: %s vs %s", account.Currency, arg.Currency)
+		case account.Status != AccountStatusActive:
+			reason = fmt.Sprintf("recipient account is %s", account.Status)
+		}
+
+		if reason != "" {
+			invalid = append(invalid, TransferBatchItemError{Index: i, Error: reason})
+			continue
+		}
+		toAccountIDs[i] = account.ID
+	}
+
+	if invalid != nil {
+		return nil, &TransferBatchValidationError{Items: invalid}
+	}
+	return toAccountIDs, nil
+}
+
+// batchRecipient gets the recipient account of an item, or the reason it cannot be found
+func (store *SQLStore) batchRecipient(ctx context.Context, owner string, item TransferBatchItemParams) (BankAccount, string, error) {
+	accountNumber := item.ToAccountNumber
+
+	if item.PayeeID != 0 {
+		payee, err := store.GetPayee(ctx, item.PayeeID)
+		if errors.Is(err, ErrRecordNotFound) || (err == nil && payee.Payee.Owner != owner) {
+			return BankAccount{}, "payee not found", nil
+		}
+		if err != nil {
+			return BankAccount{}, "", err
+		}
+		if !payee.Payee.ConfirmedAt.Valid {
+			return BankAccount{}, "payee is not confirmed", nil
+		}
+		accountNumber = payee.Payee.AccountNumber
+	}
+
+	var account BankAccount
+	var err error
+	if accountNumber != "" {
+		account, err = store.GetBankAccountByNumber(ctx, accountNumber)
+	} else {
+		account, err = store.GetBankAccount(ctx, item.ToAccountID)
+	}
+	if errors.Is(err, ErrRecordNotFound) {
+		return account, "recipient account not found", nil
+	}
+	return account, "", err
+}
+
+// createTransferBatch stores the batch and its pending items
+func (store *SQLStore) createTransferBatch(ctx context.Context, arg TransferBatchTxParams, toAccountIDs []int64) (TransferBatch, error) {
+	totalAmount, ok := arg.TotalAmount()
+	if !ok {
+		return TransferBatch{}, ErrTransferBatchTooLarge
+	}
+
+	var batch TransferBatch
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		if arg.StepUpChallengeID.Valid {
+			_, err := q.ConsumeBatchStepUpChallenge(ctx, ConsumeBatchStepUpChallengeParams{
+				ID:            arg.StepUpChallengeID.UUID,
+				Username:      arg.Owner,
+				FromAccountID: arg.FromAccountID,
+				BatchHash:     arg.Hash(),
+				Amount:        totalAmount,
+				Currency:      arg.Currency,
+			})
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrStepUpChallengeUnusable
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		batch, err = q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+			Owner:             arg.Owner,
+			FromAccountID:     arg.FromAccountID,
+			Currency:          arg.Currency,
+			Mode:              arg.Mode,
+			ItemCount:         int32(len(arg.Items)),
+			TotalAmount:       totalAmount,
+			StepUpChallengeID: arg.StepUpChallengeID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, item := range arg.Items {
+			_, err := q.CreateTransferBatchItem(ctx, CreateTransferBatchItemParams{
+				BatchID:     batch.ID,
+				Position:    int32(i),
+				ToAccountID: toAccountIDs[i],
+				Amount:      item.Amount,
+				Memo:        item.Memo,
+				Reference:   item.Reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return batch, err
+}
+
+// runTransferBatch transfers the items of the batch within the transaction of q
+func (store *SQLStore) runTransferBatch(ctx context.Context, q *Queries, batch TransferBatch) (TransferBatchTxResult, error) {
+	result := TransferBatchTxResult{Batch: batch}
+
+	// the sender is locked once, before the first item, and its totals are only summed once:
+	// every item counts its amount against the limits as it succeeds
+	limits, err := store.lockTransferLimits(ctx, q, batch.FromAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	items, err := q.ListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		return result, err
+	}
+
+	if err := lockBatchAccounts(ctx, q, batch.FromAccountID, items); err != nil {
+		return result, err
+	}
+
+	atomic := batch.Mode == TransferBatchModeAtomic
+	var succeeded, failed int32
+
+	for i, item := range items {
+		if !atomic {
+			if _, err := q.db.Exec(ctx, "SAVEPOINT batch_item"); err != nil {
+				return result, err
+			}
+		}
+
+		transfer, transferErr := store.executeTransfer(ctx, q, CreateTransferParams{
+			FromAccountID: batch.FromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+			Memo:          item.Memo,
+			Reference:     item.Reference,
+		}, 0, limits)
+		if transferErr != nil {
+			// a serialization failure or a deadlock runs the whole batch again
+			if _, retryable := retryableTxError(transferErr); retryable {
+				return result, transferErr
+			}
+			if atomic {
+				return result, &batchItemFailure{index: i, err: transferErr}
+			}
+
+			if _, err := q.db.Exec(ctx, "ROLLBACK TO SAVEPOINT batch_item"); err != nil {
+				return result, err
+			}
+		}
+		if !atomic {
+			if _, err := q.db.Exec(ctx, "RELEASE SAVEPOINT batch_item"); err != nil {
+				return result, err
+			}
+		}
+
+		arg := UpdateTransferBatchItemParams{
+			Status:   TransferBatchItemStatusSucceeded,
+			BatchID:  batch.ID,
+			Position: item.Position,
+		}
+		if transferErr != nil {
+			arg.Status = TransferBatchItemStatusFailed
+			arg.Error = transferErr.Error()
+			failed++
+		} else {
+			arg.TransferID = pgtype.Int8{Int64: transfer.Transfer.ID, Valid: true}
+			succeeded++
+		}
+
+		items[i], err = q.UpdateTransferBatchItem(ctx, arg)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	status := TransferBatchStatusCompleted
+	switch {
+	case succeeded == 0:
+		status = TransferBatchStatusFailed
+	case failed > 0:
+		status = TransferBatchStatusPartiallyCompleted
+	}
+
+	result.Batch, err = q.FinishTransferBatch(ctx, FinishTransferBatchParams{
+		ID:             batch.ID,
+		Status:         status,
+		SucceededCount: succeeded,
+		FailedCount:    failed,
+	})
+	result.Items = items
+	return result, err
+}
+
+// lockBatchAccounts locks the sender account and the recipient accounts of the items in the order of
+// their IDs, the order in which TransferTx locks the accounts of a transfer, so that the batch does
+// not deadlock with the transfers running concurrently
+func lockBatchAccounts(ctx context.Context, q *Queries, fromAccountID int64, items []TransferBatchItem) error {
+	accountIDs := make([]int64, 0, len(items)+1)
+	accountIDs = append(accountIDs, fromAccountID)
+	for _, item := range items {
+		accountIDs = append(accountIDs, item.ToAccountID)
+	}
+	sort.Slice(accountIDs, func(i, j int) bool { return accountIDs[i] < accountIDs[j] })
+
+	for i, accountID := range accountIDs {
+		if i > 0 && accountID == accountIDs[i-1] {
+			continue
+		}
+		if _, err := q.GetBankAccountForUpdate(ctx, accountID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failTransferBatch reports the batch and its pending items as failed, for the reason.
+// The item that stopped an atomic batch, if any, keeps the error it failed with.
+func (store *SQLStore) failTransferBatch(ctx context.Context, batch TransferBatch, reason string, failure *batchItemFailure) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+
+	err := store.execTx(ctx, pgx.TxOptions{}, func(q *Queries) error {
+		if failure != nil {
+			_, err := q.UpdateTransferBatchItem(ctx, UpdateTransferBatchItemParams{
+				Status:   TransferBatchItemStatusFailed,
+				Error:    failure.err.Error(),
+				BatchID:  batch.ID,
+				Position: int32(failure.index),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err := q.FailPendingTransferBatchItems(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Batch, err = q.FinishTransferBatch(ctx, FinishTransferBatchParams{
+			ID:          batch.ID,
+			Status:      TransferBatchStatusFailed,
+			FailedCount: batch.ItemCount,
+			Error:       reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Items, err = q.ListTransferBatchItems(ctx, batch.ID)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,288 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: transfer_batch.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createTransferBatch = `-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (owner, from_account_id, currency, mode, item_count, total_amount, step_up_challenge_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, owner, from_account_id, currency, mode, status, item_count, total_amount, succeeded_count, failed_count, error, created_at, completed_at, step_up_challenge_id
+`
+
+type CreateTransferBatchParams struct {
+	Owner             string        `json:"owner"`
+	FromAccountID     int64         `json:"fromAccountID"`
+	Currency          string        `json:"currency"`
+	Mode              string        `json:"mode"`
+	ItemCount         int32         `json:"itemCount"`
+	TotalAmount       int64         `json:"totalAmount"`
+	StepUpChallengeID uuid.NullUUID `json:"stepUpChallengeID"`
+}
+
+func (q *Queries) CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error) {
+	row := q.db.QueryRow(ctx, createTransferBatch,
+		arg.Owner,
+		arg.FromAccountID,
+		arg.Currency,
+		arg.Mode,
+		arg.ItemCount,
+		arg.TotalAmount,
+		arg.StepUpChallengeID,
+	)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.Currency,
+		&i.Mode,
+		&i.Status,
+		&i.ItemCount,
+		&i.TotalAmount,
+		&i.SucceededCount,
+		&i.FailedCount,
+		&i.Error,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.StepUpChallengeID,
+	)
+	return i, err
+}
+
+const createTransferBatchItem = `-- name: CreateTransferBatchItem :one
+INSERT INTO transfer_batch_items (batch_id, position, to_account_id, amount, memo, reference)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING batch_id, position, to_account_id, amount, memo, reference, status, transfer_id, error
+`
+
+type CreateTransferBatchItemParams struct {
+	BatchID     int64  `json:"batchID"`
+	Position    int32  `json:"position"`
+	ToAccountID int64  `json:"toAccountID"`
+	Amount      int64  `json:"amount"`
+	Memo        string `json:"memo"`
+	Reference   string `json:"reference"`
+}
+
+func (q *Queries) CreateTransferBatchItem(ctx context.Context, arg CreateTransferBatchItemParams) (TransferBatchItem, error) {
+	row := q.db.QueryRow(ctx, createTransferBatchItem,
+		arg.BatchID,
+		arg.Position,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Memo,
+		arg.Reference,
+	)
+	var i TransferBatchItem
+	err := row.Scan(
+		&i.BatchID,
+		&i.Position,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Memo,
+		&i.Reference,
+		&i.Status,
+		&i.TransferID,
+		&i.Error,
+	)
+	return i, err
+}
+
+const failPendingTransferBatchItems = `-- name: FailPendingTransferBatchItems :exec
+UPDATE transfer_batch_items SET status = 'failed'
+WHERE batch_id = $1 AND status = 'pending'
+`
+
+func (q *Queries) FailPendingTransferBatchItems(ctx context.Context, batchID int64) error {
+	_, err := q.db.Exec(ctx, failPendingTransferBatchItems, batchID)
+	return err
+}
+
+const finishTransferBatch = `-- name: FinishTransferBatch :one
+UPDATE transfer_batches
+SET status = $1,
+    succeeded_count = $2,
+    failed_count = $3,
+    error = $4,
+    completed_at = now()
+WHERE id = $5
+RETURNING id, owner, from_account_id, currency, mode, status, item_count, total_amount, succeeded_count, failed_count, error, created_at, completed_at, step_up_challenge_id
+`
+
+type FinishTransferBatchParams struct {
+	Status         string `json:"status"`
+	SucceededCount int32  `json:"succeededCount"`
+	FailedCount    int32  `json:"failedCount"`
+	Error          string `json:"error"`
+	ID             int64  `json:"id"`
+}
+
+func (q *Queries) FinishTransferBatch(ctx context.Context, arg FinishTransferBatchParams) (TransferBatch, error) {
+	row := q.db.QueryRow(ctx, finishTransferBatch,
+		arg.Status,
+		arg.SucceededCount,
+		arg.FailedCount,
+		arg.Error,
+		arg.ID,
+	)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.Currency,
+		&i.Mode,
+		&i.Status,
+		&i.ItemCount,
+		&i.TotalAmount,
+		&i.SucceededCount,
+		&i.FailedCount,
+		&i.Error,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.StepUpChallengeID,
+	)
+	return i, err
+}
+
+const getTransferBatch = `-- name: GetTransferBatch :one
+SELECT id, owner, from_account_id, currency, mode, status, item_count, total_amount, succeeded_count, failed_count, error, created_at, completed_at, step_up_challenge_id FROM transfer_batches WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error) {
+	row := q.db.QueryRow(ctx, getTransferBatch, id)
+	var i TransferBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.Currency,
+		&i.Mode,
+		&i.Status,
+		&i.ItemCount,
+		&i.TotalAmount,
+		&i.SucceededCount,
+		&i.FailedCount,
+		&i.Error,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.StepUpChallengeID,
+	)
+	return i, err
+}
+
+const listTransferBatchItems = `-- name: ListTransferBatchItems :many
+SELECT batch_id, position, to_account_id, amount, memo, reference, status, transfer_id, error FROM transfer_batch_items WHERE batch_id = $1 ORDER BY position
+`
+
+func (q *Queries) ListTransferBatchItems(ctx context.Context, batchID int64) ([]TransferBatchItem, error) {
+	rows, err := q.db.Query(ctx, listTransferBatchItems, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferBatchItem{}
+	for rows.Next() {
+		var i TransferBatchItem
+		if err := rows.Scan(
+			&i.BatchID,
+			&i.Position,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Memo,
+			&i.Reference,
+			&i.Status,
+			&i.TransferID,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferBatchRecipients = `-- name: ListTransferBatchRecipients :many
+SELECT DISTINCT bank_accounts.id, bank_accounts.account_number
+FROM transfer_batch_items
+JOIN bank_accounts ON bank_accounts.id = transfer_batch_items.to_account_id
+WHERE transfer_batch_items.batch_id = $1
+`
+
+type ListTransferBatchRecipientsRow struct {
+	ID            int64  `json:"id"`
+	AccountNumber string `json:"accountNumber"`
+}
+
+// The account numbers of the recipients of a batch, which the items are reported with.
+func (q *Queries) ListTransferBatchRecipients(ctx context.Context, batchID int64) ([]ListTransferBatchRecipientsRow, error) {
+	rows, err := q.db.Query(ctx, listTransferBatchRecipients, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTransferBatchRecipientsRow{}
+	for rows.Next() {
+		var i ListTransferBatchRecipientsRow
+		if err := rows.Scan(&i.ID, &i.AccountNumber); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTransferBatchItem = `-- name: UpdateTransferBatchItem :one
+UPDATE transfer_batch_items
+SET status = $1,
+    transfer_id = $2,
+    error = $3
+WHERE batch_id = $4 AND position = $5
+RETURNING batch_id, position, to_account_id, amount, memo, reference, status, transfer_id, error
+`
+
+type UpdateTransferBatchItemParams struct {
+	Status     string      `json:"status"`
+	TransferID pgtype.Int8 `json:"transferID"`
+	Error      string      `json:"error"`
+	BatchID    int64       `json:"batchID"`
+	Position   int32       `json:"position"`
+}
+
+func (q *Queries) UpdateTransferBatchItem(ctx context.Context, arg UpdateTransferBatchItemParams) (TransferBatchItem, error) {
+	row := q.db.QueryRow(ctx, updateTransferBatchItem,
+		arg.Status,
+		arg.TransferID,
+		arg.Error,
+		arg.BatchID,
+		arg.Position,
+	)
+	var i TransferBatchItem
+	err := row.Scan(
+		&i.BatchID,
+		&i.Position,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Memo,
+		&i.Reference,
+		&i.Status,
+		&i.TransferID,
+		&i.Error,
+	)
+	return i, err
+}
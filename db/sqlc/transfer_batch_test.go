@@ -0,0 +1,285 @@
+package db
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+// batchItems returns the items of a batch transferring each amount to the account
+func batchItems(toAccountID int64, amounts ...int64) []TransferBatchItemParams {
+	items := make([]TransferBatchItemParams, len(amounts))
+	for i, amount := range amounts {
+		items[i] = TransferBatchItemParams{ToAccountID: toAccountID, Amount: amount}
+	}
+	return items
+}
+
+func TestTransferBatchTxPerItem(t *testing.T) {
+	store := NewStore(testPool, WithTransferLimits(TransferLimits{
+		PerTransfer: map[string]int64{tools.USD: 100},
+	}))
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.USD, 1000)
+	account2 := createRandomAccountOf(t, createRandomUser(t).Username, tools.USD)
+
+	// the second item is over the per transfer limit, only its own transfer is undone
+	result, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         owner.Username,
+		FromAccountID: account1.ID,
+		Currency:      tools.USD,
+		Mode:          TransferBatchModePerItem,
+		Items:         batchItems(account2.ID, 50, 150, 30),
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferBatchStatusPartiallyCompleted, result.Batch.Status)
+	require.Equal(t, int32(3), result.Batch.ItemCount)
+	require.Equal(t, int64(230), result.Batch.TotalAmount)
+	require.Equal(t, int32(2), result.Batch.SucceededCount)
+	require.Equal(t, int32(1), result.Batch.FailedCount)
+	require.True(t, result.Batch.CompletedAt.Valid)
+
+	require.Len(t, result.Items, 3)
+	require.Equal(t, TransferBatchItemStatusSucceeded, result.Items[0].Status)
+	require.True(t, result.Items[0].TransferID.Valid)
+	require.Equal(t, TransferBatchItemStatusFailed, result.Items[1].Status)
+	require.False(t, result.Items[1].TransferID.Valid)
+	require.NotEmpty(t, result.Items[1].Error)
+	require.Equal(t, TransferBatchItemStatusSucceeded, result.Items[2].Status)
+
+	updated1, err := testQueries.GetBankAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(920), updated1.Balance)
+
+	updated2, err := testQueries.GetBankAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+80, updated2.Balance)
+
+	// the stored batch can be fetched again
+	batch, err := testQueries.GetTransferBatch(context.Background(), result.Batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Batch, batch)
+
+	recipients, err := testQueries.ListTransferBatchRecipients(context.Background(), result.Batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, []ListTransferBatchRecipientsRow{{ID: account2.ID, AccountNumber: account2.AccountNumber}}, recipients)
+}
+
+func TestTransferBatchTxAtomic(t *testing.T) {
+	store := NewStore(testPool, WithTransferLimits(TransferLimits{
+		PerTransfer: map[string]int64{tools.USD: 100},
+	}))
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.USD, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.USD)
+
+	// the second item fails, so none of them is transferred
+	result, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         owner.Username,
+		FromAccountID: account1.ID,
+		Currency:      tools.USD,
+		Mode:          TransferBatchModeAtomic,
+		Items:         batchItems(account2.ID, 50, 150, 30),
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferBatchStatusFailed, result.Batch.Status)
+	require.Equal(t, int32(0), result.Batch.SucceededCount)
+	require.Equal(t, int32(3), result.Batch.FailedCount)
+	require.Contains(t, result.Batch.Error, "item 1")
+
+	require.Len(t, result.Items, 3)
+	for _, item := range result.Items {
+		require.Equal(t, TransferBatchItemStatusFailed, item.Status)
+		require.False(t, item.TransferID.Valid)
+	}
+
+	updated1, err := testQueries.GetBankAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), updated1.Balance)
+
+	// the same batch within the limit goes through entirely
+	result, err = store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         owner.Username,
+		FromAccountID: account1.ID,
+		Currency:      tools.USD,
+		Mode:          TransferBatchModeAtomic,
+		Items:         batchItems(account2.ID, 50, 100, 30),
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferBatchStatusCompleted, result.Batch.Status)
+	require.Equal(t, int32(3), result.Batch.SucceededCount)
+
+	updated1, err = testQueries.GetBankAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(820), updated1.Balance)
+}
+
+func TestTransferBatchTxDailyLimit(t *testing.T) {
+	store := NewStore(testPool, WithTransferLimits(TransferLimits{
+		DailyAccount: map[string]int64{tools.USD: 300},
+	}))
+
+	owner := createRandomUser(t)
+	// the recipient has the lower ID, it is locked before the sender
+	account2 := createRandomAccountOf(t, createRandomUser(t).Username, tools.USD)
+	account1 := createFundedAccountOf(t, owner.Username, tools.USD, 1000)
+
+	// the items count against the daily limit as they run, the second one would exceed it
+	result, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         owner.Username,
+		FromAccountID: account1.ID,
+		Currency:      tools.USD,
+		Mode:          TransferBatchModePerItem,
+		Items:         batchItems(account2.ID, 200, 150, 100),
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferBatchStatusPartiallyCompleted, result.Batch.Status)
+	require.Equal(t, TransferBatchItemStatusSucceeded, result.Items[0].Status)
+	require.Equal(t, TransferBatchItemStatusFailed, result.Items[1].Status)
+	require.Contains(t, result.Items[1].Error, TransferLimitDailyAccount)
+	require.Equal(t, TransferBatchItemStatusSucceeded, result.Items[2].Status)
+
+	// the next transfer sees the totals of the batch
+	_, err = store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, TransferLimitDailyAccount, limitErr.Limit)
+	require.Zero(t, limitErr.Remaining)
+}
+
+func TestTransferBatchTxInvalidItems(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.USD, 1000)
+	account2 := createRandomAccountOf(t, owner.Username, tools.USD)
+	other := createRandomAccountOf(t, owner.Username, tools.EUR)
+
+	items := batchItems(account2.ID, 10, 10, 10, 10)
+	items[1].ToAccountID = other.ID
+	items[2].ToAccountID = account1.ID
+	items[3] = TransferBatchItemParams{ToAccountNumber: "0000000000", Amount: 10}
+
+	_, err := store.TransferBatchTx(context.Background(), TransferBatchTxParams{
+		Owner:         owner.Username,
+		FromAccountID: account1.ID,
+		Currency:      tools.USD,
+		Mode:          TransferBatchModePerItem,
+		Items:         items,
+	})
+	require.ErrorIs(t, err, ErrInvalidTransferBatch)
+
+	var validationErr *TransferBatchValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Items, 3)
+	require.Equal(t, 1, validationErr.Items[0].Index)
+	require.Equal(t, 2, validationErr.Items[1].Index)
+	require.Equal(t, 3, validationErr.Items[2].Index)
+
+	// nothing was transferred
+	updated1, err := testQueries.GetBankAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), updated1.Balance)
+}
+
+func TestTransferBatchTxParamsTotalAmount(t *testing.T) {
+	total, ok := TransferBatchTxParams{Items: batchItems(1, 600, 401)}.TotalAmount()
+	require.True(t, ok)
+	require.Equal(t, int64(1001), total)
+
+	_, ok = TransferBatchTxParams{Items: batchItems(1, math.MaxInt64, 1)}.TotalAmount()
+	require.False(t, ok)
+}
+
+func TestTransferBatchTxParamsHash(t *testing.T) {
+	arg := TransferBatchTxParams{
+		Owner:         "owner",
+		FromAccountID: 1,
+		Currency:      tools.USD,
+		Mode:          TransferBatchModeAtomic,
+		Items:         batchItems(2, 600, 401),
+	}
+	require.Len(t, arg.Hash(), 64)
+
+	// the challenge ID is not part of the batch it confirms
+	confirmed := arg
+	confirmed.StepUpChallengeID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	require.Equal(t, arg.Hash(), confirmed.Hash())
+
+	reordered := arg
+	reordered.Items = batchItems(2, 401, 600)
+	require.NotEqual(t, arg.Hash(), reordered.Hash())
+
+	otherRecipient := arg
+	otherRecipient.Items = batchItems(3, 600, 401)
+	require.NotEqual(t, arg.Hash(), otherRecipient.Hash())
+
+	otherMode := arg
+	otherMode.Mode = TransferBatchModePerItem
+	require.NotEqual(t, arg.Hash(), otherMode.Hash())
+}
+
+func TestTransferBatchTxConsumesStepUpChallenge(t *testing.T) {
+	store := NewStore(testPool)
+
+	owner := createRandomUser(t)
+	account1 := createFundedAccountOf(t, owner.Username, tools.USD, 1000)
+	account2 := createRandomAccountOf(t, createRandomUser(t).Username, tools.USD)
+
+	arg := TransferBatchTxParams{
+		Owner:         owner.Username,
+		FromAccountID: account1.ID,
+		Currency:      tools.USD,
+		Mode:          TransferBatchModeAtomic,
+		Items:         batchItems(account2.ID, 30, 20),
+	}
+
+	challenge, err := testQueries.CreateStepUpChallenge(context.Background(), CreateStepUpChallengeParams{
+		ID:            uuid.New(),
+		Username:      owner.Username,
+		FromAccountID: account1.ID,
+		BatchHash:     pgtype.Text{String: arg.Hash(), Valid: true},
+		Amount:        50,
+		Currency:      tools.USD,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	arg.StepUpChallengeID = uuid.NullUUID{UUID: challenge.ID, Valid: true}
+
+	// an unverified challenge cannot authorize the batch
+	_, err = store.TransferBatchTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrStepUpChallengeUnusable)
+
+	_, err = testQueries.VerifyStepUpChallenge(context.Background(), VerifyStepUpChallengeParams{
+		ID:     challenge.ID,
+		Method: "password",
+	})
+	require.NoError(t, err)
+
+	// nor can it authorize another batch
+	other := arg
+	other.Items = batchItems(account2.ID, 20, 30)
+	_, err = store.TransferBatchTx(context.Background(), other)
+	require.ErrorIs(t, err, ErrStepUpChallengeUnusable)
+
+	result, err := store.TransferBatchTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, TransferBatchStatusCompleted, result.Batch.Status)
+	require.Equal(t, arg.StepUpChallengeID, result.Batch.StepUpChallengeID)
+
+	// the same confirmation cannot be used twice
+	_, err = store.TransferBatchTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrStepUpChallengeUnusable)
+}
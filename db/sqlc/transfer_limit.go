@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Limits on the outgoing transfers of a user, in one currency
+const (
+	TransferLimitPerTransfer    = "per_transfer"
+	TransferLimitDailyAccount   = "daily_account"
+	TransferLimitMonthlyAccount = "monthly_account"
+	TransferLimitDailyUser      = "daily_user"
+	TransferLimitMonthlyUser    = "monthly_user"
+)
+
+// ErrTransferLimitExceeded is wrapped by TransferLimitError
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// TransferLimitError is returned by TransferTx when the transfer would exceed a limit of the sender
+type TransferLimitError struct {
+	Limit    string `json:"limit"`
+	Currency string `json:"currency"`
+	// Max is the amount allowed by the limit, Remaining what is left of it in the current day or month
+	Max       int64 `json:"max"`
+	Remaining int64 `json:"remaining"`
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("transfer exceeds the %s limit of %d %s, %d remaining", e.Limit, e.Max, e.Currency, e.Remaining)
+}
+
+func (e *TransferLimitError) Unwrap() error {
+	return ErrTransferLimitExceeded
+}
+
+// TransferLimits are the default limits of every user, each mapping a currency to its maximum
+// amount. A currency missing from a map has no such limit. The daily and monthly totals
+// are counted from the start of the day and of the month, in UTC.
+type TransferLimits struct {
+	PerTransfer    map[string]int64
+	DailyAccount   map[string]int64
+	MonthlyAccount map[string]int64
+	DailyUser      map[string]int64
+	MonthlyUser    map[string]int64
+}
+
+// WithTransferLimits sets the default limits enforced by TransferTx
+func WithTransferLimits(limits TransferLimits) StoreOption {
+	return func(store *SQLStore) {
+		store.transferLimits = limits
+	}
+}
+
+// transferLimit is a limit applying to a transfer, with the amount already sent against it
+type transferLimit struct {
+	name string
+	max  int64
+	used int64
+}
+
+// applicableLimits merges the default limits of the currency with the overrides of the user
+func (limits TransferLimits) applicableLimits(currency string, override TransferLimitOverride, totals GetOutgoingTransferTotalsRow) []transferLimit {
+	candidates := []struct {
+		name     string
+		defaults map[string]int64
+		override pgtype.Int8
+		used     int64
+	}{
+		{TransferLimitPerTransfer, limits.PerTransfer, override.PerTransfer, 0},
+		{TransferLimitDailyAccount, limits.DailyAccount, override.DailyAccount, totals.AccountDay},
+		{TransferLimitMonthlyAccount, limits.MonthlyAccount, override.MonthlyAccount, totals.AccountMonth},
+		{TransferLimitDailyUser, limits.DailyUser, override.DailyUser, totals.UserDay},
+		{TransferLimitMonthlyUser, limits.MonthlyUser, override.MonthlyUser, totals.UserMonth},
+	}
+
+	var applicable []transferLimit
+	for _, candidate := range candidates {
+		max, ok := candidate.defaults[currency]
+		if candidate.override.Valid {
+			max, ok = candidate.override.Int64, true
+		}
+		if ok {
+			applicable = append(applicable, transferLimit{name: candidate.name, max: max, used: candidate.used})
+		}
+	}
+	return applicable
+}
+
+// checkTransferLimits returns a TransferLimitError for the first limit the amount would exceed
+func checkTransferLimits(limits []transferLimit, currency string, amount int64) error {
+	for _, limit := range limits {
+		remaining := limit.max - limit.used
+		if remaining < 0 {
+			remaining = 0
+		}
+		if amount > remaining {
+			return &TransferLimitError{
+				Limit:     limit.name,
+				Currency:  currency,
+				Max:       limit.max,
+				Remaining: remaining,
+			}
+		}
+	}
+	return nil
+}
+
+// senderLimits are the limits of a locked sender, with the amounts already sent against them
+type senderLimits struct {
+	currency string
+	limits   []transferLimit
+}
+
+// lockTransferLimits locks the sender of the account and loads its limits with the totals it sent
+// in the current day and month. The sender is locked first, so that the totals of concurrent
+// transfers of the user are counted one transfer at a time.
+func (store *SQLStore) lockTransferLimits(ctx context.Context, q *Queries, fromAccountID int64) (*senderLimits, error) {
+	sender, err := q.LockTransferSender(ctx, fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	override, err := q.GetTransferLimitOverride(ctx, GetTransferLimitOverrideParams{
+		Username: sender.Owner,
+		Currency: sender.Currency,
+	})
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	totals, err := q.GetOutgoingTransferTotals(ctx, GetOutgoingTransferTotalsParams{
+		AccountID:  fromAccountID,
+		DayStart:   time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Owner:      sender.Owner,
+		Currency:   sender.Currency,
+		MonthStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &senderLimits{
+		currency: sender.Currency,
+		limits:   store.transferLimits.applicableLimits(sender.Currency, override, totals),
+	}, nil
+}
+
+// check returns a TransferLimitError for the first limit the amount would exceed
+func (sender *senderLimits) check(amount int64) error {
+	return checkTransferLimits(sender.limits, sender.currency, amount)
+}
+
+// add counts a transfer of the amount against the daily and monthly limits, so that the
+// transfers of a batch are checked without summing the transfers of the month again
+func (sender *senderLimits) add(amount int64) {
+	for i := range sender.limits {
+		if sender.limits[i].name != TransferLimitPerTransfer {
+			sender.limits[i].used += amount
+		}
+	}
+}
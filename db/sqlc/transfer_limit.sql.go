@@ -0,0 +1,162 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: transfer_limit.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getOutgoingTransferTotals = `-- name: GetOutgoingTransferTotals :one
+SELECT
+  COALESCE(SUM(t.amount) FILTER (
+    WHERE t.from_account_id = $1 AND t.created_at >= $2::timestamptz
+  ), 0)::bigint AS account_day,
+  COALESCE(SUM(t.amount) FILTER (WHERE t.from_account_id = $1), 0)::bigint AS account_month,
+  COALESCE(SUM(t.amount) FILTER (WHERE t.created_at >= $2::timestamptz), 0)::bigint AS user_day,
+  COALESCE(SUM(t.amount), 0)::bigint AS user_month
+FROM transfers t
+JOIN bank_accounts a ON a.id = t.from_account_id
+WHERE a.owner = $3
+  AND a.currency = $4
+  AND t.created_at >= $5::timestamptz
+`
+
+type GetOutgoingTransferTotalsParams struct {
+	AccountID  int64     `json:"accountID"`
+	DayStart   time.Time `json:"dayStart"`
+	Owner      string    `json:"owner"`
+	Currency   string    `json:"currency"`
+	MonthStart time.Time `json:"monthStart"`
+}
+
+type GetOutgoingTransferTotalsRow struct {
+	AccountDay   int64 `json:"accountDay"`
+	AccountMonth int64 `json:"accountMonth"`
+	UserDay      int64 `json:"userDay"`
+	UserMonth    int64 `json:"userMonth"`
+}
+
+func (q *Queries) GetOutgoingTransferTotals(ctx context.Context, arg GetOutgoingTransferTotalsParams) (GetOutgoingTransferTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getOutgoingTransferTotals,
+		arg.AccountID,
+		arg.DayStart,
+		arg.Owner,
+		arg.Currency,
+		arg.MonthStart,
+	)
+	var i GetOutgoingTransferTotalsRow
+	err := row.Scan(
+		&i.AccountDay,
+		&i.AccountMonth,
+		&i.UserDay,
+		&i.UserMonth,
+	)
+	return i, err
+}
+
+const getTransferLimitOverride = `-- name: GetTransferLimitOverride :one
+SELECT username, currency, per_transfer, daily_account, monthly_account, daily_user, monthly_user, updated_by, updated_at FROM transfer_limit_overrides
+WHERE username = $1 AND currency = $2 LIMIT 1
+`
+
+type GetTransferLimitOverrideParams struct {
+	Username string `json:"username"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetTransferLimitOverride(ctx context.Context, arg GetTransferLimitOverrideParams) (TransferLimitOverride, error) {
+	row := q.db.QueryRow(ctx, getTransferLimitOverride, arg.Username, arg.Currency)
+	var i TransferLimitOverride
+	err := row.Scan(
+		&i.Username,
+		&i.Currency,
+		&i.PerTransfer,
+		&i.DailyAccount,
+		&i.MonthlyAccount,
+		&i.DailyUser,
+		&i.MonthlyUser,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const lockTransferSender = `-- name: LockTransferSender :one
+SELECT bank_accounts.owner, bank_accounts.currency
+FROM bank_accounts
+JOIN users ON users.username = bank_accounts.owner
+WHERE bank_accounts.id = $1
+FOR NO KEY UPDATE OF users
+`
+
+type LockTransferSenderRow struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) LockTransferSender(ctx context.Context, id int64) (LockTransferSenderRow, error) {
+	row := q.db.QueryRow(ctx, lockTransferSender, id)
+	var i LockTransferSenderRow
+	err := row.Scan(&i.Owner, &i.Currency)
+	return i, err
+}
+
+const setTransferLimitOverride = `-- name: SetTransferLimitOverride :one
+INSERT INTO transfer_limit_overrides (
+  username, currency, per_transfer, daily_account, monthly_account, daily_user, monthly_user, updated_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (username, currency) DO UPDATE
+SET per_transfer = EXCLUDED.per_transfer,
+    daily_account = EXCLUDED.daily_account,
+    monthly_account = EXCLUDED.monthly_account,
+    daily_user = EXCLUDED.daily_user,
+    monthly_user = EXCLUDED.monthly_user,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING username, currency, per_transfer, daily_account, monthly_account, daily_user, monthly_user, updated_by, updated_at
+`
+
+type SetTransferLimitOverrideParams struct {
+	Username       string      `json:"username"`
+	Currency       string      `json:"currency"`
+	PerTransfer    pgtype.Int8 `json:"perTransfer"`
+	DailyAccount   pgtype.Int8 `json:"dailyAccount"`
+	MonthlyAccount pgtype.Int8 `json:"monthlyAccount"`
+	DailyUser      pgtype.Int8 `json:"dailyUser"`
+	MonthlyUser    pgtype.Int8 `json:"monthlyUser"`
+	UpdatedBy      string      `json:"updatedBy"`
+}
+
+func (q *Queries) SetTransferLimitOverride(ctx context.Context, arg SetTransferLimitOverrideParams) (TransferLimitOverride, error) {
+	row := q.db.QueryRow(ctx, setTransferLimitOverride,
+		arg.Username,
+		arg.Currency,
+		arg.PerTransfer,
+		arg.DailyAccount,
+		arg.MonthlyAccount,
+		arg.DailyUser,
+		arg.MonthlyUser,
+		arg.UpdatedBy,
+	)
+	var i TransferLimitOverride
+	err := row.Scan(
+		&i.Username,
+		&i.Currency,
+		&i.PerTransfer,
+		&i.DailyAccount,
+		&i.MonthlyAccount,
+		&i.DailyUser,
+		&i.MonthlyUser,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
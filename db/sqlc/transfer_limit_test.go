@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTransferLimits(t *testing.T) {
+	limits := TransferLimits{
+		PerTransfer: map[string]int64{"USD": 100},
+		DailyUser:   map[string]int64{"USD": 500, "EUR": 50},
+	}
+	totals := GetOutgoingTransferTotalsRow{AccountDay: 300, UserDay: 450}
+
+	applicable := limits.applicableLimits("USD", TransferLimitOverride{}, totals)
+	require.Len(t, applicable, 2)
+	require.NoError(t, checkTransferLimits(applicable, "USD", 50))
+
+	err := checkTransferLimits(applicable, "USD", 60)
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+	require.Equal(t, TransferLimitDailyUser, limitErr.Limit)
+	require.Equal(t, int64(500), limitErr.Max)
+	require.Equal(t, int64(50), limitErr.Remaining)
+
+	err = checkTransferLimits(applicable, "USD", 101)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, TransferLimitPerTransfer, limitErr.Limit)
+
+	// the overrides of the user replace the defaults, and add limits missing from them
+	override := TransferLimitOverride{
+		DailyUser:    pgtype.Int8{Int64: 1000, Valid: true},
+		DailyAccount: pgtype.Int8{Int64: 320, Valid: true},
+	}
+	applicable = limits.applicableLimits("USD", override, totals)
+	require.Len(t, applicable, 3)
+
+	err = checkTransferLimits(applicable, "USD", 60)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, TransferLimitDailyAccount, limitErr.Limit)
+	require.Equal(t, int64(20), limitErr.Remaining)
+
+	// a currency without limits
+	require.Empty(t, limits.applicableLimits("CAD", TransferLimitOverride{}, totals))
+}
+
+func TestTransferTxLimits(t *testing.T) {
+	user := createRandomUser(t)
+	account1 := createRandomAccountOf(t, user.Username, "USD")
+	account2 := createRandomAccountOf(t, user.Username, "USD")
+	recipient := createRandomAccountOf(t, createRandomUser(t).Username, "USD")
+
+	store := NewStore(testPool, WithTransferLimits(TransferLimits{
+		DailyUser: map[string]int64{"USD": 10},
+	}))
+
+	// n concurrent transfers from both accounts, only the ones within the daily limit of the user go through
+	n := 6
+	amount := int64(3)
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		from := account1.ID
+		if i%2 == 1 {
+			from = account2.ID
+		}
+		go func() {
+			_, err := store.TransferTx(context.Background(), CreateTransferParams{
+				FromAccountID: from,
+				ToAccountID:   recipient.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+
+		var limitErr *TransferLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, TransferLimitDailyUser, limitErr.Limit)
+		require.Equal(t, int64(1), limitErr.Remaining)
+	}
+	require.Equal(t, 3, succeeded)
+
+	// an admin raises the limit of the user
+	_, err := testQueries.SetTransferLimitOverride(context.Background(), SetTransferLimitOverrideParams{
+		Username:  user.Username,
+		Currency:  "USD",
+		DailyUser: pgtype.Int8{Int64: 100, Valid: true},
+		UpdatedBy: createRandomUser(t).Username,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   recipient.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+}
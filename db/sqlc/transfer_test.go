@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransfer(t *testing.T, from BankAccount, to BankAccount) Transfer {
+	transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        tools.RandomMoney(),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, transfer.ID)
+
+	return transfer
+}
+
+func TestGetOwnerTransfer(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	transfer := createRandomTransfer(t, from, to)
+
+	for _, owner := range []string{from.Owner, to.Owner} {
+		got, err := testQueries.GetOwnerTransfer(context.Background(), GetOwnerTransferParams{
+			ID:    transfer.ID,
+			Owner: owner,
+		})
+		require.NoError(t, err)
+		require.Equal(t, transfer.ID, got.ID)
+	}
+
+	other := createRandomUser(t)
+	_, err := testQueries.GetOwnerTransfer(context.Background(), GetOwnerTransferParams{
+		ID:    transfer.ID,
+		Owner: other.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListOwnerTransfers(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+
+	transfers := []Transfer{
+		createRandomTransfer(t, from, to),
+		createRandomTransfer(t, to, from),
+	}
+
+	// newest first
+	all, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+		Owner: from.Owner,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, transfers[1].ID, all[0].ID)
+	require.Equal(t, transfers[0].ID, all[1].ID)
+
+	out, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+		Owner:     from.Owner,
+		Direction: NullString(TransferDirectionOut),
+		AccountID: NullInt64(from.ID),
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	require.Equal(t, transfers[0].ID, out[0].ID)
+
+	in, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+		Owner:     from.Owner,
+		Direction: NullString(TransferDirectionIn),
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, in, 1)
+	require.Equal(t, transfers[1].ID, in[0].ID)
+
+	// accounts of other owners are not listed
+	none, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+		Owner:     from.Owner,
+		AccountID: NullInt64(to.ID),
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
+
+func TestTransferTxMemoAndReference(t *testing.T) {
+	store := NewStore(testPool)
+
+	from := createRandomAccount(t)
+	to := createRandomAccountOf(t, createRandomUser(t).Username, from.Currency)
+
+	result, err := store.TransferTx(context.Background(), CreateTransferParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Memo:          "dinner",
+		Reference:     "RF18539007547034",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "dinner", result.Transfer.Memo)
+	require.Equal(t, "RF18539007547034", result.Transfer.Reference)
+
+	transfer, err := store.GetTransfer(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer, transfer)
+}
@@ -1,17 +1,18 @@
 // Code generated by sqlc. DO NOT EDIT.
 // versions:
-//   sqlc v1.15.0
+//   sqlc v1.18.0
 // source: user.sql
 
 package db
 
 import (
 	"context"
-	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, hashed_password, full_name, email) VALUES ($1, $2, $3, $4) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+INSERT INTO users (username, hashed_password, full_name, email) VALUES ($1, $2, $3, $4) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, role, tier, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -22,7 +23,7 @@ type CreateUserParams struct {
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.queryRow(ctx, q.createUserStmt, createUser,
+	row := q.db.QueryRow(ctx, createUser,
 		arg.Username,
 		arg.HashedPassword,
 		arg.FullName,
@@ -36,16 +37,50 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.Role,
+		&i.Tier,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users WHERE username = $1 LIMIT 1
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, role, tier, totp_last_step FROM users WHERE username = $1 LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
-	row := q.queryRow(ctx, q.getUserStmt, getUser, username)
+	row := q.db.QueryRow(ctx, getUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.Role,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $1::varchar
+WHERE username = $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, role, tier, totp_last_step
+`
+
+type SetUserTOTPSecretParams struct {
+	TotpSecret string `json:"totpSecret"`
+	Username   string `json:"username"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserTOTPSecret, arg.TotpSecret, arg.Username)
 	var i User
 	err := row.Scan(
 		&i.Username,
@@ -54,6 +89,10 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.Role,
+		&i.Tier,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -64,26 +103,29 @@ SET
   hashed_password = COALESCE($1, hashed_password),
   password_changed_at = COALESCE($2, password_changed_at),
   full_name = COALESCE($3, full_name),
-  email = COALESCE($4, email)
+  email = COALESCE($4, email),
+  tier = COALESCE($5, tier)
 WHERE
-  username = $5
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+  username = $6
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, role, tier, totp_last_step
 `
 
 type UpdateUserParams struct {
-	HashedPassword    sql.NullString `json:"hashedPassword"`
-	PasswordChangedAt sql.NullTime   `json:"passwordChangedAt"`
-	FullName          sql.NullString `json:"fullName"`
-	Email             sql.NullString `json:"email"`
-	Username          string         `json:"username"`
+	HashedPassword    pgtype.Text        `json:"hashedPassword"`
+	PasswordChangedAt pgtype.Timestamptz `json:"passwordChangedAt"`
+	FullName          pgtype.Text        `json:"fullName"`
+	Email             pgtype.Text        `json:"email"`
+	Tier              pgtype.Text        `json:"tier"`
+	Username          string             `json:"username"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.queryRow(ctx, q.updateUserStmt, updateUser,
+	row := q.db.QueryRow(ctx, updateUser,
 		arg.HashedPassword,
 		arg.PasswordChangedAt,
 		arg.FullName,
 		arg.Email,
+		arg.Tier,
 		arg.Username,
 	)
 	var i User
@@ -94,6 +136,41 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.Role,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useUserTOTPStep = `-- name: UseUserTOTPStep :one
+UPDATE users
+SET totp_last_step = $1::bigint
+WHERE username = $2 AND totp_last_step < $1::bigint
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, totp_secret, role, tier, totp_last_step
+`
+
+type UseUserTOTPStepParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+// Records the time step of an accepted totp code, a code of that step or an earlier one is a replay.
+func (q *Queries) UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error) {
+	row := q.db.QueryRow(ctx, useUserTOTPStep, arg.Step, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.Role,
+		&i.Tier,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -35,6 +35,7 @@ func createRandomUser(t *testing.T) User {
 	require.NotZero(t, user.CreatedAt)
 
 	require.True(t, user.PasswordChangedAt.IsZero())
+	require.Equal(t, tools.DepositorRole, user.Role)
 
 	return user
 }
@@ -57,3 +58,21 @@ func TestGetUser(t *testing.T) {
 
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+func TestCreateUserDuplicateEmail(t *testing.T) {
+	store := NewStore(testPool)
+	user1 := createRandomUser(t)
+
+	_, err := store.CreateUser(context.Background(), CreateUserParams{
+		Username:       tools.RandomOwner(),
+		Email:          user1.Email,
+		FullName:       tools.RandomString(10),
+		HashedPassword: user1.HashedPassword,
+	})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	require.Equal(t, "users_email_key", dbErr.Constraint)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
@@ -0,0 +1,68 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SetAccountProduct creates or replaces an account product and its interest rate
+func (server *Server) SetAccountProduct(ctx context.Context, req *pb.SetAccountProductRequest) (*pb.SetAccountProductResponse, error) {
+	violations := validateSetAccountProductRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	product, err := server.store.SetAccountProduct(ctx, db.SetAccountProductParams{
+		Code:          req.GetCode(),
+		Name:          req.GetName(),
+		AnnualRateBps: req.GetAnnualRateBps(),
+		DayCount:      req.GetDayCount(),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to set account product: %s", err)
+	}
+
+	rsp := &pb.SetAccountProductResponse{
+		AccountProduct: convertAccountProduct(product),
+	}
+	return rsp, nil
+}
+
+func validateSetAccountProductRequest(req *pb.SetAccountProductRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := ValidateString(req.GetCode(), 1, 50); err != nil {
+		violations = append(violations, fieldViolation("code", err))
+	}
+
+	if err := ValidateString(req.GetName(), 1, 100); err != nil {
+		violations = append(violations, fieldViolation("name", err))
+	}
+
+	if req.GetAnnualRateBps() < 0 || req.GetAnnualRateBps() > 10000 {
+		violations = append(violations, fieldViolation("annual_rate_bps", errors.New("must be between 0 and 10000")))
+	}
+
+	if !db.IsSupportedDayCount(req.GetDayCount()) {
+		violations = append(violations, fieldViolation("day_count", fmt.Errorf("must be %s, %s or %s",
+			db.DayCountActual365, db.DayCountActual360, db.DayCountActualActual)))
+	}
+
+	return violations
+}
+
+func convertAccountProduct(product db.AccountProduct) *pb.AccountProduct {
+	return &pb.AccountProduct{
+		Code:          product.Code,
+		Name:          product.Name,
+		AnnualRateBps: product.AnnualRateBps,
+		DayCount:      product.DayCount,
+		UpdatedAt:     timestamppb.New(product.UpdatedAt),
+	}
+}
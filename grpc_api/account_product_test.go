@@ -0,0 +1,66 @@
+package grpc_api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSetAccountProduct(t *testing.T) {
+	arg := db.SetAccountProductParams{
+		Code:          "savings",
+		Name:          "Savings",
+		AnnualRateBps: 350,
+		DayCount:      db.DayCountActualActual,
+	}
+	product := db.AccountProduct{
+		Code:          arg.Code,
+		Name:          arg.Name,
+		AnnualRateBps: arg.AnnualRateBps,
+		DayCount:      arg.DayCount,
+		UpdatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Eq(arg)).Times(1).Return(product, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	req := &pb.SetAccountProductRequest{
+		Code:          arg.Code,
+		Name:          arg.Name,
+		AnnualRateBps: arg.AnnualRateBps,
+		DayCount:      arg.DayCount,
+	}
+
+	// only admins can set the products
+	depositorCtx := withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err := client.SetAccountProduct(depositorCtx, req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	adminCtx := withAccessToken(t, server.tokenGenerator, "admin", tools.AdminRole)
+	rsp, err := client.SetAccountProduct(adminCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, arg.AnnualRateBps, rsp.GetAccountProduct().GetAnnualRateBps())
+	require.Equal(t, arg.DayCount, rsp.GetAccountProduct().GetDayCount())
+
+	_, err = client.SetAccountProduct(adminCtx, &pb.SetAccountProductRequest{
+		Code:          arg.Code,
+		Name:          arg.Name,
+		AnnualRateBps: -1,
+		DayCount:      "30/360",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,82 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"google.golang.org/grpc/status"
+)
+
+// CloseBankAccount closes an account of the caller, keeping its history
+func (server *Server) CloseBankAccount(ctx context.Context, req *pb.CloseBankAccountRequest) (*pb.CloseBankAccountResponse, error) {
+	violations := validateAccountID("account_id", req.GetAccountId())
+	if req.GetSweepAccountId() != 0 {
+		violations = append(violations, validateAccountID("sweep_account_id", req.GetSweepAccountId())...)
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if err := server.authorizeBankAccount(ctx, req.GetAccountId()); err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.CloseAccountTx(ctx, db.CloseAccountTxParams{
+		AccountID:      req.GetAccountId(),
+		SweepAccountID: req.GetSweepAccountId(),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to close bank account: %s", err)
+	}
+
+	rsp := &pb.CloseBankAccountResponse{
+		BankAccount: convertBankAccount(result.Account),
+	}
+	if result.Sweep != nil {
+		rsp.SweepTransfer = convertTransfer(result.Sweep.Transfer)
+	}
+	return rsp, nil
+}
+
+// FreezeBankAccount stops an account from sending or receiving money until it is reopened
+func (server *Server) FreezeBankAccount(ctx context.Context, req *pb.FreezeBankAccountRequest) (*pb.FreezeBankAccountResponse, error) {
+	bankAccount, err := server.updateAccountStatus(ctx, req.GetAccountId(), db.AccountStatusFrozen)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.FreezeBankAccountResponse{
+		BankAccount: convertBankAccount(bankAccount),
+	}
+	return rsp, nil
+}
+
+// ReopenBankAccount makes a frozen account active again
+func (server *Server) ReopenBankAccount(ctx context.Context, req *pb.ReopenBankAccountRequest) (*pb.ReopenBankAccountResponse, error) {
+	bankAccount, err := server.updateAccountStatus(ctx, req.GetAccountId(), db.AccountStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.ReopenBankAccountResponse{
+		BankAccount: convertBankAccount(bankAccount),
+	}
+	return rsp, nil
+}
+
+func (server *Server) updateAccountStatus(ctx context.Context, accountID int64, accountStatus string) (db.BankAccount, error) {
+	if violations := validateAccountID("account_id", accountID); violations != nil {
+		return db.BankAccount{}, invalidArgumentError(violations)
+	}
+
+	bankAccount, err := server.store.UpdateAccountStatusTx(ctx, db.UpdateBankAccountStatusParams{
+		ID:     accountID,
+		Status: accountStatus,
+	})
+	if err != nil {
+		return bankAccount, status.Errorf(apierror.GRPCCode(err), "failed to update bank account status: %s", err)
+	}
+	return bankAccount, nil
+}
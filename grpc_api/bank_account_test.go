@@ -0,0 +1,103 @@
+package grpc_api
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCloseBankAccount(t *testing.T) {
+	username := randomUsername()
+	bankAccount := randomBankAccount(username)
+	sweepAccount := randomBankAccount(username)
+
+	closed := bankAccount
+	closed.Status = db.AccountStatusClosed
+	closed.ClosedAt = pgtype.Timestamptz{Time: bankAccount.CreatedAt, Valid: true}
+	sweep := randomTransfer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+		Times(2).
+		Return(bankAccount, nil)
+	store.EXPECT().
+		CloseAccountTx(gomock.Any(), gomock.Eq(db.CloseAccountTxParams{
+			AccountID:      bankAccount.ID,
+			SweepAccountID: sweepAccount.ID,
+		})).
+		Times(1).
+		Return(db.CloseAccountTxResult{Account: closed, Sweep: &db.TransferTxResult{Transfer: sweep}}, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+	rsp, err := client.CloseBankAccount(ctx, &pb.CloseBankAccountRequest{
+		AccountId:      bankAccount.ID,
+		SweepAccountId: sweepAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, db.AccountStatusClosed, rsp.GetBankAccount().GetStatus())
+	require.NotNil(t, rsp.GetBankAccount().GetClosedAt())
+	require.Equal(t, sweep.ID, rsp.GetSweepTransfer().GetId())
+
+	otherCtx := withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err = client.CloseBankAccount(otherCtx, &pb.CloseBankAccountRequest{AccountId: bankAccount.ID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.CloseBankAccount(ctx, &pb.CloseBankAccountRequest{AccountId: bankAccount.ID, SweepAccountId: -1})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFreezeAndReopenBankAccount(t *testing.T) {
+	bankAccount := randomBankAccount(randomUsername())
+	frozen := bankAccount
+	frozen.Status = db.AccountStatusFrozen
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateBankAccountStatusParams{
+			ID:     bankAccount.ID,
+			Status: db.AccountStatusFrozen,
+		})).
+		Times(1).
+		Return(frozen, nil)
+	store.EXPECT().
+		UpdateAccountStatusTx(gomock.Any(), gomock.Eq(db.UpdateBankAccountStatusParams{
+			ID:     bankAccount.ID,
+			Status: db.AccountStatusActive,
+		})).
+		Times(1).
+		Return(db.BankAccount{}, db.ErrInvalidStatusTransition)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	// only admins can freeze or reopen accounts
+	depositorCtx := withAccessToken(t, server.tokenGenerator, bankAccount.Owner, tools.DepositorRole)
+	_, err := client.FreezeBankAccount(depositorCtx, &pb.FreezeBankAccountRequest{AccountId: bankAccount.ID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	adminCtx := withAccessToken(t, server.tokenGenerator, "admin", tools.AdminRole)
+	rsp, err := client.FreezeBankAccount(adminCtx, &pb.FreezeBankAccountRequest{AccountId: bankAccount.ID})
+	require.NoError(t, err)
+	require.Equal(t, db.AccountStatusFrozen, rsp.GetBankAccount().GetStatus())
+
+	_, err = client.ReopenBankAccount(adminCtx, &pb.ReopenBankAccountRequest{AccountId: bankAccount.ID})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
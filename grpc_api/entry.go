@@ -0,0 +1,52 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// UpdateEntry sets the category of an entry of an account of the caller
+func (server *Server) UpdateEntry(ctx context.Context, req *pb.UpdateEntryRequest) (*pb.UpdateEntryResponse, error) {
+	violations := validateCategory(req.GetCategory())
+	if req.GetId() < 1 {
+		violations = append(violations, fieldViolation("id", errors.New("must be a positive integer")))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	entry, err := server.store.GetEntry(ctx, req.GetId())
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to get entry: %s", err)
+	}
+
+	if err := server.authorizeBankAccount(ctx, entry.AccountID); err != nil {
+		return nil, err
+	}
+
+	entry, err = server.store.UpdateEntryCategory(ctx, db.UpdateEntryCategoryParams{
+		ID:       req.GetId(),
+		Category: req.GetCategory(),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to update entry: %s", err)
+	}
+
+	rsp := &pb.UpdateEntryResponse{
+		Entry: convertEntry(entry),
+	}
+	return rsp, nil
+}
+
+func validateCategory(category string) []*errdetails.BadRequest_FieldViolation {
+	if err := ValidateString(category, 0, 50); err != nil {
+		return []*errdetails.BadRequest_FieldViolation{fieldViolation("category", err)}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package grpc_api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateEntry(t *testing.T) {
+	username := randomUsername()
+	bankAccount := randomBankAccount(username)
+
+	entry := db.Entry{
+		ID:        tools.RandomInt(1, 1000),
+		AccountID: bankAccount.ID,
+		Amount:    tools.RandomMoney(),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	categorized := entry
+	categorized.Category = "rent"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetEntry(gomock.Any(), gomock.Eq(entry.ID)).Times(2).Return(entry, nil)
+	store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).Times(2).Return(bankAccount, nil)
+	store.EXPECT().
+		UpdateEntryCategory(gomock.Any(), gomock.Eq(db.UpdateEntryCategoryParams{ID: entry.ID, Category: "rent"})).
+		Times(1).
+		Return(categorized, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+	rsp, err := client.UpdateEntry(ctx, &pb.UpdateEntryRequest{Id: entry.ID, Category: "rent"})
+	require.NoError(t, err)
+	require.Equal(t, "rent", rsp.GetEntry().GetCategory())
+
+	otherCtx := withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err = client.UpdateEntry(otherCtx, &pb.UpdateEntryRequest{Id: entry.ID, Category: "rent"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.UpdateEntry(ctx, &pb.UpdateEntryRequest{Id: entry.ID, Category: tools.RandomString(51)})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,144 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SetFeeSchedule creates or replaces the fee schedule of a currency, transfer type and customer tier
+func (server *Server) SetFeeSchedule(ctx context.Context, req *pb.SetFeeScheduleRequest) (*pb.SetFeeScheduleResponse, error) {
+	violations := validateSetFeeScheduleRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	revenueAccount, err := server.store.GetBankAccount(ctx, req.GetRevenueAccountId())
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to get revenue account: %s", err)
+	}
+
+	if revenueAccount.Currency != req.GetCurrency() {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("revenue_account_id", fmt.Errorf("must be an account in %s", req.GetCurrency())),
+		})
+	}
+
+	if revenueAccount.Status != db.AccountStatusActive {
+		err := fmt.Errorf("revenue account [%d] is %s: %w", revenueAccount.ID, revenueAccount.Status, db.ErrAccountNotActive)
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to set fee schedule: %s", err)
+	}
+
+	schedule, err := server.store.SetFeeSchedule(ctx, db.SetFeeScheduleParams{
+		Currency:         req.GetCurrency(),
+		TransferType:     req.GetTransferType(),
+		Tier:             req.GetTier(),
+		FlatFee:          req.GetFlatFee(),
+		PercentBps:       req.GetPercentBps(),
+		MinFee:           req.GetMinFee(),
+		MaxFee:           nullInt64(req.MaxFee),
+		RevenueAccountID: req.GetRevenueAccountId(),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to set fee schedule: %s", err)
+	}
+
+	rsp := &pb.SetFeeScheduleResponse{
+		FeeSchedule: convertFeeSchedule(schedule),
+	}
+	return rsp, nil
+}
+
+// SetUserTier moves a user to a customer tier, which selects the fee schedules of its transfers
+func (server *Server) SetUserTier(ctx context.Context, req *pb.SetUserTierRequest) (*pb.SetUserTierResponse, error) {
+	violations := validateSetUserTierRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	user, err := server.store.UpdateUser(ctx, db.UpdateUserParams{
+		Username: req.GetUsername(),
+		Tier: pgtype.Text{
+			String: req.GetTier(),
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to set user tier: %s", err)
+	}
+
+	rsp := &pb.SetUserTierResponse{
+		User: convertUser(user),
+	}
+	return rsp, nil
+}
+
+func validateSetUserTierRequest(req *pb.SetUserTierRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if !tools.IsSupportedTier(req.GetTier()) {
+		violations = append(violations, fieldViolation("tier", errors.New("unsupported tier")))
+	}
+
+	return violations
+}
+
+func validateSetFeeScheduleRequest(req *pb.SetFeeScheduleRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if !tools.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", errors.New("unsupported currency")))
+	}
+
+	if req.GetTransferType() != db.TransferTypeOwn && req.GetTransferType() != db.TransferTypeCrossOwner {
+		violations = append(violations, fieldViolation("transfer_type", fmt.Errorf("must be %s or %s", db.TransferTypeOwn, db.TransferTypeCrossOwner)))
+	}
+
+	if !tools.IsSupportedTier(req.GetTier()) {
+		violations = append(violations, fieldViolation("tier", errors.New("unsupported tier")))
+	}
+
+	if req.GetFlatFee() < 0 {
+		violations = append(violations, fieldViolation("flat_fee", errors.New("must not be negative")))
+	}
+
+	if req.GetPercentBps() < 0 || req.GetPercentBps() > 10000 {
+		violations = append(violations, fieldViolation("percent_bps", errors.New("must be between 0 and 10000")))
+	}
+
+	if req.GetMinFee() < 0 {
+		violations = append(violations, fieldViolation("min_fee", errors.New("must not be negative")))
+	}
+
+	if req.MaxFee != nil && req.GetMaxFee() < req.GetMinFee() {
+		violations = append(violations, fieldViolation("max_fee", errors.New("must not be less than min_fee")))
+	}
+
+	violations = append(violations, validateAccountID("revenue_account_id", req.GetRevenueAccountId())...)
+
+	return violations
+}
+
+func convertFeeSchedule(schedule db.FeeSchedule) *pb.FeeSchedule {
+	return &pb.FeeSchedule{
+		Id:               schedule.ID,
+		Currency:         schedule.Currency,
+		TransferType:     schedule.TransferType,
+		Tier:             schedule.Tier,
+		FlatFee:          schedule.FlatFee,
+		PercentBps:       schedule.PercentBps,
+		MinFee:           schedule.MinFee,
+		MaxFee:           int64Value(schedule.MaxFee),
+		RevenueAccountId: schedule.RevenueAccountID,
+		UpdatedAt:        timestamppb.New(schedule.UpdatedAt),
+	}
+}
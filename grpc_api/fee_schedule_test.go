@@ -0,0 +1,122 @@
+package grpc_api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSetFeeSchedule(t *testing.T) {
+	revenueAccount := randomBankAccount("bank")
+	revenueAccount.Currency = tools.EUR
+
+	arg := db.SetFeeScheduleParams{
+		Currency:         tools.EUR,
+		TransferType:     db.TransferTypeCrossOwner,
+		Tier:             tools.StandardTier,
+		FlatFee:          25,
+		PercentBps:       50,
+		MaxFee:           pgtype.Int8{Int64: 500, Valid: true},
+		RevenueAccountID: revenueAccount.ID,
+	}
+	schedule := db.FeeSchedule{
+		ID:               1,
+		Currency:         arg.Currency,
+		TransferType:     arg.TransferType,
+		Tier:             arg.Tier,
+		FlatFee:          arg.FlatFee,
+		PercentBps:       arg.PercentBps,
+		MaxFee:           arg.MaxFee,
+		RevenueAccountID: arg.RevenueAccountID,
+		UpdatedAt:        time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(revenueAccount.ID)).Times(1).Return(revenueAccount, nil)
+	store.EXPECT().SetFeeSchedule(gomock.Any(), gomock.Eq(arg)).Times(1).Return(schedule, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	req := &pb.SetFeeScheduleRequest{
+		Currency:         tools.EUR,
+		TransferType:     db.TransferTypeCrossOwner,
+		Tier:             tools.StandardTier,
+		FlatFee:          25,
+		PercentBps:       50,
+		MaxFee:           proto.Int64(500),
+		RevenueAccountId: revenueAccount.ID,
+	}
+
+	// only admins can set the fees
+	depositorCtx := withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err := client.SetFeeSchedule(depositorCtx, req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	adminCtx := withAccessToken(t, server.tokenGenerator, "admin", tools.AdminRole)
+	rsp, err := client.SetFeeSchedule(adminCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, schedule.ID, rsp.GetFeeSchedule().GetId())
+	require.Equal(t, int64(500), rsp.GetFeeSchedule().GetMaxFee())
+
+	_, err = client.SetFeeSchedule(adminCtx, &pb.SetFeeScheduleRequest{
+		Currency:         tools.EUR,
+		TransferType:     "wire",
+		Tier:             "gold",
+		PercentBps:       20000,
+		MinFee:           100,
+		MaxFee:           proto.Int64(50),
+		RevenueAccountId: revenueAccount.ID,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSetUserTier(t *testing.T) {
+	user := db.User{
+		Username: randomUsername(),
+		FullName: tools.RandomOwner(),
+		Email:    tools.RandomEmail(),
+		Tier:     tools.PremiumTier,
+	}
+	arg := db.UpdateUserParams{
+		Username: user.Username,
+		Tier:     pgtype.Text{String: user.Tier, Valid: true},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().UpdateUser(gomock.Any(), gomock.Eq(arg)).Times(1).Return(user, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	req := &pb.SetUserTierRequest{Username: user.Username, Tier: user.Tier}
+
+	// users cannot change their own tier
+	depositorCtx := withAccessToken(t, server.tokenGenerator, user.Username, tools.DepositorRole)
+	_, err := client.SetUserTier(depositorCtx, req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	adminCtx := withAccessToken(t, server.tokenGenerator, "admin", tools.AdminRole)
+	rsp, err := client.SetUserTier(adminCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, user.Tier, rsp.GetUser().GetTier())
+
+	_, err = client.SetUserTier(adminCtx, &pb.SetUserTierRequest{Username: user.Username, Tier: "gold"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,316 @@
+package grpc_api
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/radugaf/simplebank/logging"
+	"github.com/radugaf/simplebank/metrics"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods can be called without an access token
+var publicMethods = map[string]bool{
+	"/pb.SimpleBank/CreateUser": true,
+	"/pb.SimpleBank/LoginUser":  true,
+
+	"/grpc.health.v1.Health/Check": true,
+	"/grpc.health.v1.Health/Watch": true,
+
+	// server reflection only describes the services, it is used by evans
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+}
+
+// accessibleRoles lists the roles allowed to call each method.
+// Methods that are neither listed here nor in publicMethods cannot be called.
+var accessibleRoles = map[string][]string{
+	"/pb.SimpleBank/UpdateUser": {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/UnlockUser": {tools.AdminRole},
+
+	"/pb.SimpleBank/ListBankAccounts":     {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/ListEntries":          {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/UpdateEntry":          {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/ListAccountTransfers": {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/ListTransfers":        {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/GetTransfer":          {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/CloseBankAccount":     {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/FreezeBankAccount":    {tools.AdminRole},
+	"/pb.SimpleBank/ReopenBankAccount":    {tools.AdminRole},
+	"/pb.SimpleBank/CreatePayee":          {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/GetPayee":             {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/ListPayees":           {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/UpdatePayee":          {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/DeletePayee":          {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/ConfirmPayee":         {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/SetTransferLimits":    {tools.AdminRole},
+	"/pb.SimpleBank/SetFeeSchedule":       {tools.AdminRole},
+	"/pb.SimpleBank/SetUserTier":          {tools.AdminRole},
+	"/pb.SimpleBank/SetAccountProduct":    {tools.AdminRole},
+	"/pb.SimpleBank/CreateTransferBatch":  {tools.AdminRole, tools.DepositorRole},
+	"/pb.SimpleBank/GetTransferBatch":     {tools.AdminRole, tools.DepositorRole},
+}
+
+type payloadContextKey struct{}
+
+// payloadFromContext returns the payload of the access token the call was authorized with
+func payloadFromContext(ctx context.Context) (*token.Payload, bool) {
+	payload, ok := ctx.Value(payloadContextKey{}).(*token.Payload)
+	return payload, ok
+}
+
+// ServerOptions returns the interceptor chain every gRPC server should be created with
+func (server *Server) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			TracingInterceptor,
+			RequestIDInterceptor,
+			GrpcLogger,
+			MetricsInterceptor,
+			RecoveryInterceptor,
+			server.AuthInterceptor,
+			server.RateLimitInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			TracingStreamInterceptor,
+			RequestIDStreamInterceptor,
+			GrpcStreamLogger,
+			MetricsStreamInterceptor,
+			RecoveryStreamInterceptor,
+			server.AuthStreamInterceptor,
+			server.RateLimitStreamInterceptor,
+		),
+	}
+}
+
+// authorize checks the access token of a call against the policy of the method
+// and returns a context carrying its payload
+func (server *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	roles, ok := accessibleRoles[method]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "permission denied")
+	}
+
+	payload, err := server.authenticate(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if !hasPermission(payload.Role, roles) {
+		return nil, status.Errorf(codes.PermissionDenied, "permission denied")
+	}
+
+	return context.WithValue(ctx, payloadContextKey{}, payload), nil
+}
+
+// AuthInterceptor authorizes unary calls according to accessibleRoles
+func (server *Server) AuthInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, err := server.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// AuthStreamInterceptor authorizes streaming calls according to accessibleRoles
+func (server *Server) AuthStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := server.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+}
+
+// serverStream overrides the context of a grpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *serverStream) Context() context.Context {
+	return stream.ctx
+}
+
+// withRequestID returns a context carrying the x-request-id metadata of the call, or a new
+// request ID if the client did not send one, and sends the request ID back as a header
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logging.RequestIDMetadataKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	requestID = logging.RequestIDOrNew(requestID)
+
+	grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDMetadataKey, requestID))
+	return logging.WithRequestID(ctx, requestID)
+}
+
+// RequestIDInterceptor carries the request ID of every unary call in its context
+func RequestIDInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+// RequestIDStreamInterceptor carries the request ID of every streaming call in its context
+func RequestIDStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, &serverStream{ServerStream: stream, ctx: withRequestID(stream.Context())})
+}
+
+// GrpcLogger logs the method, status and duration of every unary call
+func GrpcLogger(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	startTime := time.Now()
+	result, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, startTime, err)
+	return result, err
+}
+
+// GrpcStreamLogger logs the method, status and duration of every streaming call
+func GrpcStreamLogger(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	startTime := time.Now()
+	err := handler(srv, stream)
+	logCall(stream.Context(), info.FullMethod, startTime, err)
+	return err
+}
+
+func logCall(ctx context.Context, method string, startTime time.Time, err error) {
+	duration := time.Since(startTime)
+	statusCode := status.Code(err)
+
+	logger := logging.FromContext(ctx)
+	event := logger.Info()
+	if err != nil {
+		event = logger.Error().Err(err)
+	}
+
+	event.Str("protocol", "grpc").
+		Str("method", method).
+		Int("status_code", int(statusCode)).
+		Str("status_text", statusCode.String()).
+		Dur("duration", duration).
+		Msg("received a gRPC request")
+}
+
+// loginMethod is the method whose results are counted as login attempts
+const loginMethod = "/pb.SimpleBank/LoginUser"
+
+// MetricsInterceptor counts the unary calls and observes their latency per method
+func MetricsInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	startTime := time.Now()
+	result, err := handler(ctx, req)
+	observeCall(info.FullMethod, startTime, err)
+	return result, err
+}
+
+// MetricsStreamInterceptor counts the streaming calls and observes their latency per method
+func MetricsStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	startTime := time.Now()
+	err := handler(srv, stream)
+	observeCall(info.FullMethod, startTime, err)
+	return err
+}
+
+func observeCall(method string, startTime time.Time, err error) {
+	statusCode := status.Code(err)
+
+	metrics.GRPCRequests.WithLabelValues(method, statusCode.String()).Inc()
+	metrics.GRPCRequestDuration.WithLabelValues(method).Observe(time.Since(startTime).Seconds())
+
+	if method == loginMethod {
+		switch statusCode {
+		case codes.OK, codes.Unauthenticated:
+			metrics.Logins.WithLabelValues(metrics.LoginResult(statusCode == codes.OK)).Inc()
+		}
+	}
+}
+
+// RecoveryInterceptor turns a panic in a unary handler into a codes.Internal error
+func RecoveryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(ctx, info.FullMethod, r)
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// RecoveryStreamInterceptor turns a panic in a stream handler into a codes.Internal error
+func RecoveryStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(stream.Context(), info.FullMethod, r)
+		}
+	}()
+
+	return handler(srv, stream)
+}
+
+func recoveredError(ctx context.Context, method string, r interface{}) error {
+	logging.FromContext(ctx).Error().
+		Str("method", method).
+		Interface("panic", r).
+		Bytes("stack", debug.Stack()).
+		Msg("recovered from panic")
+	return status.Errorf(codes.Internal, "internal error")
+}
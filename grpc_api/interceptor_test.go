@@ -0,0 +1,355 @@
+package grpc_api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/logging"
+	"github.com/radugaf/simplebank/metrics"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/ratelimit"
+	"github.com/radugaf/simplebank/token"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func withAccessToken(t *testing.T, tokenGenerator token.Token, username string, role string) context.Context {
+	accessToken, _, err := tokenGenerator.GenerateToken(username, role, time.Minute)
+	require.NoError(t, err)
+
+	authorization := fmt.Sprintf("%s %s", authorizationBearer, accessToken)
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationHeader, authorization)
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	username := randomUsername()
+
+	testCases := []struct {
+		name       string
+		buildCtx   func(t *testing.T, tokenGenerator token.Token) context.Context
+		buildStubs func(store *mockdb.MockStore)
+		checkCode  codes.Code
+	}{
+		{
+			name: "OK",
+			buildCtx: func(t *testing.T, tokenGenerator token.Token) context.Context {
+				return withAccessToken(t, tokenGenerator, "admin", tools.AdminRole)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteLoginFailure(gomock.Any(), gomock.Eq(db.DeleteLoginFailureParams{
+						Scope:      "username",
+						Identifier: username,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "NoAuthorization",
+			buildCtx: func(t *testing.T, tokenGenerator token.Token) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name: "InvalidToken",
+			buildCtx: func(t *testing.T, tokenGenerator token.Token) context.Context {
+				return metadata.AppendToOutgoingContext(context.Background(), authorizationHeader, "bearer invalid")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name: "PermissionDenied",
+			buildCtx: func(t *testing.T, tokenGenerator token.Token) context.Context {
+				return withAccessToken(t, tokenGenerator, username, tools.DepositorRole)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCode: codes.PermissionDenied,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := newTestClient(t, server)
+
+			ctx := tc.buildCtx(t, server.tokenGenerator)
+			rsp, err := client.UnlockUser(ctx, &pb.UnlockUserRequest{Username: username})
+			require.Equal(t, tc.checkCode, status.Code(err))
+			if tc.checkCode == codes.OK {
+				require.Equal(t, username, rsp.GetUsername())
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorPutsPayloadInContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := db.User{
+		Username: randomUsername(),
+		FullName: "New Name",
+		Email:    tools.RandomEmail(),
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		UpdateUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(user, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	// the handler compares the username of the payload with the one being updated
+	ctx := withAccessToken(t, server.tokenGenerator, user.Username, tools.DepositorRole)
+	fullName := user.FullName
+	rsp, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{Username: user.Username, FullName: &fullName})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, rsp.GetUser().GetUsername())
+
+	ctx = withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err = client.UpdateUser(ctx, &pb.UpdateUserRequest{Username: user.Username, FullName: &fullName})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx = withAccessToken(t, server.tokenGenerator, randomUsername(), tools.AdminRole)
+	_, err = client.UpdateUser(ctx, &pb.UpdateUserRequest{Username: user.Username, FullName: &fullName})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAuthorizeDeniesUnlistedMethods(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	// every method of the service needs a policy, or nobody can call it
+	service := pb.SimpleBank_ServiceDesc
+	for _, method := range service.Methods {
+		fullMethod := "/" + service.ServiceName + "/" + method.MethodName
+		require.True(t, publicMethods[fullMethod] || accessibleRoles[fullMethod] != nil, fullMethod)
+	}
+	for _, stream := range service.Streams {
+		fullMethod := "/" + service.ServiceName + "/" + stream.StreamName
+		require.True(t, publicMethods[fullMethod] || accessibleRoles[fullMethod] != nil, fullMethod)
+	}
+
+	_, err := server.authorize(context.Background(), "/pb.SimpleBank/LoginUser")
+	require.NoError(t, err)
+
+	accessToken, _, err := server.tokenGenerator.GenerateToken("admin", tools.AdminRole, time.Minute)
+	require.NoError(t, err)
+	md := metadata.Pairs(authorizationHeader, fmt.Sprintf("%s %s", authorizationBearer, accessToken))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, err = server.authorize(ctx, "/pb.SimpleBank/NotListed")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DeleteLoginFailure(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.DeleteLoginFailureParams) error {
+			panic("boom")
+		})
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	ctx := withAccessToken(t, server.tokenGenerator, "admin", tools.AdminRole)
+	_, err := client.UnlockUser(ctx, &pb.UnlockUserRequest{Username: randomUsername()})
+	require.Equal(t, codes.Internal, status.Code(err))
+
+	// the server keeps serving after a panic
+	_, err = client.UnlockUser(context.Background(), &pb.UnlockUserRequest{Username: randomUsername()})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGrpcLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = logger }()
+
+	server := newTestServer(t, nil)
+	client := newTestClient(t, server)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), logging.RequestIDMetadataKey, "client-request-id")
+	_, err := client.UnlockUser(ctx, &pb.UnlockUserRequest{Username: randomUsername()}, grpc.Header(&header))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Equal(t, []string{"client-request-id"}, header.Get(logging.RequestIDMetadataKey))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "client-request-id", entry["request_id"])
+	require.Equal(t, "grpc", entry["protocol"])
+	require.Equal(t, "/pb.SimpleBank/UnlockUser", entry["method"])
+	require.Equal(t, float64(codes.Unauthenticated), entry["status_code"])
+	require.Equal(t, codes.Unauthenticated.String(), entry["status_text"])
+	require.Contains(t, entry, "duration")
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *testServerStream) Context() context.Context {
+	return stream.ctx
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	server := newTestServer(t, nil)
+	info := &grpc.StreamServerInfo{FullMethod: "/pb.SimpleBank/UnlockUser"}
+
+	accessToken, _, err := server.tokenGenerator.GenerateToken("admin", tools.AdminRole, time.Minute)
+	require.NoError(t, err)
+	md := metadata.Pairs(authorizationHeader, fmt.Sprintf("%s %s", authorizationBearer, accessToken))
+	stream := &testServerStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+
+	err = server.AuthStreamInterceptor(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+		payload, ok := payloadFromContext(stream.Context())
+		require.True(t, ok)
+		require.Equal(t, "admin", payload.Username)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = server.AuthStreamInterceptor(nil, &testServerStream{ctx: context.Background()}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	err = RecoveryStreamInterceptor(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+		panic("boom")
+	})
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestRateLimitStreamInterceptor(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.rateLimiter = ratelimit.NewLimiter(tools.RateLimits{
+		"/pb.SimpleBank/CreateTransferBatch": {Rate: 0.001, Burst: 1},
+	})
+	info := &grpc.StreamServerInfo{FullMethod: "/pb.SimpleBank/CreateTransferBatch"}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	newStream := func(username string) grpc.ServerStream {
+		payload := &token.Payload{Username: username, Role: tools.DepositorRole}
+		return &testServerStream{ctx: context.WithValue(context.Background(), payloadContextKey{}, payload)}
+	}
+
+	require.NoError(t, server.RateLimitStreamInterceptor(nil, newStream("alice"), info, handler))
+
+	err := server.RateLimitStreamInterceptor(nil, newStream("alice"), info, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the limit applies to every user on their own
+	require.NoError(t, server.RateLimitStreamInterceptor(nil, newStream("bob"), info, handler))
+}
+
+func TestRequestIDInterceptor(t *testing.T) {
+	server := newTestServer(t, nil)
+	client := newTestClient(t, server)
+
+	var header metadata.MD
+	_, err := client.UnlockUser(context.Background(), &pb.UnlockUserRequest{Username: randomUsername()}, grpc.Header(&header))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	requestIDs := header.Get(logging.RequestIDMetadataKey)
+	require.Len(t, requestIDs, 1)
+	require.NotEmpty(t, requestIDs[0])
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	server := newTestServer(t, nil)
+	client := newTestClient(t, server)
+
+	method := "/pb.SimpleBank/UnlockUser"
+	requests := testutil.ToFloat64(metrics.GRPCRequests.WithLabelValues(method, codes.Unauthenticated.String()))
+
+	_, err := client.UnlockUser(context.Background(), &pb.UnlockUserRequest{Username: randomUsername()})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	require.Equal(t, requests+1, testutil.ToFloat64(metrics.GRPCRequests.WithLabelValues(method, codes.Unauthenticated.String())))
+
+	// failed logins are counted by the result of the call
+	logins := testutil.ToFloat64(metrics.Logins.WithLabelValues(metrics.LoginFailed))
+	observeCall(loginMethod, time.Now(), status.Error(codes.Unauthenticated, "invalid username or password"))
+	require.Equal(t, logins+1, testutil.ToFloat64(metrics.Logins.WithLabelValues(metrics.LoginFailed)))
+}
+
+func TestTracingInterceptor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+
+	server := newTestServer(t, nil)
+	client := newTestClient(t, server)
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID := "00f067aa0ba902b7"
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", fmt.Sprintf("00-%s-%s-01", traceID, parentSpanID))
+	_, err := client.UnlockUser(ctx, &pb.UnlockUserRequest{Username: randomUsername()})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, "/pb.SimpleBank/UnlockUser", span.Name())
+	require.Equal(t, traceID, span.SpanContext().TraceID().String())
+	require.Equal(t, parentSpanID, span.Parent().SpanID().String())
+	require.Contains(t, span.Attributes(), semconv.RPCGRPCStatusCodeKey.Int(int(codes.Unauthenticated)))
+	require.Equal(t, otelcodes.Error, span.Status().Code)
+}
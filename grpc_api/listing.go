@@ -0,0 +1,249 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) ListBankAccounts(ctx context.Context, req *pb.ListBankAccountsRequest) (*pb.ListBankAccountsResponse, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	scope := pagination.Scope("bank_accounts", authPayload.Username)
+	cursor, violations := server.validatePageRequest(req.GetPage(), scope)
+	if req.GetCurrency() != "" && !tools.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", fmt.Errorf("is not a supported currency")))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	bankAccounts, err := server.store.ListBankAccounts(ctx, db.ListBankAccountsParams{
+		Owner:           authPayload.Username,
+		Currency:        db.NullString(req.GetCurrency()),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to list bank accounts: %s", err)
+	}
+
+	bankAccounts, nextCursor := pagination.Page(server.cursorCodec, scope, bankAccounts, req.GetPage().GetPageSize(),
+		func(bankAccount db.BankAccount) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: bankAccount.CreatedAt, ID: bankAccount.ID}
+		})
+
+	rsp := &pb.ListBankAccountsResponse{NextCursor: nextCursor}
+	for _, bankAccount := range bankAccounts {
+		rsp.BankAccounts = append(rsp.BankAccounts, convertBankAccount(bankAccount))
+	}
+	return rsp, nil
+}
+
+func (server *Server) ListEntries(ctx context.Context, req *pb.ListEntriesRequest) (*pb.ListEntriesResponse, error) {
+	scope := pagination.Scope("entries", req.GetAccountId())
+	cursor, violations := server.validatePageRequest(req.GetPage(), scope)
+	violations = append(violations, validateAccountID("account_id", req.GetAccountId())...)
+	violations = append(violations, validateRangeFilter(req.GetFilter())...)
+	violations = append(violations, validateCategory(req.GetCategory())...)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if err := server.authorizeBankAccount(ctx, req.GetAccountId()); err != nil {
+		return nil, err
+	}
+
+	filter := req.GetFilter()
+	entries, err := server.store.ListEntries(ctx, db.ListEntriesParams{
+		AccountID:       req.GetAccountId(),
+		FromTime:        db.NullTime(timeOf(filter.GetFromTime())),
+		ToTime:          db.NullTime(timeOf(filter.GetToTime())),
+		MinAmount:       db.NullInt64(filter.GetMinAmount()),
+		MaxAmount:       db.NullInt64(filter.GetMaxAmount()),
+		Category:        db.NullString(req.GetCategory()),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to list entries: %s", err)
+	}
+
+	entries, nextCursor := pagination.Page(server.cursorCodec, scope, entries, req.GetPage().GetPageSize(),
+		func(entry db.Entry) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+		})
+
+	rsp := &pb.ListEntriesResponse{NextCursor: nextCursor}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, convertEntry(entry))
+	}
+	return rsp, nil
+}
+
+func (server *Server) ListAccountTransfers(ctx context.Context, req *pb.ListAccountTransfersRequest) (*pb.ListAccountTransfersResponse, error) {
+	scope := pagination.Scope("transfers", req.GetAccountId())
+	cursor, violations := server.validatePageRequest(req.GetPage(), scope)
+	violations = append(violations, validateAccountID("account_id", req.GetAccountId())...)
+	violations = append(violations, validateRangeFilter(req.GetFilter())...)
+	if req.GetCounterpartyId() != 0 {
+		violations = append(violations, validateAccountID("counterparty_id", req.GetCounterpartyId())...)
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if err := server.authorizeBankAccount(ctx, req.GetAccountId()); err != nil {
+		return nil, err
+	}
+
+	filter := req.GetFilter()
+	transfers, err := server.store.ListTransfers(ctx, db.ListTransfersParams{
+		AccountID:       req.GetAccountId(),
+		CounterpartyID:  db.NullInt64(req.GetCounterpartyId()),
+		FromTime:        db.NullTime(timeOf(filter.GetFromTime())),
+		ToTime:          db.NullTime(timeOf(filter.GetToTime())),
+		MinAmount:       db.NullInt64(filter.GetMinAmount()),
+		MaxAmount:       db.NullInt64(filter.GetMaxAmount()),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to list transfers: %s", err)
+	}
+
+	transfers, nextCursor := pagination.Page(server.cursorCodec, scope, transfers, req.GetPage().GetPageSize(), transferCursor)
+
+	rsp := &pb.ListAccountTransfersResponse{NextCursor: nextCursor}
+	for _, transfer := range transfers {
+		rsp.Transfers = append(rsp.Transfers, convertTransfer(transfer))
+	}
+	return rsp, nil
+}
+
+// authorizeBankAccount returns a status error unless the bank account belongs to the caller
+func (server *Server) authorizeBankAccount(ctx context.Context, accountID int64) error {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	bankAccount, err := server.store.GetBankAccount(ctx, accountID)
+	if err != nil {
+		return status.Errorf(apierror.GRPCCode(err), "failed to get bank account: %s", err)
+	}
+
+	if bankAccount.Owner != authPayload.Username {
+		return status.Errorf(codes.PermissionDenied, "you are not the owner of this bank account")
+	}
+	return nil
+}
+
+// validatePageRequest checks the page size and decodes the cursor of the page for the scope
+func (server *Server) validatePageRequest(page *pb.PageRequest, scope string) (pagination.Cursor, []*errdetails.BadRequest_FieldViolation) {
+	var violations []*errdetails.BadRequest_FieldViolation
+
+	if page.GetPageSize() < 0 || page.GetPageSize() > pagination.MaxPageSize {
+		violations = append(violations, fieldViolation("page.page_size",
+			fmt.Errorf("must be from 1-%d, or 0 for the default", pagination.MaxPageSize)))
+	}
+
+	cursor, err := server.cursorCodec.Decode(scope, page.GetCursor())
+	if err != nil {
+		violations = append(violations, fieldViolation("page.cursor", err))
+	}
+
+	return cursor, violations
+}
+
+func validateAccountID(field string, accountID int64) []*errdetails.BadRequest_FieldViolation {
+	if accountID < 1 {
+		return []*errdetails.BadRequest_FieldViolation{fieldViolation(field, errors.New("must be a positive integer"))}
+	}
+	return nil
+}
+
+func validateRangeFilter(filter *pb.RangeFilter) (violations []*errdetails.BadRequest_FieldViolation) {
+	if filter.GetMinAmount() < 0 {
+		violations = append(violations, fieldViolation("filter.min_amount", errors.New("must not be negative")))
+	}
+
+	if filter.GetMaxAmount() < 0 || (filter.GetMaxAmount() != 0 && filter.GetMaxAmount() < filter.GetMinAmount()) {
+		violations = append(violations, fieldViolation("filter.max_amount", errors.New("must not be less than min_amount")))
+	}
+
+	fromTime, toTime := timeOf(filter.GetFromTime()), timeOf(filter.GetToTime())
+	if !toTime.IsZero() && !toTime.After(fromTime) {
+		violations = append(violations, fieldViolation("filter.to_time", errors.New("must be after from_time")))
+	}
+
+	return violations
+}
+
+// timeOf returns the time of the timestamp, the zero time if it is not set
+func timeOf(timestamp *timestamppb.Timestamp) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return timestamp.AsTime()
+}
+
+func convertBankAccount(bankAccount db.BankAccount) *pb.BankAccount {
+	account := &pb.BankAccount{
+		Id:               bankAccount.ID,
+		Owner:            bankAccount.Owner,
+		Balance:          bankAccount.Balance,
+		Currency:         bankAccount.Currency,
+		CreatedAt:        timestamppb.New(bankAccount.CreatedAt),
+		Status:           bankAccount.Status,
+		Nickname:         bankAccount.Nickname,
+		AccountNumber:    bankAccount.AccountNumber,
+		Product:          bankAccount.Product,
+		AvailableBalance: bankAccount.AvailableBalance,
+		Held:             bankAccount.Held,
+	}
+	if bankAccount.ClosedAt.Valid {
+		account.ClosedAt = timestamppb.New(bankAccount.ClosedAt.Time)
+	}
+	return account
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    entry.Amount,
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+		Category:  entry.Category,
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+		Memo:          transfer.Memo,
+		Reference:     transfer.Reference,
+		Fee:           transfer.Fee,
+	}
+}
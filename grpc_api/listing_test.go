@@ -0,0 +1,244 @@
+package grpc_api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func randomBankAccount(owner string) db.BankAccount {
+	balance := tools.RandomMoney()
+	return db.BankAccount{
+		ID:               tools.RandomInt(1, 1000),
+		Owner:            owner,
+		Balance:          balance,
+		AvailableBalance: balance,
+		Currency:         tools.RandomCurrency(),
+		CreatedAt:        time.Now().UTC().Truncate(time.Microsecond),
+		Status:           db.AccountStatusActive,
+		Nickname:         tools.RandomString(8),
+	}
+}
+
+func TestListBankAccounts(t *testing.T) {
+	username := randomUsername()
+
+	n := 3
+	bankAccounts := make([]db.BankAccount, n)
+	for i := range bankAccounts {
+		bankAccounts[i] = randomBankAccount(username)
+	}
+	bankAccounts[0].Held = 10
+	bankAccounts[0].AvailableBalance = bankAccounts[0].Balance - 10
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListBankAccounts(gomock.Any(), gomock.Eq(db.ListBankAccountsParams{
+			Owner:    username,
+			Currency: db.NullString(tools.USD),
+			Limit:    int32(n),
+		})).
+		Times(1).
+		Return(bankAccounts, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+
+	rsp, err := client.ListBankAccounts(ctx, &pb.ListBankAccountsRequest{
+		Page:     &pb.PageRequest{PageSize: int32(n - 1)},
+		Currency: tools.USD,
+	})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetBankAccounts(), n-1)
+	require.Equal(t, bankAccounts[0].ID, rsp.GetBankAccounts()[0].GetId())
+	require.Equal(t, bankAccounts[0].Balance, rsp.GetBankAccounts()[0].GetBalance())
+	require.Equal(t, bankAccounts[0].Balance-10, rsp.GetBankAccounts()[0].GetAvailableBalance())
+	require.Equal(t, int64(10), rsp.GetBankAccounts()[0].GetHeld())
+
+	cursor, err := server.cursorCodec.Decode(pagination.Scope("bank_accounts", username), rsp.GetNextCursor())
+	require.NoError(t, err)
+	require.Equal(t, bankAccounts[n-2].ID, cursor.ID)
+	require.True(t, bankAccounts[n-2].CreatedAt.Equal(cursor.CreatedAt))
+
+	// the cursor is bound to the owner of the listing
+	otherCtx := withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err = client.ListBankAccounts(otherCtx, &pb.ListBankAccountsRequest{
+		Page: &pb.PageRequest{Cursor: rsp.GetNextCursor()},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListEntries(t *testing.T) {
+	username := randomUsername()
+	bankAccount := randomBankAccount(username)
+
+	entry := db.Entry{
+		ID:        tools.RandomInt(1, 1000),
+		AccountID: bankAccount.ID,
+		Amount:    tools.RandomMoney(),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	fromTime := entry.CreatedAt.Add(-time.Hour)
+
+	testCases := []struct {
+		name       string
+		req        *pb.ListEntriesRequest
+		username   string
+		buildStubs func(store *mockdb.MockStore)
+		checkCode  codes.Code
+	}{
+		{
+			name: "OK",
+			req: &pb.ListEntriesRequest{
+				AccountId: bankAccount.ID,
+				Filter: &pb.RangeFilter{
+					FromTime:  timestamppb.New(fromTime),
+					MinAmount: 1,
+				},
+				Category: "groceries",
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Eq(db.ListEntriesParams{
+						AccountID: bankAccount.ID,
+						FromTime:  db.NullTime(fromTime),
+						MinAmount: db.NullInt64(1),
+						Category:  db.NullString("groceries"),
+						Limit:     pagination.DefaultPageSize + 1,
+					})).
+					Times(1).
+					Return([]db.Entry{entry}, nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name:     "PermissionDenied",
+			req:      &pb.ListEntriesRequest{AccountId: bankAccount.ID},
+			username: randomUsername(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(bankAccount, nil)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkCode: codes.PermissionDenied,
+		},
+		{
+			name:     "NotFound",
+			req:      &pb.ListEntriesRequest{AccountId: bankAccount.ID},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+					Times(1).
+					Return(db.BankAccount{}, db.ErrRecordNotFound)
+			},
+			checkCode: codes.NotFound,
+		},
+		{
+			name: "InvalidArguments",
+			req: &pb.ListEntriesRequest{
+				Page:   &pb.PageRequest{PageSize: pagination.MaxPageSize + 1, Cursor: "not-a-cursor"},
+				Filter: &pb.RangeFilter{MinAmount: 10, MaxAmount: 5},
+			},
+			username: username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkCode: codes.InvalidArgument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := newTestClient(t, server)
+
+			ctx := withAccessToken(t, server.tokenGenerator, tc.username, tools.DepositorRole)
+			rsp, err := client.ListEntries(ctx, tc.req)
+			require.Equal(t, tc.checkCode, status.Code(err))
+			if tc.checkCode == codes.OK {
+				require.Len(t, rsp.GetEntries(), 1)
+				require.Equal(t, entry.Amount, rsp.GetEntries()[0].GetAmount())
+				require.Empty(t, rsp.GetNextCursor())
+			}
+		})
+	}
+}
+
+func TestListAccountTransfers(t *testing.T) {
+	username := randomUsername()
+	bankAccount := randomBankAccount(username)
+	counterparty := randomBankAccount(randomUsername())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetBankAccount(gomock.Any(), gomock.Eq(bankAccount.ID)).
+		Times(1).
+		Return(bankAccount, nil)
+	store.EXPECT().
+		ListTransfers(gomock.Any(), gomock.Eq(db.ListTransfersParams{
+			AccountID:      bankAccount.ID,
+			CounterpartyID: db.NullInt64(counterparty.ID),
+			Limit:          pagination.DefaultPageSize + 1,
+		})).
+		Times(1).
+		Return([]db.Transfer{{
+			ID:            tools.RandomInt(1, 1000),
+			FromAccountID: bankAccount.ID,
+			ToAccountID:   counterparty.ID,
+			Amount:        tools.RandomMoney(),
+			CreatedAt:     time.Now(),
+		}}, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+
+	rsp, err := client.ListAccountTransfers(ctx, &pb.ListAccountTransfersRequest{
+		AccountId:      bankAccount.ID,
+		CounterpartyId: counterparty.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetTransfers(), 1)
+	require.Equal(t, counterparty.ID, rsp.GetTransfers()[0].GetToAccountId())
+
+	_, err = client.ListAccountTransfers(context.Background(), &pb.ListAccountTransfersRequest{AccountId: bankAccount.ID})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
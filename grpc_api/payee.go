@@ -0,0 +1,278 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreatePayee adds a payee to the address book of the caller. When step-up is required
+// for new payees, the payee cannot receive transfers until it is confirmed with ConfirmPayee.
+func (server *Server) CreatePayee(ctx context.Context, req *pb.CreatePayeeRequest) (*pb.CreatePayeeResponse, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	accountNumber := tools.NormalizeAccountNumber(req.GetAccountNumber())
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := ValidateString(req.GetDisplayName(), 1, 50); err != nil {
+		violations = append(violations, fieldViolation("display_name", err))
+	}
+	if !tools.IsValidAccountNumber(accountNumber) {
+		violations = append(violations, fieldViolation("account_number", errors.New("is not a valid account number")))
+	}
+	if err := ValidateString(req.GetNote(), 0, 200); err != nil {
+		violations = append(violations, fieldViolation("note", err))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetBankAccountByNumber(ctx, accountNumber)
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to get bank account: %s", err)
+	}
+
+	if account.Status == db.AccountStatusClosed {
+		err := fmt.Errorf("bank account [%s] is closed: %w", account.AccountNumber, db.ErrAccountNotActive)
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to create payee: %s", err)
+	}
+
+	payee, err := server.store.CreatePayee(ctx, db.CreatePayeeParams{
+		Owner:         authPayload.Username,
+		DisplayName:   req.GetDisplayName(),
+		AccountNumber: account.AccountNumber,
+		Currency:      account.Currency,
+		Note:          req.GetNote(),
+		Confirmed:     !server.config.PayeeStepUpRequired,
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to create payee: %s", err)
+	}
+
+	rsp := &pb.CreatePayeeResponse{
+		Payee:                convertPayee(payee, false),
+		ConfirmationRequired: !payee.ConfirmedAt.Valid,
+	}
+	return rsp, nil
+}
+
+// GetPayee gets a payee of the caller
+func (server *Server) GetPayee(ctx context.Context, req *pb.GetPayeeRequest) (*pb.GetPayeeResponse, error) {
+	payee, err := server.ownedPayee(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.GetPayeeResponse{
+		Payee: convertPayee(payee.Payee, payee.TargetClosed),
+	}
+	return rsp, nil
+}
+
+// ListPayees lists the address book of the caller, oldest first
+func (server *Server) ListPayees(ctx context.Context, req *pb.ListPayeesRequest) (*pb.ListPayeesResponse, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	scope := pagination.Scope("payees", authPayload.Username)
+	cursor, violations := server.validatePageRequest(req.GetPage(), scope)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	rows, err := server.store.ListPayees(ctx, db.ListPayeesParams{
+		Owner:           authPayload.Username,
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to list payees: %s", err)
+	}
+
+	rows, nextCursor := pagination.Page(server.cursorCodec, scope, rows, req.GetPage().GetPageSize(), payeeCursor)
+
+	rsp := &pb.ListPayeesResponse{NextCursor: nextCursor}
+	for _, row := range rows {
+		rsp.Payees = append(rsp.Payees, convertPayee(row.Payee, row.TargetClosed))
+	}
+	return rsp, nil
+}
+
+// UpdatePayee renames a payee of the caller or changes its note
+func (server *Server) UpdatePayee(ctx context.Context, req *pb.UpdatePayeeRequest) (*pb.UpdatePayeeResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.DisplayName != nil {
+		if err := ValidateString(req.GetDisplayName(), 1, 50); err != nil {
+			violations = append(violations, fieldViolation("display_name", err))
+		}
+	}
+	if err := ValidateString(req.GetNote(), 0, 200); err != nil {
+		violations = append(violations, fieldViolation("note", err))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	current, err := server.ownedPayee(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	payee, err := server.store.UpdatePayee(ctx, db.UpdatePayeeParams{
+		ID: req.GetId(),
+		DisplayName: pgtype.Text{
+			String: req.GetDisplayName(),
+			Valid:  req.DisplayName != nil,
+		},
+		Note: pgtype.Text{
+			String: req.GetNote(),
+			Valid:  req.Note != nil,
+		},
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to update payee: %s", err)
+	}
+
+	rsp := &pb.UpdatePayeeResponse{
+		Payee: convertPayee(payee, current.TargetClosed),
+	}
+	return rsp, nil
+}
+
+// DeletePayee removes a payee from the address book of the caller
+func (server *Server) DeletePayee(ctx context.Context, req *pb.DeletePayeeRequest) (*pb.DeletePayeeResponse, error) {
+	if _, err := server.ownedPayee(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := server.store.DeletePayee(ctx, req.GetId()); err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to delete payee: %s", err)
+	}
+
+	return &pb.DeletePayeeResponse{}, nil
+}
+
+// ConfirmPayee confirms a pending payee of the caller with their password or a TOTP code
+func (server *Server) ConfirmPayee(ctx context.Context, req *pb.ConfirmPayeeRequest) (*pb.ConfirmPayeeResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.GetPassword() == "" && req.GetTotpCode() == "" {
+		violations = append(violations, fieldViolation("password", errors.New("either password or totp_code is required")))
+	}
+	if req.GetTotpCode() != "" && len(req.GetTotpCode()) != 6 {
+		violations = append(violations, fieldViolation("totp_code", errors.New("must contain 6 digits")))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	current, err := server.ownedPayee(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Payee.ConfirmedAt.Valid {
+		return nil, status.Errorf(codes.FailedPrecondition, "payee is already confirmed")
+	}
+
+	user, err := server.store.GetUser(ctx, current.Payee.Owner)
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to get user: %s", err)
+	}
+
+	if req.GetTotpCode() != "" {
+		if !user.TotpSecret.Valid {
+			return nil, status.Errorf(codes.FailedPrecondition, "totp is not enabled for this user")
+		}
+		step, ok := tools.ValidateTOTP(user.TotpSecret.String, req.GetTotpCode(), time.Now())
+		if !ok {
+			return nil, unauthenticatedError(errors.New("incorrect totp code"))
+		}
+		_, err := server.store.UseUserTOTPStep(ctx, db.UseUserTOTPStepParams{
+			Username: user.Username,
+			Step:     step,
+		})
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, unauthenticatedError(errors.New("totp code was already used, wait for the next one"))
+		}
+		if err != nil {
+			return nil, status.Errorf(apierror.GRPCCode(err), "failed to use totp code: %s", err)
+		}
+	} else if err := tools.CheckPassword(req.GetPassword(), user.HashedPassword); err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	payee, err := server.store.ConfirmPayee(ctx, req.GetId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "payee is already confirmed")
+		}
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to confirm payee: %s", err)
+	}
+
+	rsp := &pb.ConfirmPayeeResponse{
+		Payee: convertPayee(payee, current.TargetClosed),
+	}
+	return rsp, nil
+}
+
+// ownedPayee gets a payee of the caller, other payees are denied
+func (server *Server) ownedPayee(ctx context.Context, id int64) (db.GetPayeeRow, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return db.GetPayeeRow{}, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	if id < 1 {
+		return db.GetPayeeRow{}, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("id", errors.New("must be a positive integer")),
+		})
+	}
+
+	payee, err := server.store.GetPayee(ctx, id)
+	if err != nil {
+		return payee, status.Errorf(apierror.GRPCCode(err), "failed to get payee: %s", err)
+	}
+
+	if payee.Payee.Owner != authPayload.Username {
+		return payee, status.Errorf(codes.PermissionDenied, "payee doesn't belong to the caller")
+	}
+	return payee, nil
+}
+
+func convertPayee(payee db.Payee, targetClosed bool) *pb.Payee {
+	rsp := &pb.Payee{
+		Id:            payee.ID,
+		DisplayName:   payee.DisplayName,
+		AccountNumber: payee.AccountNumber,
+		Currency:      payee.Currency,
+		Note:          payee.Note,
+		CreatedAt:     timestamppb.New(payee.CreatedAt),
+		TargetClosed:  targetClosed,
+	}
+	if payee.ConfirmedAt.Valid {
+		rsp.ConfirmedAt = timestamppb.New(payee.ConfirmedAt.Time)
+	}
+	return rsp
+}
+
+func payeeCursor(row db.ListPayeesRow) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: row.Payee.CreatedAt, ID: row.Payee.ID}
+}
@@ -0,0 +1,182 @@
+package grpc_api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreatePayee(t *testing.T) {
+	username := randomUsername()
+	target := randomBankAccount(randomUsername())
+	target.AccountNumber = randomAccountNumber(t)
+	payee := randomPayee(username, target)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetBankAccountByNumber(gomock.Any(), gomock.Eq(target.AccountNumber)).
+		Times(1).
+		Return(target, nil)
+	store.EXPECT().
+		CreatePayee(gomock.Any(), gomock.Eq(db.CreatePayeeParams{
+			Owner:         username,
+			DisplayName:   payee.DisplayName,
+			AccountNumber: target.AccountNumber,
+			Currency:      target.Currency,
+			Confirmed:     false,
+		})).
+		Times(1).
+		Return(payee, nil)
+
+	server := newTestServer(t, store)
+	server.config.PayeeStepUpRequired = true
+	client := newTestClient(t, server)
+
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+	rsp, err := client.CreatePayee(ctx, &pb.CreatePayeeRequest{
+		DisplayName:   payee.DisplayName,
+		AccountNumber: target.AccountNumber,
+	})
+	require.NoError(t, err)
+	require.True(t, rsp.GetConfirmationRequired())
+	require.Equal(t, payee.ID, rsp.GetPayee().GetId())
+	require.Nil(t, rsp.GetPayee().GetConfirmedAt())
+
+	_, err = client.CreatePayee(ctx, &pb.CreatePayeeRequest{
+		DisplayName:   payee.DisplayName,
+		AccountNumber: tools.MistypedAccountNumber(target.AccountNumber),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestConfirmPayee(t *testing.T) {
+	username := randomUsername()
+	password := tools.RandomString(8)
+	hashedPassword, err := tools.HashPassword(password)
+	require.NoError(t, err)
+	user := db.User{Username: username, HashedPassword: hashedPassword}
+
+	target := randomBankAccount(randomUsername())
+	target.AccountNumber = randomAccountNumber(t)
+	payee := randomPayee(username, target)
+
+	confirmed := payee
+	confirmed.ConfirmedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(2).Return(db.GetPayeeRow{Payee: payee}, nil),
+		store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(db.GetPayeeRow{Payee: confirmed}, nil),
+	)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(2).Return(user, nil)
+	store.EXPECT().ConfirmPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(confirmed, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+
+	_, err = client.ConfirmPayee(ctx, &pb.ConfirmPayeeRequest{Id: payee.ID, Password: "wrong-password"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	rsp, err := client.ConfirmPayee(ctx, &pb.ConfirmPayeeRequest{Id: payee.ID, Password: password})
+	require.NoError(t, err)
+	require.NotNil(t, rsp.GetPayee().GetConfirmedAt())
+
+	_, err = client.ConfirmPayee(ctx, &pb.ConfirmPayeeRequest{Id: payee.ID, Password: password})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.ConfirmPayee(ctx, &pb.ConfirmPayeeRequest{Id: payee.ID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListPayees(t *testing.T) {
+	username := randomUsername()
+
+	rows := make([]db.ListPayeesRow, 2)
+	for i := range rows {
+		target := randomBankAccount(randomUsername())
+		target.AccountNumber = randomAccountNumber(t)
+		rows[i] = db.ListPayeesRow{Payee: randomPayee(username, target)}
+	}
+	rows[1].TargetClosed = true
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListPayees(gomock.Any(), gomock.Eq(db.ListPayeesParams{
+			Owner: username,
+			Limit: 11,
+		})).
+		Times(1).
+		Return(rows, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+	rsp, err := client.ListPayees(ctx, &pb.ListPayeesRequest{})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetPayees(), 2)
+	require.False(t, rsp.GetPayees()[0].GetTargetClosed())
+	require.True(t, rsp.GetPayees()[1].GetTargetClosed())
+	require.Empty(t, rsp.GetNextCursor())
+}
+
+func TestDeletePayee(t *testing.T) {
+	username := randomUsername()
+	target := randomBankAccount(randomUsername())
+	target.AccountNumber = randomAccountNumber(t)
+	payee := randomPayee(username, target)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(2).Return(db.GetPayeeRow{Payee: payee}, nil)
+	store.EXPECT().DeletePayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	otherCtx := withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err := client.DeletePayee(otherCtx, &pb.DeletePayeeRequest{Id: payee.ID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+	_, err = client.DeletePayee(ctx, &pb.DeletePayeeRequest{Id: payee.ID})
+	require.NoError(t, err)
+}
+
+func randomPayee(owner string, target db.BankAccount) db.Payee {
+	return db.Payee{
+		ID:            tools.RandomInt(1, 1000),
+		Owner:         owner,
+		DisplayName:   tools.RandomOwner(),
+		AccountNumber: target.AccountNumber,
+		Currency:      target.Currency,
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func randomAccountNumber(t *testing.T) string {
+	accountNumber, err := tools.NewAccountNumber()
+	require.NoError(t, err)
+	return accountNumber
+}
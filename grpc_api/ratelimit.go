@@ -0,0 +1,67 @@
+package grpc_api
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimitInterceptor limits the calls per method of every authenticated user,
+// or of every client IP for calls that are not authenticated
+func (server *Server) RateLimitInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if ok, retryAfter := server.rateLimiter.Allow(info.FullMethod, server.rateLimitCaller(ctx)); !ok {
+		return nil, rateLimitedError(retryAfter)
+	}
+
+	return handler(ctx, req)
+}
+
+// RateLimitStreamInterceptor limits the streaming calls like RateLimitInterceptor limits the unary ones
+func (server *Server) RateLimitStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if ok, retryAfter := server.rateLimiter.Allow(info.FullMethod, server.rateLimitCaller(stream.Context())); !ok {
+		return rateLimitedError(retryAfter)
+	}
+
+	return handler(srv, stream)
+}
+
+func (server *Server) rateLimitCaller(ctx context.Context) string {
+	if payload, ok := payloadFromContext(ctx); ok {
+		return "user:" + payload.Username
+	}
+
+	clientIP := server.extractMetadata(ctx).ClientIP
+	if host, _, err := net.SplitHostPort(clientIP); err == nil {
+		clientIP = host
+	}
+	return "ip:" + clientIP
+}
+
+func rateLimitedError(retryAfter time.Duration) error {
+	statusLimited := status.New(codes.ResourceExhausted, "too many requests")
+
+	statusDetails, detailsErr := statusLimited.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryAfter),
+	})
+	if detailsErr != nil {
+		return statusLimited.Err()
+	}
+
+	return statusDetails.Err()
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/loginguard"
+	"github.com/radugaf/simplebank/pagination"
 	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/ratelimit"
 	"github.com/radugaf/simplebank/token"
 	"github.com/radugaf/simplebank/tools"
 )
@@ -14,6 +17,10 @@ type Server struct {
 	pb.UnimplementedSimpleBankServer
 	store          db.Store
 	tokenGenerator token.Token
+	loginGuard     *loginguard.Guard
+	passwordHasher *tools.PasswordHasher
+	rateLimiter    *ratelimit.Limiter
+	cursorCodec    *pagination.Codec
 	config         tools.Config
 }
 
@@ -24,10 +31,19 @@ func NewServer(config tools.Config, store db.Store) (*Server, error) {
 		return nil, fmt.Errorf("cannot create token generator: %w", err)
 	}
 
+	cursorCodec, err := pagination.NewCodec(config.CursorSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cursor codec: %w", err)
+	}
+
 	server := &Server{
 		config:         config,
 		store:          store,
 		tokenGenerator: tokenGenerator,
+		loginGuard:     loginguard.New(store, config),
+		passwordHasher: config.PasswordHasher(),
+		rateLimiter:    ratelimit.NewLimiter(config.RateLimits),
+		cursorCodec:    cursorCodec,
 	}
 
 	return server, nil
@@ -2,24 +2,33 @@ package grpc_api
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/mail"
 	"regexp"
 	"time"
 
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/apierror"
 	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/logging"
+	"github.com/radugaf/simplebank/loginguard"
 	"github.com/radugaf/simplebank/pb"
 	"github.com/radugaf/simplebank/tools"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
-	hashedPassword, err := tools.HashPassword(req.GetPassword())
+	violations := validateCreateUserRequest(req, server.config.PasswordPolicy())
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.GetPassword())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
 	}
@@ -32,13 +41,7 @@ func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 	}
 	user, err := server.store.CreateUser(ctx, userParams)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				return nil, status.Errorf(codes.AlreadyExists, "username or email already exists")
-			}
-		}
-		return nil, status.Errorf(codes.Internal, "failed to create user: %s", err)
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to create user: %s", err)
 	}
 
 	response := &pb.CreateUserResponse{
@@ -60,21 +63,43 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, invalidArgumentError(violations)
 	}
 
-	user, err := server.store.GetUser(ctx, req.GetUsername())
+	mtdt := server.extractMetadata(ctx)
+	attempt, err := server.loginGuard.Begin(ctx, req.GetUsername(), mtdt.ClientIP)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, status.Errorf(codes.NotFound, "user not found")
+		var lockedErr *loginguard.LockedError
+		if errors.As(err, &lockedErr) {
+			return nil, lockedOutError(lockedErr)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to find user")
+		return nil, status.Errorf(codes.Internal, "failed to check login attempts")
 	}
 
-	err = tools.CheckPassword(req.Password, user.HashedPassword)
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		if err := attempt.Release(); err != nil {
+			logging.FromContext(ctx).Error().Err(err).Str("username", req.GetUsername()).Msg("cannot release login attempt")
+		}
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to find user")
+	}
+
+	err = loginguard.VerifyPassword(req.GetPassword(), user.HashedPassword, err == nil)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "incorrect password")
+		if err := attempt.Fail(); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record login attempt")
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+	}
+
+	if err := attempt.Succeed(); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to reset login attempts")
+	}
+
+	if err := loginguard.UpgradePasswordHash(ctx, server.store, server.passwordHasher, user, req.GetPassword()); err != nil {
+		logging.FromContext(ctx).Error().Err(err).Str("username", user.Username).Msg("cannot upgrade password hash")
 	}
 
 	accessToken, accessPayload, err := server.tokenGenerator.GenerateToken(
 		user.Username,
+		user.Role,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -83,13 +108,13 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 
 	refreshToken, refreshPayload, err := server.tokenGenerator.GenerateToken(
 		user.Username,
+		user.Role,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create refresh token")
 	}
 
-	mtdt := server.extractMetadata(ctx)
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
 		Username:     user.Username,
@@ -100,7 +125,7 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		ExpiresAt:    refreshPayload.ExpiresAt,
 	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create session")
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to create session")
 	}
 
 	rsp := &pb.LoginUserResponse{
@@ -116,12 +141,12 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 }
 
 func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
-	authPayload, err := server.authorizeUser(ctx)
-	if err != nil {
-		return nil, unauthenticatedError(err)
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
 	}
 
-	violations := validateUpdateUserRequest(req)
+	violations := validateUpdateUserRequest(req, server.config.PasswordPolicy())
 	if violations != nil {
 		return nil, invalidArgumentError(violations)
 	}
@@ -132,28 +157,28 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 
 	arg := db.UpdateUserParams{
 		Username: req.GetUsername(),
-		FullName: sql.NullString{
+		FullName: pgtype.Text{
 			String: req.GetFullName(),
 			Valid:  req.FullName != nil,
 		},
-		Email: sql.NullString{
+		Email: pgtype.Text{
 			String: req.GetEmail(),
 			Valid:  req.Email != nil,
 		},
 	}
 
 	if req.Password != nil {
-		hashedPassword, err := tools.HashPassword(req.GetPassword())
+		hashedPassword, err := server.passwordHasher.Hash(req.GetPassword())
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
 		}
 
-		arg.HashedPassword = sql.NullString{
+		arg.HashedPassword = pgtype.Text{
 			String: hashedPassword,
 			Valid:  true,
 		}
 
-		arg.PasswordChangedAt = sql.NullTime{
+		arg.PasswordChangedAt = pgtype.Timestamptz{
 			Time:  time.Now(),
 			Valid: true,
 		}
@@ -161,10 +186,7 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 
 	user, err := server.store.UpdateUser(ctx, arg)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, status.Errorf(codes.NotFound, "user not found")
-		}
-		return nil, status.Errorf(codes.Internal, "failed to update user: %s", err)
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to update user: %s", err)
 	}
 
 	rsp := &pb.UpdateUserResponse{
@@ -173,13 +195,48 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 	return rsp, nil
 }
 
-func validateUpdateUserRequest(req *pb.UpdateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+func (server *Server) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	if err := ValidateUsername(req.GetUsername()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("username", err)})
+	}
+
+	if err := server.loginGuard.Unlock(ctx, req.GetUsername()); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unlock user: %s", err)
+	}
+
+	rsp := &pb.UnlockUserResponse{
+		Username: req.GetUsername(),
+	}
+	return rsp, nil
+}
+
+func validateCreateUserRequest(req *pb.CreateUserRequest, policy tools.PasswordPolicy) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if err := policy.Validate(req.GetPassword()); err != nil {
+		violations = append(violations, fieldViolation("password", err))
+	}
+
+	if err := ValidateFullName(req.GetFullName()); err != nil {
+		violations = append(violations, fieldViolation("full_name", err))
+	}
+
+	if err := ValidateEmail(req.GetEmail()); err != nil {
+		violations = append(violations, fieldViolation("email", err))
+	}
+
+	return violations
+}
+
+func validateUpdateUserRequest(req *pb.UpdateUserRequest, policy tools.PasswordPolicy) (violations []*errdetails.BadRequest_FieldViolation) {
 	if err := ValidateUsername(req.GetUsername()); err != nil {
 		violations = append(violations, fieldViolation("username", err))
 	}
 
 	if req.Password != nil {
-		if err := ValidatePassword(req.GetPassword()); err != nil {
+		if err := policy.Validate(req.GetPassword()); err != nil {
 			violations = append(violations, fieldViolation("password", err))
 		}
 	}
@@ -234,6 +291,19 @@ func unauthenticatedError(err error) error {
 	return status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
 }
 
+func lockedOutError(err *loginguard.LockedError) error {
+	statusLocked := status.New(codes.ResourceExhausted, err.Error())
+
+	statusDetails, detailsErr := statusLocked.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(err.RetryAfter),
+	})
+	if detailsErr != nil {
+		return statusLocked.Err()
+	}
+
+	return statusDetails.Err()
+}
+
 var (
 	isValidUsername = regexp.MustCompile(`^[a-z0-9_]+$`).MatchString
 	isValidFullName = regexp.MustCompile(`^[a-zA-Z\s]+$`).MatchString
@@ -267,8 +337,10 @@ func ValidateFullName(value string) error {
 	return nil
 }
 
+// ValidatePassword only checks the length of a password being logged in with.
+// New passwords are checked against the configured tools.PasswordPolicy instead.
 func ValidatePassword(value string) error {
-	return ValidateString(value, 6, 100)
+	return ValidateString(value, 1, tools.DefaultPasswordPolicy.MaxLength)
 }
 
 func ValidateEmail(value string) error {
@@ -288,5 +360,6 @@ func convertUser(user db.User) *pb.User {
 		Email:             user.Email,
 		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
 		CreatedAt:         timestamppb.New(user.CreatedAt),
+		Tier:              user.Tier,
 	}
 }
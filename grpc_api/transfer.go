@@ -0,0 +1,104 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var transferDirections = map[pb.TransferDirection]string{
+	pb.TransferDirection_TRANSFER_DIRECTION_IN:  db.TransferDirectionIn,
+	pb.TransferDirection_TRANSFER_DIRECTION_OUT: db.TransferDirectionOut,
+}
+
+// ListTransfers lists the transfers from or to any account of the caller, newest first
+func (server *Server) ListTransfers(ctx context.Context, req *pb.ListTransfersRequest) (*pb.ListTransfersResponse, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	scope := pagination.Scope("owner_transfers", authPayload.Username)
+	cursor, violations := server.validatePageRequest(req.GetPage(), scope)
+	violations = append(violations, validateRangeFilter(req.GetFilter())...)
+	if req.GetAccountId() != 0 {
+		violations = append(violations, validateAccountID("account_id", req.GetAccountId())...)
+	}
+	direction, ok := transferDirections[req.GetDirection()]
+	if !ok && req.GetDirection() != pb.TransferDirection_TRANSFER_DIRECTION_UNSPECIFIED {
+		violations = append(violations, fieldViolation("direction", errors.New("is not a valid direction")))
+	}
+	if req.GetCurrency() != "" && !tools.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", fmt.Errorf("is not a supported currency")))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	filter := req.GetFilter()
+	transfers, err := server.store.ListOwnerTransfers(ctx, db.ListOwnerTransfersParams{
+		Owner:           authPayload.Username,
+		Direction:       db.NullString(direction),
+		AccountID:       db.NullInt64(req.GetAccountId()),
+		Currency:        db.NullString(req.GetCurrency()),
+		FromTime:        db.NullTime(timeOf(filter.GetFromTime())),
+		ToTime:          db.NullTime(timeOf(filter.GetToTime())),
+		MinAmount:       db.NullInt64(filter.GetMinAmount()),
+		MaxAmount:       db.NullInt64(filter.GetMaxAmount()),
+		CursorCreatedAt: db.NullTime(cursor.CreatedAt),
+		CursorID:        cursor.ID,
+		Limit:           pagination.Limit(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to list transfers: %s", err)
+	}
+
+	transfers, nextCursor := pagination.Page(server.cursorCodec, scope, transfers, req.GetPage().GetPageSize(), transferCursor)
+
+	rsp := &pb.ListTransfersResponse{NextCursor: nextCursor}
+	for _, transfer := range transfers {
+		rsp.Transfers = append(rsp.Transfers, convertTransfer(transfer))
+	}
+	return rsp, nil
+}
+
+// GetTransfer gets a transfer from or to an account of the caller.
+// Other transfers are reported as not found, so that their IDs are not disclosed.
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	if req.GetId() < 1 {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("id", errors.New("must be a positive integer")),
+		})
+	}
+
+	transfer, err := server.store.GetOwnerTransfer(ctx, db.GetOwnerTransferParams{
+		ID:    req.GetId(),
+		Owner: authPayload.Username,
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to get transfer: %s", err)
+	}
+
+	rsp := &pb.GetTransferResponse{
+		Transfer: convertTransfer(transfer),
+	}
+	return rsp, nil
+}
+
+func transferCursor(transfer db.Transfer) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: transfer.CreatedAt, ID: transfer.ID}
+}
@@ -0,0 +1,344 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var transferBatchModes = map[pb.TransferBatchMode]string{
+	pb.TransferBatchMode_TRANSFER_BATCH_MODE_ATOMIC:   db.TransferBatchModeAtomic,
+	pb.TransferBatchMode_TRANSFER_BATCH_MODE_PER_ITEM: db.TransferBatchModePerItem,
+}
+
+// CreateTransferBatch receives a batch of transfers from one account of the caller: the header
+// first, then the items. Every item is checked once the stream is closed, before any of them runs,
+// and the batch is stored so that its outcome can be fetched again with GetTransferBatch.
+func (server *Server) CreateTransferBatch(stream pb.SimpleBank_CreateTransferBatchServer) error {
+	ctx := stream.Context()
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	header, items, err := receiveTransferBatch(stream)
+	if err != nil {
+		return err
+	}
+
+	violations := server.validateTransferBatch(header, items)
+	if violations != nil {
+		return invalidArgumentError(violations)
+	}
+
+	if err := server.checkBatchSender(ctx, authPayload.Username, header); err != nil {
+		return err
+	}
+
+	arg := db.TransferBatchTxParams{
+		Owner:         authPayload.Username,
+		FromAccountID: header.GetFromAccountId(),
+		Currency:      header.GetCurrency(),
+		Mode:          transferBatchModes[header.GetMode()],
+		Items:         make([]db.TransferBatchItemParams, len(items)),
+	}
+	for i, item := range items {
+		arg.Items[i] = db.TransferBatchItemParams{
+			ToAccountID:     item.GetToAccountId(),
+			ToAccountNumber: tools.NormalizeAccountNumber(item.GetToAccountNumber()),
+			PayeeID:         item.GetPayeeId(),
+			Amount:          item.GetAmount(),
+			Memo:            item.GetMemo(),
+			Reference:       tools.NormalizeCreditorReference(item.GetReference()),
+		}
+	}
+
+	total, _ := arg.TotalAmount()
+	if threshold, ok := server.config.StepUpThresholds.Get(arg.Currency); ok && total > threshold {
+		if header.GetStepUpChallengeId() == "" {
+			return server.issueBatchStepUpChallenge(ctx, arg, total)
+		}
+		arg.StepUpChallengeID = uuid.NullUUID{UUID: uuid.MustParse(header.GetStepUpChallengeId()), Valid: true}
+	}
+
+	result, err := server.store.TransferBatchTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrStepUpChallengeUnusable) {
+			return status.Errorf(codes.Unauthenticated, "failed to run transfer batch: %s", err)
+		}
+		var validationErr *db.TransferBatchValidationError
+		if errors.As(err, &validationErr) {
+			for _, item := range validationErr.Items {
+				violations = append(violations, fieldViolation(fmt.Sprintf("items[%d]", item.Index), errors.New(item.Error)))
+			}
+			return invalidArgumentError(violations)
+		}
+		return status.Errorf(apierror.GRPCCode(err), "failed to run transfer batch: %s", err)
+	}
+
+	transferBatch, err := server.convertTransferBatch(ctx, result.Batch, result.Items)
+	if err != nil {
+		return status.Errorf(apierror.GRPCCode(err), "failed to list transfer batch recipients: %s", err)
+	}
+
+	return stream.SendAndClose(&pb.CreateTransferBatchResponse{
+		TransferBatch: transferBatch,
+	})
+}
+
+// issueBatchStepUpChallenge creates a challenge bound to the batch and returns the
+// PERMISSION_DENIED error that hands it to the client
+func (server *Server) issueBatchStepUpChallenge(ctx context.Context, arg db.TransferBatchTxParams, total int64) error {
+	challengeID, err := uuid.NewRandom()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to generate step-up challenge id: %s", err)
+	}
+
+	challenge, err := server.store.CreateStepUpChallenge(ctx, db.CreateStepUpChallengeParams{
+		ID:            challengeID,
+		Username:      arg.Owner,
+		FromAccountID: arg.FromAccountID,
+		BatchHash:     pgtype.Text{String: arg.Hash(), Valid: true},
+		Amount:        total,
+		Currency:      arg.Currency,
+		ExpiresAt:     time.Now().Add(server.config.StepUpChallengeDuration),
+	})
+	if err != nil {
+		return status.Errorf(apierror.GRPCCode(err), "failed to create step-up challenge: %s", err)
+	}
+
+	statusDenied := status.New(codes.PermissionDenied, "step-up authentication required")
+	statusDetails, err := statusDenied.WithDetails(&errdetails.ErrorInfo{
+		Reason: "STEP_UP_REQUIRED",
+		Metadata: map[string]string{
+			"challenge_id": challenge.ID.String(),
+			"expires_at":   challenge.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return statusDenied.Err()
+	}
+	return statusDetails.Err()
+}
+
+// receiveTransferBatch reads the header and the items of a batch until the client closes the stream
+func receiveTransferBatch(stream pb.SimpleBank_CreateTransferBatchServer) (*pb.TransferBatchHeader, []*pb.TransferBatchItemRequest, error) {
+	var header *pb.TransferBatchHeader
+	var items []*pb.TransferBatchItemRequest
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch payload := req.GetPayload().(type) {
+		case *pb.CreateTransferBatchRequest_Header:
+			if header != nil || items != nil {
+				return nil, nil, status.Errorf(codes.InvalidArgument, "the header must be the first message and is sent once")
+			}
+			header = payload.Header
+		case *pb.CreateTransferBatchRequest_Item:
+			if header == nil {
+				return nil, nil, status.Errorf(codes.InvalidArgument, "the header must be the first message and is sent once")
+			}
+			// stop reading a stream that is already too long
+			if len(items) == db.MaxTransferBatchItems {
+				return nil, nil, status.Errorf(codes.InvalidArgument, "a batch has at most %d items", db.MaxTransferBatchItems)
+			}
+			items = append(items, payload.Item)
+		default:
+			return nil, nil, status.Errorf(codes.InvalidArgument, "message has no header nor item")
+		}
+	}
+
+	if header == nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "missing batch header")
+	}
+	return header, items, nil
+}
+
+func (server *Server) validateTransferBatch(header *pb.TransferBatchHeader, items []*pb.TransferBatchItemRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	violations = append(violations, validateAccountID("header.from_account_id", header.GetFromAccountId())...)
+	if !tools.IsSupportedCurrency(header.GetCurrency()) {
+		violations = append(violations, fieldViolation("header.currency", errors.New("is not a supported currency")))
+	}
+	if _, ok := transferBatchModes[header.GetMode()]; !ok {
+		violations = append(violations, fieldViolation("header.mode", errors.New("must be atomic or per item")))
+	}
+	if header.GetStepUpChallengeId() != "" {
+		if _, err := uuid.Parse(header.GetStepUpChallengeId()); err != nil {
+			violations = append(violations, fieldViolation("header.step_up_challenge_id", errors.New("is not a valid uuid")))
+		}
+	}
+	if len(items) == 0 {
+		violations = append(violations, fieldViolation("items", errors.New("must have at least one item")))
+	}
+
+	var total int64
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d]", i)
+
+		recipients := 0
+		if item.GetToAccountId() != 0 {
+			recipients++
+			violations = append(violations, validateAccountID(field+".to_account_id", item.GetToAccountId())...)
+		}
+		if item.GetToAccountNumber() != "" {
+			recipients++
+			if !tools.IsValidAccountNumber(tools.NormalizeAccountNumber(item.GetToAccountNumber())) {
+				violations = append(violations, fieldViolation(field+".to_account_number", errors.New("is not a valid account number")))
+			}
+		}
+		if item.GetPayeeId() != 0 {
+			recipients++
+			violations = append(violations, validateAccountID(field+".payee_id", item.GetPayeeId())...)
+		}
+		if recipients != 1 {
+			violations = append(violations, fieldViolation(field, errors.New("must have exactly one of to_account_id, to_account_number or payee_id")))
+		}
+
+		if item.GetAmount() <= 0 {
+			violations = append(violations, fieldViolation(field+".amount", errors.New("must be positive")))
+		} else if total > math.MaxInt64-item.GetAmount() {
+			violations = append(violations, fieldViolation(field+".amount", db.ErrTransferBatchTooLarge))
+		} else {
+			total += item.GetAmount()
+		}
+		if err := ValidateString(item.GetMemo(), 0, 140); err != nil {
+			violations = append(violations, fieldViolation(field+".memo", err))
+		}
+		if item.GetReference() != "" && !tools.IsValidCreditorReference(tools.NormalizeCreditorReference(item.GetReference())) {
+			violations = append(violations, fieldViolation(field+".reference", errors.New("is not a valid creditor reference")))
+		}
+	}
+
+	return violations
+}
+
+// checkBatchSender returns a status error unless the from account of the batch
+// belongs to the caller, is active and in the currency of the batch
+func (server *Server) checkBatchSender(ctx context.Context, username string, header *pb.TransferBatchHeader) error {
+	account, err := server.store.GetBankAccount(ctx, header.GetFromAccountId())
+	if err != nil {
+		return status.Errorf(apierror.GRPCCode(err), "failed to get bank account: %s", err)
+	}
+
+	if account.Owner != username {
+		return status.Errorf(codes.PermissionDenied, "you are not the owner of this bank account")
+	}
+
+	if account.Currency != header.GetCurrency() {
+		return invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("header.currency", fmt.Errorf("bank account currency mismatch: %s vs %s", account.Currency, header.GetCurrency())),
+		})
+	}
+
+	if account.Status != db.AccountStatusActive {
+		err := fmt.Errorf("bank account [%d] is %s: %w", account.ID, account.Status, db.ErrAccountNotActive)
+		return status.Errorf(apierror.GRPCCode(err), "failed to run transfer batch: %s", err)
+	}
+	return nil
+}
+
+// GetTransferBatch gets a batch of the caller with the outcome of its items
+func (server *Server) GetTransferBatch(ctx context.Context, req *pb.GetTransferBatchRequest) (*pb.GetTransferBatchResponse, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	if req.GetId() < 1 {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("id", errors.New("must be a positive integer")),
+		})
+	}
+
+	batch, err := server.store.GetTransferBatch(ctx, req.GetId())
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to get transfer batch: %s", err)
+	}
+
+	if batch.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "transfer batch doesn't belong to the caller")
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to list transfer batch items: %s", err)
+	}
+
+	transferBatch, err := server.convertTransferBatch(ctx, batch, items)
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to list transfer batch recipients: %s", err)
+	}
+
+	rsp := &pb.GetTransferBatchResponse{
+		TransferBatch: transferBatch,
+	}
+	return rsp, nil
+}
+
+// convertTransferBatch converts the batch with its items, whose recipient accounts can belong
+// to other users and are therefore given by their account numbers
+func (server *Server) convertTransferBatch(ctx context.Context, batch db.TransferBatch, items []db.TransferBatchItem) (*pb.TransferBatch, error) {
+	recipients, err := server.store.ListTransferBatchRecipients(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountNumbers := make(map[int64]string, len(recipients))
+	for _, recipient := range recipients {
+		accountNumbers[recipient.ID] = recipient.AccountNumber
+	}
+
+	rsp := &pb.TransferBatch{
+		Id:             batch.ID,
+		Owner:          batch.Owner,
+		FromAccountId:  batch.FromAccountID,
+		Currency:       batch.Currency,
+		Mode:           batch.Mode,
+		Status:         batch.Status,
+		ItemCount:      batch.ItemCount,
+		TotalAmount:    batch.TotalAmount,
+		SucceededCount: batch.SucceededCount,
+		FailedCount:    batch.FailedCount,
+		Error:          batch.Error,
+		CreatedAt:      timestamppb.New(batch.CreatedAt),
+	}
+	if batch.CompletedAt.Valid {
+		rsp.CompletedAt = timestamppb.New(batch.CompletedAt.Time)
+	}
+
+	for _, item := range items {
+		rsp.Items = append(rsp.Items, &pb.TransferBatchItem{
+			Position:        item.Position,
+			ToAccountNumber: accountNumbers[item.ToAccountID],
+			Amount:          item.Amount,
+			Memo:            item.Memo,
+			Reference:       item.Reference,
+			Status:          item.Status,
+			TransferId:      int64Value(item.TransferID),
+			Error:           item.Error,
+		})
+	}
+	return rsp, nil
+}
@@ -0,0 +1,347 @@
+package grpc_api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sendTransferBatch streams the messages of a batch and returns the response
+func sendTransferBatch(ctx context.Context, client pb.SimpleBankClient, reqs ...*pb.CreateTransferBatchRequest) (*pb.CreateTransferBatchResponse, error) {
+	stream, err := client.CreateTransferBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, req := range reqs {
+		if err := stream.Send(req); err != nil {
+			break
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+func batchHeader(header *pb.TransferBatchHeader) *pb.CreateTransferBatchRequest {
+	return &pb.CreateTransferBatchRequest{Payload: &pb.CreateTransferBatchRequest_Header{Header: header}}
+}
+
+func batchItem(item *pb.TransferBatchItemRequest) *pb.CreateTransferBatchRequest {
+	return &pb.CreateTransferBatchRequest{Payload: &pb.CreateTransferBatchRequest_Item{Item: item}}
+}
+
+func TestCreateTransferBatch(t *testing.T) {
+	username := randomUsername()
+	account := randomBankAccount(username)
+	other := randomBankAccount(randomUsername())
+
+	header := &pb.TransferBatchHeader{
+		FromAccountId: account.ID,
+		Currency:      account.Currency,
+		Mode:          pb.TransferBatchMode_TRANSFER_BATCH_MODE_PER_ITEM,
+	}
+	item := &pb.TransferBatchItemRequest{
+		ToAccountId: account.ID + 1,
+		Amount:      10,
+		Memo:        "rent",
+	}
+
+	batch := db.TransferBatch{
+		ID:             tools.RandomInt(1, 1000),
+		Owner:          username,
+		FromAccountID:  account.ID,
+		Currency:       account.Currency,
+		Mode:           db.TransferBatchModePerItem,
+		Status:         db.TransferBatchStatusCompleted,
+		ItemCount:      1,
+		TotalAmount:    item.Amount,
+		SucceededCount: 1,
+		CreatedAt:      time.Now().UTC().Truncate(time.Microsecond),
+		CompletedAt:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	items := []db.TransferBatchItem{
+		{
+			BatchID:     batch.ID,
+			ToAccountID: item.ToAccountId,
+			Amount:      item.Amount,
+			Memo:        item.Memo,
+			Status:      db.TransferBatchItemStatusSucceeded,
+			TransferID:  pgtype.Int8{Int64: 7, Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		reqs       []*pb.CreateTransferBatchRequest
+		buildStubs func(store *mockdb.MockStore)
+		checkCode  codes.Code
+	}{
+		{
+			name: "OK",
+			reqs: []*pb.CreateTransferBatchRequest{batchHeader(header), batchItem(item)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					TransferBatchTx(gomock.Any(), gomock.Eq(db.TransferBatchTxParams{
+						Owner:         username,
+						FromAccountID: account.ID,
+						Currency:      account.Currency,
+						Mode:          db.TransferBatchModePerItem,
+						Items: []db.TransferBatchItemParams{
+							{ToAccountID: item.ToAccountId, Amount: item.Amount, Memo: item.Memo},
+						},
+					})).
+					Times(1).
+					Return(db.TransferBatchTxResult{Batch: batch, Items: items}, nil)
+				store.EXPECT().
+					ListTransferBatchRecipients(gomock.Any(), gomock.Eq(batch.ID)).
+					Times(1).
+					Return([]db.ListTransferBatchRecipientsRow{{ID: item.ToAccountId, AccountNumber: other.AccountNumber}}, nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "InvalidRecipients",
+			reqs: []*pb.CreateTransferBatchRequest{batchHeader(header), batchItem(item)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					TransferBatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferBatchTxResult{}, &db.TransferBatchValidationError{
+						Items: []db.TransferBatchItemError{{Index: 0, Error: "recipient account not found"}},
+					})
+			},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name: "MissingHeader",
+			reqs: []*pb.CreateTransferBatchRequest{batchItem(item)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name: "InvalidItem",
+			reqs: []*pb.CreateTransferBatchRequest{
+				batchHeader(header),
+				batchItem(&pb.TransferBatchItemRequest{ToAccountId: 1, PayeeId: 2}),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBankAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name: "NoItems",
+			reqs: []*pb.CreateTransferBatchRequest{batchHeader(header)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name: "NotOwner",
+			reqs: []*pb.CreateTransferBatchRequest{
+				batchHeader(&pb.TransferBatchHeader{
+					FromAccountId: other.ID,
+					Currency:      other.Currency,
+					Mode:          pb.TransferBatchMode_TRANSFER_BATCH_MODE_ATOMIC,
+				}),
+				batchItem(item),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBankAccount(gomock.Any(), gomock.Eq(other.ID)).
+					Times(1).
+					Return(other, nil)
+				store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkCode: codes.PermissionDenied,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := newTestClient(t, server)
+			ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+
+			rsp, err := sendTransferBatch(ctx, client, tc.reqs...)
+			require.Equal(t, tc.checkCode, status.Code(err), err)
+			if tc.checkCode == codes.OK {
+				require.Equal(t, batch.ID, rsp.GetTransferBatch().GetId())
+				require.Equal(t, db.TransferBatchStatusCompleted, rsp.GetTransferBatch().GetStatus())
+				require.Len(t, rsp.GetTransferBatch().GetItems(), 1)
+				require.Equal(t, int64(7), rsp.GetTransferBatch().GetItems()[0].GetTransferId())
+				require.Equal(t, other.AccountNumber, rsp.GetTransferBatch().GetItems()[0].GetToAccountNumber())
+			}
+		})
+	}
+}
+
+func TestCreateTransferBatchStepUp(t *testing.T) {
+	username := randomUsername()
+	account := randomBankAccount(username)
+	account.Currency = tools.USD
+
+	header := &pb.TransferBatchHeader{
+		FromAccountId: account.ID,
+		Currency:      tools.USD,
+		Mode:          pb.TransferBatchMode_TRANSFER_BATCH_MODE_ATOMIC,
+	}
+	// every item is below the threshold of 1000, the total is not
+	reqs := []*pb.CreateTransferBatchRequest{
+		batchHeader(header),
+		batchItem(&pb.TransferBatchItemRequest{ToAccountId: account.ID + 1, Amount: 600}),
+		batchItem(&pb.TransferBatchItemRequest{ToAccountId: account.ID + 1, Amount: 401}),
+	}
+	arg := db.TransferBatchTxParams{
+		Owner:         username,
+		FromAccountID: account.ID,
+		Currency:      tools.USD,
+		Mode:          db.TransferBatchModeAtomic,
+		Items: []db.TransferBatchItemParams{
+			{ToAccountID: account.ID + 1, Amount: 600},
+			{ToAccountID: account.ID + 1, Amount: 401},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challengeID := uuid.New()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetBankAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+	store.EXPECT().
+		CreateStepUpChallenge(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.CreateStepUpChallengeParams) (db.StepUpChallenge, error) {
+			return db.StepUpChallenge{ID: challengeID, BatchHash: arg.BatchHash, Amount: arg.Amount, ExpiresAt: arg.ExpiresAt}, nil
+		})
+	confirmed := arg
+	confirmed.StepUpChallengeID = uuid.NullUUID{UUID: challengeID, Valid: true}
+	store.EXPECT().
+		TransferBatchTx(gomock.Any(), gomock.Eq(confirmed)).
+		Times(1).
+		Return(db.TransferBatchTxResult{}, db.ErrStepUpChallengeUnusable)
+
+	server, err := NewServer(tools.Config{
+		TokenSymmetricKey:       tools.RandomString(32),
+		CursorSigningKey:        tools.RandomString(32),
+		AccessTokenDuration:     time.Minute,
+		StepUpThresholds:        tools.CurrencyAmounts{tools.USD: 1000},
+		StepUpChallengeDuration: time.Minute,
+	}, store)
+	require.NoError(t, err)
+	client := newTestClient(t, server)
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+
+	// without a challenge, one bound to the batch is issued
+	_, err = sendTransferBatch(ctx, client, reqs...)
+	require.Equal(t, codes.PermissionDenied, status.Code(err), err)
+
+	st, _ := status.FromError(err)
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, "STEP_UP_REQUIRED", info.GetReason())
+	require.Equal(t, challengeID.String(), info.GetMetadata()["challenge_id"])
+
+	// the challenge is checked when the batch runs
+	header.StepUpChallengeId = challengeID.String()
+	_, err = sendTransferBatch(ctx, client, reqs...)
+	require.Equal(t, codes.Unauthenticated, status.Code(err), err)
+}
+
+func TestCreateTransferBatchUnauthenticated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().TransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	_, err := sendTransferBatch(context.Background(), client, batchHeader(&pb.TransferBatchHeader{}))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGetTransferBatch(t *testing.T) {
+	username := randomUsername()
+	batch := db.TransferBatch{
+		ID:        tools.RandomInt(1, 1000),
+		Owner:     username,
+		Mode:      db.TransferBatchModeAtomic,
+		Status:    db.TransferBatchStatusFailed,
+		ItemCount: 1,
+		Error:     "item 0: account is not active",
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	items := []db.TransferBatchItem{
+		{BatchID: batch.ID, Amount: 10, Status: db.TransferBatchItemStatusFailed},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).
+		Times(2).
+		Return(batch, nil)
+	store.EXPECT().
+		ListTransferBatchItems(gomock.Any(), gomock.Eq(batch.ID)).
+		Times(1).
+		Return(items, nil)
+	store.EXPECT().
+		ListTransferBatchRecipients(gomock.Any(), gomock.Eq(batch.ID)).
+		Times(1).
+		Return([]db.ListTransferBatchRecipientsRow{}, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+	rsp, err := client.GetTransferBatch(ctx, &pb.GetTransferBatchRequest{Id: batch.ID})
+	require.NoError(t, err)
+	require.Equal(t, batch.Error, rsp.GetTransferBatch().GetError())
+	require.Nil(t, rsp.GetTransferBatch().CompletedAt)
+	require.Len(t, rsp.GetTransferBatch().GetItems(), 1)
+	require.Nil(t, rsp.GetTransferBatch().GetItems()[0].TransferId)
+
+	ctx = withAccessToken(t, server.tokenGenerator, randomUsername(), tools.DepositorRole)
+	_, err = client.GetTransferBatch(ctx, &pb.GetTransferBatchRequest{Id: batch.ID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.GetTransferBatch(ctx, &pb.GetTransferBatchRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,98 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/radugaf/simplebank/apierror"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SetTransferLimits overrides the default transfer limits of a user in one currency
+func (server *Server) SetTransferLimits(ctx context.Context, req *pb.SetTransferLimitsRequest) (*pb.SetTransferLimitsResponse, error) {
+	authPayload, ok := payloadFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing access token")
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+	if !tools.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", errors.New("unsupported currency")))
+	}
+	limits := []struct {
+		field string
+		limit *int64
+	}{
+		{db.TransferLimitPerTransfer, req.PerTransfer},
+		{db.TransferLimitDailyAccount, req.DailyAccount},
+		{db.TransferLimitMonthlyAccount, req.MonthlyAccount},
+		{db.TransferLimitDailyUser, req.DailyUser},
+		{db.TransferLimitMonthlyUser, req.MonthlyUser},
+	}
+	for _, l := range limits {
+		if l.limit != nil && *l.limit < 0 {
+			violations = append(violations, fieldViolation(l.field, errors.New("must not be negative")))
+		}
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	override, err := server.store.SetTransferLimitOverride(ctx, db.SetTransferLimitOverrideParams{
+		Username:       req.GetUsername(),
+		Currency:       req.GetCurrency(),
+		PerTransfer:    nullInt64(req.PerTransfer),
+		DailyAccount:   nullInt64(req.DailyAccount),
+		MonthlyAccount: nullInt64(req.MonthlyAccount),
+		DailyUser:      nullInt64(req.DailyUser),
+		MonthlyUser:    nullInt64(req.MonthlyUser),
+		UpdatedBy:      authPayload.Username,
+	})
+	if err != nil {
+		return nil, status.Errorf(apierror.GRPCCode(err), "failed to set transfer limits: %s", err)
+	}
+
+	rsp := &pb.SetTransferLimitsResponse{
+		TransferLimits: convertTransferLimits(override),
+	}
+	return rsp, nil
+}
+
+// nullInt64 returns the optional integer as a nullable parameter, NULL if it is unset
+func nullInt64(limit *int64) pgtype.Int8 {
+	if limit == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *limit, Valid: true}
+}
+
+func int64Value(limit pgtype.Int8) *int64 {
+	if !limit.Valid {
+		return nil
+	}
+	return &limit.Int64
+}
+
+func convertTransferLimits(override db.TransferLimitOverride) *pb.TransferLimits {
+	return &pb.TransferLimits{
+		Username:       override.Username,
+		Currency:       override.Currency,
+		PerTransfer:    int64Value(override.PerTransfer),
+		DailyAccount:   int64Value(override.DailyAccount),
+		MonthlyAccount: int64Value(override.MonthlyAccount),
+		DailyUser:      int64Value(override.DailyUser),
+		MonthlyUser:    int64Value(override.MonthlyUser),
+		UpdatedBy:      override.UpdatedBy,
+		UpdatedAt:      timestamppb.New(override.UpdatedAt),
+	}
+}
@@ -0,0 +1,72 @@
+package grpc_api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSetTransferLimits(t *testing.T) {
+	username := randomUsername()
+	dailyUser := tools.RandomMoney()
+
+	override := db.TransferLimitOverride{
+		Username:  username,
+		Currency:  tools.USD,
+		DailyUser: pgtype.Int8{Int64: dailyUser, Valid: true},
+		UpdatedBy: "admin",
+		UpdatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		SetTransferLimitOverride(gomock.Any(), gomock.Eq(db.SetTransferLimitOverrideParams{
+			Username:  username,
+			Currency:  tools.USD,
+			DailyUser: pgtype.Int8{Int64: dailyUser, Valid: true},
+			UpdatedBy: "admin",
+		})).
+		Times(1).
+		Return(override, nil)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+
+	req := &pb.SetTransferLimitsRequest{
+		Username:  username,
+		Currency:  tools.USD,
+		DailyUser: proto.Int64(dailyUser),
+	}
+
+	// only admins can override the limits
+	depositorCtx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+	_, err := client.SetTransferLimits(depositorCtx, req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	adminCtx := withAccessToken(t, server.tokenGenerator, "admin", tools.AdminRole)
+	rsp, err := client.SetTransferLimits(adminCtx, req)
+	require.NoError(t, err)
+	require.Equal(t, dailyUser, rsp.GetTransferLimits().GetDailyUser())
+	require.Nil(t, rsp.GetTransferLimits().PerTransfer)
+	require.Equal(t, "admin", rsp.GetTransferLimits().GetUpdatedBy())
+
+	_, err = client.SetTransferLimits(adminCtx, &pb.SetTransferLimitsRequest{
+		Username:    username,
+		Currency:    tools.USD,
+		PerTransfer: proto.Int64(-1),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,138 @@
+package grpc_api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/pagination"
+	"github.com/radugaf/simplebank/pb"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func randomTransfer() db.Transfer {
+	return db.Transfer{
+		ID:            tools.RandomInt(1, 1000),
+		FromAccountID: tools.RandomInt(1, 1000),
+		ToAccountID:   tools.RandomInt(1, 1000),
+		Amount:        tools.RandomMoney(),
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func TestListTransfers(t *testing.T) {
+	username := randomUsername()
+	transfer := randomTransfer()
+
+	testCases := []struct {
+		name       string
+		req        *pb.ListTransfersRequest
+		buildStubs func(store *mockdb.MockStore)
+		checkCode  codes.Code
+	}{
+		{
+			name: "OK",
+			req: &pb.ListTransfersRequest{
+				AccountId: transfer.ToAccountID,
+				Direction: pb.TransferDirection_TRANSFER_DIRECTION_IN,
+				Currency:  tools.CAD,
+				Filter:    &pb.RangeFilter{MaxAmount: 500},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListOwnerTransfers(gomock.Any(), gomock.Eq(db.ListOwnerTransfersParams{
+						Owner:     username,
+						Direction: db.NullString(db.TransferDirectionIn),
+						AccountID: db.NullInt64(transfer.ToAccountID),
+						Currency:  db.NullString(tools.CAD),
+						MaxAmount: db.NullInt64(500),
+						Limit:     pagination.DefaultPageSize + 1,
+					})).
+					Times(1).
+					Return([]db.Transfer{transfer}, nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "InvalidDirection",
+			req:  &pb.ListTransfersRequest{Direction: pb.TransferDirection(42)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListOwnerTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name: "InvalidCurrency",
+			req:  &pb.ListTransfersRequest{Currency: "XYZ"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListOwnerTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkCode: codes.InvalidArgument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := newTestClient(t, server)
+
+			ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+			rsp, err := client.ListTransfers(ctx, tc.req)
+			require.Equal(t, tc.checkCode, status.Code(err))
+			if tc.checkCode == codes.OK {
+				require.Len(t, rsp.GetTransfers(), 1)
+				require.Equal(t, transfer.ID, rsp.GetTransfers()[0].GetId())
+				require.Empty(t, rsp.GetNextCursor())
+			}
+		})
+	}
+}
+
+func TestGetTransfer(t *testing.T) {
+	username := randomUsername()
+	transfer := randomTransfer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetOwnerTransfer(gomock.Any(), gomock.Eq(db.GetOwnerTransferParams{ID: transfer.ID, Owner: username})).
+		Times(1).
+		Return(transfer, nil)
+	store.EXPECT().
+		GetOwnerTransfer(gomock.Any(), gomock.Eq(db.GetOwnerTransferParams{ID: transfer.ID + 1, Owner: username})).
+		Times(1).
+		Return(db.Transfer{}, db.ErrRecordNotFound)
+
+	server := newTestServer(t, store)
+	client := newTestClient(t, server)
+	ctx := withAccessToken(t, server.tokenGenerator, username, tools.DepositorRole)
+
+	rsp, err := client.GetTransfer(ctx, &pb.GetTransferRequest{Id: transfer.ID})
+	require.NoError(t, err)
+	require.Equal(t, transfer.Amount, rsp.GetTransfer().GetAmount())
+
+	_, err = client.GetTransfer(ctx, &pb.GetTransferRequest{Id: transfer.ID + 1})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetTransfer(ctx, &pb.GetTransferRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
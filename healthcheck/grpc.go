@@ -0,0 +1,44 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// SyncGRPC runs the readiness checks every interval and sets the serving status of the
+// services on the gRPC health server accordingly, until the context is done. Once a graceful
+// shutdown starts every service is reported as not serving right away, and for good.
+// The empty service name stands for the server as a whole.
+func (checker *Checker) SyncGRPC(ctx context.Context, server *health.Server, interval time.Duration, services ...string) {
+	services = append([]string{""}, services...)
+
+	update := func() {
+		status := healthpb.HealthCheckResponse_SERVING
+		if len(checker.Ready(ctx)) > 0 {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		for _, service := range services {
+			server.SetServingStatus(service, status)
+		}
+	}
+
+	update()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-checker.shutdown:
+			server.Shutdown()
+			return
+		case <-ticker.C:
+			update()
+		}
+	}
+}
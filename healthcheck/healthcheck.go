@@ -0,0 +1,137 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrShuttingDown is reported by Ready once a graceful shutdown has started
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check reports whether a dependency of the service is ready
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of the service
+type Checker struct {
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+	shutdownOnce sync.Once
+	shutdown     chan struct{}
+
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+// New creates a new Checker whose checks together must finish within the timeout
+func New(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout:  timeout,
+		shutdown: make(chan struct{}),
+	}
+}
+
+// AddCheck adds a readiness check
+func (checker *Checker) AddCheck(name string, check Check) {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	checker.checks = append(checker.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown makes the service report not ready from now on,
+// so that it is taken out of rotation while it drains
+func (checker *Checker) SetShuttingDown() {
+	checker.shuttingDown.Store(true)
+	checker.shutdownOnce.Do(func() { close(checker.shutdown) })
+}
+
+// Ready runs every check and returns the error of each failed one by check name.
+// The service is ready when the map is empty.
+func (checker *Checker) Ready(ctx context.Context) map[string]error {
+	failures := map[string]error{}
+	if checker.shuttingDown.Load() {
+		failures["shutdown"] = ErrShuttingDown
+	}
+
+	checker.mu.Lock()
+	checks := checker.checks
+	checker.mu.Unlock()
+
+	if checker.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, checker.timeout)
+		defer cancel()
+	}
+
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			failures[c.name] = err
+		}
+	}
+
+	return failures
+}
+
+// Heartbeat is beaten by a background worker on every run
+type Heartbeat struct {
+	last atomic.Int64
+}
+
+// Beat records that the worker is alive
+func (heartbeat *Heartbeat) Beat() {
+	heartbeat.last.Store(time.Now().UnixNano())
+}
+
+// AddWorker adds a check that fails when the worker has not beaten its heartbeat within maxAge
+func (checker *Checker) AddWorker(name string, maxAge time.Duration) *Heartbeat {
+	heartbeat := &Heartbeat{}
+	heartbeat.Beat()
+
+	checker.AddCheck("worker:"+name, func(ctx context.Context) error {
+		age := time.Since(time.Unix(0, heartbeat.last.Load()))
+		if age > maxAge {
+			return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+		}
+		return nil
+	})
+
+	return heartbeat
+}
+
+// DBCheck checks that the database can be reached
+func DBCheck(pool *pgxpool.Pool) Check {
+	return func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	}
+}
+
+// MigrationCheck checks that the schema is migrated to at least the expected version and not dirty
+func MigrationCheck(pool *pgxpool.Pool, expectedVersion uint) Check {
+	return func(ctx context.Context) error {
+		var version int64
+		var dirty bool
+		err := pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty)
+		if err != nil {
+			return fmt.Errorf("cannot get schema version: %w", err)
+		}
+
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty", version)
+		}
+		if version < int64(expectedVersion) {
+			return fmt.Errorf("schema version is %d, expected %d", version, expectedVersion)
+		}
+		return nil
+	}
+}
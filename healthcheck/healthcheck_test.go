@@ -0,0 +1,105 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestReady(t *testing.T) {
+	checker := New(50 * time.Millisecond)
+	require.Empty(t, checker.Ready(context.Background()))
+
+	checker.AddCheck("ok", func(ctx context.Context) error { return nil })
+	checker.AddCheck("broken", func(ctx context.Context) error { return errors.New("broken") })
+	checker.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	failures := checker.Ready(context.Background())
+	require.Len(t, failures, 2)
+	require.EqualError(t, failures["broken"], "broken")
+	require.ErrorIs(t, failures["slow"], context.DeadlineExceeded)
+
+	checker.SetShuttingDown()
+	failures = checker.Ready(context.Background())
+	require.ErrorIs(t, failures["shutdown"], ErrShuttingDown)
+}
+
+func TestWorkerHeartbeat(t *testing.T) {
+	checker := New(time.Second)
+	heartbeat := checker.AddWorker("interest", time.Hour)
+	require.Empty(t, checker.Ready(context.Background()))
+
+	heartbeat.last.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	failures := checker.Ready(context.Background())
+	require.Contains(t, failures, "worker:interest")
+
+	heartbeat.Beat()
+	require.Empty(t, checker.Ready(context.Background()))
+}
+
+func TestSyncGRPC(t *testing.T) {
+	checker := New(time.Second)
+	healthy := true
+	checker.AddCheck("toggle", func(ctx context.Context) error {
+		if !healthy {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+
+	server := health.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.SyncGRPC(ctx, server, time.Hour, "pb.SimpleBank")
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		rsp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "pb.SimpleBank"})
+		return err == nil && rsp.Status == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	healthy = false
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go checker.SyncGRPC(ctx, server, time.Hour)
+	require.Eventually(t, func() bool {
+		rsp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		return err == nil && rsp.Status == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSyncGRPCShutdown(t *testing.T) {
+	checker := New(time.Second)
+	server := health.NewServer()
+
+	done := make(chan struct{})
+	go func() {
+		checker.SyncGRPC(context.Background(), server, time.Hour)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		rsp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		return err == nil && rsp.Status == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	checker.SetShuttingDown()
+	<-done
+
+	rsp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+}
@@ -0,0 +1,71 @@
+// Package hold runs the job releasing the holds that were neither captured nor voided before they expired.
+package hold
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/healthcheck"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultBatchSize is the number of holds released by one transaction of the expiry
+const DefaultBatchSize = 100
+
+// Expiry releases the expired holds. Holds are locked while they are released,
+// so several instances of the expiry can run at the same time.
+type Expiry struct {
+	store     db.Store
+	batchSize int32
+	now       func() time.Time
+}
+
+// NewExpiry creates a new hold expiry
+func NewExpiry(store db.Store) *Expiry {
+	return &Expiry{
+		store:     store,
+		batchSize: DefaultBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Run releases the expired holds every interval until the context is done,
+// and beats the heartbeat after every run
+func (expiry *Expiry) Run(ctx context.Context, interval time.Duration, heartbeat *healthcheck.Heartbeat) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := expiry.ExpireAll(ctx); err != nil {
+			log.Error().Err(err).Msg("cannot run hold expiry")
+		}
+		heartbeat.Beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireAll releases the holds expired by now in batches, until a batch is not full
+func (expiry *Expiry) ExpireAll(ctx context.Context) error {
+	now := expiry.now()
+
+	for {
+		holds, err := expiry.store.ExpireHoldsTx(ctx, now, expiry.batchSize)
+		if err != nil {
+			return fmt.Errorf("cannot expire holds: %w", err)
+		}
+		if len(holds) > 0 {
+			log.Info().Int("holds", len(holds)).Msg("expired holds")
+		}
+
+		if len(holds) < int(expiry.batchSize) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package hold
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/healthcheck"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpireAll(t *testing.T) {
+	now := time.Date(2024, time.May, 3, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "Batches",
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().ExpireHoldsTx(gomock.Any(), gomock.Eq(now), gomock.Eq(int32(2))).
+						Times(1).
+						Return([]db.Hold{{ID: 1}, {ID: 2}}, nil),
+					// the last batch is not full
+					store.EXPECT().ExpireHoldsTx(gomock.Any(), gomock.Eq(now), gomock.Eq(int32(2))).
+						Times(1).
+						Return([]db.Hold{{ID: 3}}, nil),
+				)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NothingExpired",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ExpireHoldsTx(gomock.Any(), gomock.Eq(now), gomock.Eq(int32(2))).
+					Times(1).
+					Return(nil, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Error",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ExpireHoldsTx(gomock.Any(), gomock.Eq(now), gomock.Eq(int32(2))).
+					Times(1).
+					Return(nil, db.ErrSerialization)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, db.ErrSerialization)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			expiry := NewExpiry(store)
+			expiry.batchSize = 2
+			expiry.now = func() time.Time { return now }
+
+			tc.checkError(t, expiry.ExpireAll(context.Background()))
+		})
+	}
+}
+
+func TestRunBeatsHeartbeat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExpireHoldsTx(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, nil)
+
+	checker := healthcheck.New(time.Second)
+	heartbeat := checker.AddWorker("hold_expiry", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.Contains(t, checker.Ready(context.Background()), "worker:hold_expiry")
+
+	// a done context stops the expiry after its first run
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewExpiry(store).Run(ctx, time.Hour, heartbeat)
+
+	require.Empty(t, checker.Ready(context.Background()))
+}
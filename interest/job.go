@@ -0,0 +1,97 @@
+// Package interest runs the job accruing the interest of the accounts every day
+// and posting it to them on the first day of every month.
+package interest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/healthcheck"
+	"github.com/rs/zerolog/log"
+)
+
+// Job accrues and posts the interest. Every date is only accrued and posted once,
+// so several instances of the job can run at the same time.
+type Job struct {
+	store db.Store
+	now   func() time.Time
+}
+
+// NewJob creates a new interest job
+func NewJob(store db.Store) *Job {
+	return &Job{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Run catches up with the days to accrue every interval until the context is done,
+// and beats the heartbeat after every run
+func (job *Job) Run(ctx context.Context, interval time.Duration, heartbeat *healthcheck.Heartbeat) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.CatchUp(ctx); err != nil {
+			log.Error().Err(err).Msg("cannot run interest job")
+		}
+		heartbeat.Beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CatchUp accrues every day from the day after the last accrued one up to yesterday,
+// which backfills the days missed while the job was not running. The first run accrues yesterday.
+func (job *Job) CatchUp(ctx context.Context) error {
+	yesterday := day(job.now()).AddDate(0, 0, -1)
+
+	from := yesterday
+	last, err := job.store.GetLastInterestRun(ctx, db.InterestRunAccrual)
+	if err == nil {
+		from = day(last.RunDate.Time).AddDate(0, 0, 1)
+	} else if !errors.Is(err, db.ErrRecordNotFound) {
+		return fmt.Errorf("cannot get last interest run: %w", err)
+	}
+
+	return job.Backfill(ctx, from, yesterday)
+}
+
+// Backfill accrues every day from one date to the other, both included, and posts the interest
+// accrued in a month once its last day is accrued. The dates already accrued or posted are skipped.
+func (job *Job) Backfill(ctx context.Context, from time.Time, to time.Time) error {
+	for date := day(from); !date.After(day(to)); date = date.AddDate(0, 0, 1) {
+		accrual, err := job.store.AccrueInterestTx(ctx, date)
+		if err != nil {
+			return fmt.Errorf("cannot accrue interest of %s: %w", date.Format("2006-01-02"), err)
+		}
+		log.Info().Str("date", date.Format("2006-01-02")).Bool("already_run", accrual.AlreadyRun).
+			Int("accounts", accrual.Accounts).Msg("accrued interest")
+
+		next := date.AddDate(0, 0, 1)
+		if next.Day() != 1 {
+			continue
+		}
+
+		posting, err := job.store.PostInterestTx(ctx, next)
+		if err != nil {
+			return fmt.Errorf("cannot post interest on %s: %w", next.Format("2006-01-02"), err)
+		}
+		log.Info().Str("date", next.Format("2006-01-02")).Bool("already_run", posting.AlreadyRun).
+			Int("entries", len(posting.Entries)).Msg("posted interest")
+	}
+	return nil
+}
+
+// day returns the start of the day of the time, in UTC
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,100 @@
+package interest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBackfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(date(2024, time.January, 30))).Times(1),
+		store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(date(2024, time.January, 31))).Times(1),
+		// the interest of January is posted once its last day is accrued
+		store.EXPECT().PostInterestTx(gomock.Any(), gomock.Eq(date(2024, time.February, 1))).Times(1),
+		store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(date(2024, time.February, 1))).Times(1),
+	)
+
+	job := NewJob(store)
+	err := job.Backfill(context.Background(), date(2024, time.January, 30), date(2024, time.February, 1).Add(13*time.Hour))
+	require.NoError(t, err)
+}
+
+func TestCatchUp(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name: "FromLastRun",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLastInterestRun(gomock.Any(), gomock.Eq(db.InterestRunAccrual)).
+					Times(1).
+					Return(db.InterestRun{
+						Kind:    db.InterestRunAccrual,
+						RunDate: pgtype.Date{Time: date(2024, time.March, 7), Valid: true},
+					}, nil)
+				gomock.InOrder(
+					store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(date(2024, time.March, 8))).Times(1),
+					store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(date(2024, time.March, 9))).Times(1),
+				)
+				store.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "FirstRun",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLastInterestRun(gomock.Any(), gomock.Eq(db.InterestRunAccrual)).
+					Times(1).
+					Return(db.InterestRun{}, db.ErrRecordNotFound)
+				store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Eq(date(2024, time.March, 9))).Times(1)
+			},
+		},
+		{
+			name: "UpToDate",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLastInterestRun(gomock.Any(), gomock.Eq(db.InterestRunAccrual)).
+					Times(1).
+					Return(db.InterestRun{
+						Kind:    db.InterestRunAccrual,
+						RunDate: pgtype.Date{Time: date(2024, time.March, 9), Valid: true},
+					}, nil)
+				store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			job := NewJob(store)
+			job.now = func() time.Time { return date(2024, time.March, 10).Add(2 * time.Hour) }
+
+			require.NoError(t, job.CatchUp(context.Background()))
+		})
+	}
+}
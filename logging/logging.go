@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Log formats supported by Setup
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+const (
+	// RequestIDHeader is the HTTP header carrying the request ID
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadataKey is the gRPC metadata key carrying the request ID
+	RequestIDMetadataKey = "x-request-id"
+	// requestIDField is the name of the request ID field of every log line
+	requestIDField = "request_id"
+	// maxRequestIDLength is the longest request ID accepted from a client
+	maxRequestIDLength = 128
+)
+
+// Setup configures the global logger with the level and format from the config
+func Setup(level string, format string) error {
+	logLevel := zerolog.InfoLevel
+	if level != "" {
+		var err error
+		logLevel, err = zerolog.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+	}
+
+	var output io.Writer = os.Stderr
+	switch format {
+	case "", FormatJSON:
+	case FormatConsole:
+		output = zerolog.ConsoleWriter{Out: os.Stderr}
+	default:
+		return fmt.Errorf("unsupported log format %q", format)
+	}
+
+	zerolog.SetGlobalLevel(logLevel)
+	log.Logger = zerolog.New(output).With().Timestamp().Logger()
+	return nil
+}
+
+type requestIDContextKey struct{}
+
+// NewRequestID generates a new request ID
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// RequestIDOrNew returns the request ID sent by the client, or a new one if the
+// client did not send one or sent one that is too long or not printable ASCII
+func RequestIDOrNew(requestID string) string {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return NewRequestID()
+	}
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return NewRequestID()
+		}
+	}
+	return requestID
+}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, if any
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// FromContext returns the global logger with the request ID of the context attached
+func FromContext(ctx context.Context) *zerolog.Logger {
+	logger := log.Logger
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		logger = logger.With().Str(requestIDField, requestID).Logger()
+	}
+	return &logger
+}
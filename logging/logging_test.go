@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	logger, level := log.Logger, zerolog.GlobalLevel()
+	defer func() {
+		log.Logger = logger
+		zerolog.SetGlobalLevel(level)
+	}()
+
+	require.NoError(t, Setup("debug", FormatJSON))
+	require.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+
+	require.NoError(t, Setup("", FormatConsole))
+	require.Equal(t, zerolog.InfoLevel, zerolog.GlobalLevel())
+
+	require.Error(t, Setup("loud", FormatJSON))
+	require.Error(t, Setup("info", "xml"))
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = logger }()
+
+	requestID := NewRequestID()
+	ctx := WithRequestID(context.Background(), requestID)
+	require.Equal(t, requestID, RequestIDFromContext(ctx))
+
+	FromContext(ctx).Info().Msg("with request ID")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, requestID, entry["request_id"])
+
+	buf.Reset()
+	require.Empty(t, RequestIDFromContext(context.Background()))
+	FromContext(context.Background()).Info().Msg("without request ID")
+
+	entry = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.NotContains(t, entry, "request_id")
+}
+
+func TestRequestIDOrNew(t *testing.T) {
+	require.Equal(t, "abc-123", RequestIDOrNew("abc-123"))
+
+	for _, requestID := range []string{"", "with space", "new\nline", strings.Repeat("a", maxRequestIDLength+1)} {
+		generated := RequestIDOrNew(requestID)
+		require.NotEqual(t, requestID, generated)
+		_, err := uuid.Parse(generated)
+		require.NoError(t, err)
+	}
+}
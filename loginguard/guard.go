@@ -0,0 +1,318 @@
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/tools"
+)
+
+// Scopes under which failed login attempts are counted
+const (
+	ScopeUsername = "username"
+	ScopeIP       = "ip"
+)
+
+// ErrInvalidCredentials is returned for both an unknown username and a wrong password,
+// so that a failed login does not reveal whether the username exists.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// LockedError is returned while a username or client IP is locked out after failed logins
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (err *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", err.RetryAfter.Round(time.Second))
+}
+
+// Guard tracks failed logins per username and per client IP. Every attempt is counted before the
+// password is checked, and every failure locks the key out for an exponentially growing delay.
+// Reaching the maximum number of attempts locks it out for the full lockout duration.
+// Lockouts are stored in the database so they survive restarts.
+type Guard struct {
+	store  db.Store
+	config tools.Config
+}
+
+// New creates a new login guard
+func New(store db.Store, config tools.Config) *Guard {
+	return &Guard{
+		store:  store,
+		config: config,
+	}
+}
+
+type key struct {
+	scope       string
+	identifier  string
+	maxAttempts int32
+}
+
+// keys returns the tracked keys of a login attempt. A scope whose
+// maximum number of attempts is not configured is not tracked.
+func (guard *Guard) keys(username string, clientIP string) []key {
+	var keys []key
+
+	if guard.config.LoginMaxFailedAttempts > 0 && username != "" {
+		keys = append(keys, key{ScopeUsername, username, guard.config.LoginMaxFailedAttempts})
+	}
+
+	if host, _, err := net.SplitHostPort(clientIP); err == nil {
+		clientIP = host
+	}
+	if guard.config.LoginMaxFailedAttemptsPerIP > 0 && clientIP != "" {
+		keys = append(keys, key{ScopeIP, clientIP, guard.config.LoginMaxFailedAttemptsPerIP})
+	}
+
+	return keys
+}
+
+// pendingAttemptTimeout is how long the username of an attempt stays locked if it is never resolved
+const pendingAttemptTimeout = 30 * time.Second
+
+// resolveTimeout bounds the queries that resolve an attempt. They do not run with the context of the
+// request, so that a client disconnecting after the password was checked cannot leave its failure
+// unrecorded or the username locked until pendingAttemptTimeout.
+const resolveTimeout = 5 * time.Second
+
+// Attempt is a login attempt counted by Begin, to be resolved with Fail or Succeed
+type Attempt struct {
+	guard    *Guard
+	username string
+	keys     []startedKey
+}
+
+type startedKey struct {
+	key
+	attempts int32
+}
+
+// pending reports whether the key stays locked while the attempt is pending. Only the username is:
+// the clients behind a shared IP, like a NAT or a proxy, must not be refused while one of them logs in.
+func (k key) pending() bool {
+	return k.scope == ScopeUsername
+}
+
+// Begin counts a login attempt for the username and the client IP before the password is checked,
+// and returns a *LockedError if either of them is locked out. The username stays locked until the
+// attempt is resolved, so concurrent guesses are refused instead of all being checked before any
+// failure is recorded. The client IP is only counted.
+func (guard *Guard) Begin(ctx context.Context, username string, clientIP string) (*Attempt, error) {
+	now := time.Now()
+	attempt := &Attempt{guard: guard, username: username}
+
+	for _, k := range guard.keys(username, clientIP) {
+		failure, err := guard.startKey(ctx, k, now)
+		if err != nil {
+			// the keys started so far are released, since the attempt will not go on
+			if releaseErr := attempt.Release(); releaseErr != nil {
+				return nil, releaseErr
+			}
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return nil, guard.lockedError(ctx, k, now)
+			}
+			return nil, fmt.Errorf("failed to start login attempt: %w", err)
+		}
+
+		attempt.keys = append(attempt.keys, startedKey{key: k, attempts: failure.FailedAttempts})
+	}
+
+	return attempt, nil
+}
+
+func (guard *Guard) startKey(ctx context.Context, k key, now time.Time) (db.LoginFailure, error) {
+	if !k.pending() {
+		return guard.store.CountLoginAttempt(ctx, db.CountLoginAttemptParams{
+			Scope:       k.scope,
+			Identifier:  k.identifier,
+			ResetBefore: now.Add(-guard.config.LoginFailureWindow),
+		})
+	}
+
+	return guard.store.StartLoginAttempt(ctx, db.StartLoginAttemptParams{
+		Scope:       k.scope,
+		Identifier:  k.identifier,
+		LockedUntil: now.Add(pendingAttemptTimeout),
+		ResetBefore: now.Add(-guard.config.LoginFailureWindow),
+	})
+}
+
+// lockedError returns the *LockedError of a key that is locked out
+func (guard *Guard) lockedError(ctx context.Context, k key, now time.Time) error {
+	failure, err := guard.store.GetLoginFailure(ctx, db.GetLoginFailureParams{
+		Scope:      k.scope,
+		Identifier: k.identifier,
+	})
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get login failures: %w", err)
+	}
+
+	// the lockout may have ended in the meantime, the client retries right away
+	var retryAfter time.Duration
+	if failure.LockedUntil.Valid && now.Before(failure.LockedUntil.Time) {
+		retryAfter = failure.LockedUntil.Time.Sub(now)
+	}
+	return &LockedError{RetryAfter: retryAfter}
+}
+
+// Fail records the attempt as failed and locks its keys out
+func (attempt *Attempt) Fail() error {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	for _, k := range attempt.keys {
+		lockout := attempt.guard.lockoutDuration(k.attempts, k.maxAttempts)
+
+		_, err := attempt.guard.store.LockLogin(ctx, db.LockLoginParams{
+			Scope:       k.scope,
+			Identifier:  k.identifier,
+			LockedUntil: now.Add(lockout),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to lock login: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Succeed clears the failed attempts of the username. The attempt is only uncounted for the client IP,
+// so that an attacker cannot reset the tracking of their IP by logging into an account of their own.
+func (attempt *Attempt) Succeed() error {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	if err := attempt.guard.Unlock(ctx, attempt.username); err != nil {
+		return err
+	}
+
+	for _, k := range attempt.keys {
+		if k.scope == ScopeIP {
+			if err := attempt.guard.releaseKey(ctx, k.key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Release uncounts an attempt that could not be resolved, when the password could not be checked
+func (attempt *Attempt) Release() error {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	for _, k := range attempt.keys {
+		if err := attempt.guard.releaseKey(ctx, k.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseKey uncounts the attempt for the key, and unlocks the key if the attempt locked it
+func (guard *Guard) releaseKey(ctx context.Context, k key) error {
+	var err error
+	if k.pending() {
+		err = guard.store.ReleaseLoginAttempt(ctx, db.ReleaseLoginAttemptParams{
+			Scope:      k.scope,
+			Identifier: k.identifier,
+		})
+	} else {
+		err = guard.store.UncountLoginAttempt(ctx, db.UncountLoginAttemptParams{
+			Scope:      k.scope,
+			Identifier: k.identifier,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+	return nil
+}
+
+// Unlock clears the failed attempts and any lockout of the username
+func (guard *Guard) Unlock(ctx context.Context, username string) error {
+	err := guard.store.DeleteLoginFailure(ctx, db.DeleteLoginFailureParams{
+		Scope:      ScopeUsername,
+		Identifier: username,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear login failures: %w", err)
+	}
+	return nil
+}
+
+// lockoutDuration doubles the delay with every failed attempt, starting at
+// the base delay, up to the full lockout once the maximum is reached.
+func (guard *Guard) lockoutDuration(attempts int32, maxAttempts int32) time.Duration {
+	lockout := guard.config.LoginLockoutDuration
+	if attempts >= maxAttempts {
+		return lockout
+	}
+
+	delay := guard.config.LoginBaseDelay
+	for i := int32(1); i < attempts && delay < lockout; i++ {
+		delay *= 2
+	}
+	if delay > lockout {
+		delay = lockout
+	}
+	return delay
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// VerifyPassword checks the password against the hash of a user. If the user was not found
+// the password is checked against a dummy hash instead, so that both failures take the same
+// time and return the same ErrInvalidCredentials.
+func VerifyPassword(password string, hashedPassword string, userFound bool) error {
+	if !userFound {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = tools.HashPassword(tools.RandomString(16))
+		})
+		tools.CheckPassword(password, dummyHash)
+		return ErrInvalidCredentials
+	}
+
+	if err := tools.CheckPassword(password, hashedPassword); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// UpgradePasswordHash rehashes the password of a user who just logged in successfully when the
+// stored hash is a legacy bcrypt hash or uses outdated Argon2id parameters. The password
+// change time is left alone, since the password itself did not change.
+func UpgradePasswordHash(ctx context.Context, store db.Store, hasher *tools.PasswordHasher, user db.User, password string) error {
+	if !hasher.NeedsRehash(user.HashedPassword) {
+		return nil
+	}
+
+	hashedPassword, err := hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.UpdateUser(ctx, db.UpdateUserParams{
+		Username: user.Username,
+		HashedPassword: pgtype.Text{
+			String: hashedPassword,
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upgrade password hash: %w", err)
+	}
+	return nil
+}
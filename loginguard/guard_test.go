@@ -0,0 +1,183 @@
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGuard(store db.Store) *Guard {
+	return New(store, tools.Config{
+		LoginMaxFailedAttempts:      5,
+		LoginMaxFailedAttemptsPerIP: 20,
+		LoginFailureWindow:          time.Hour,
+		LoginBaseDelay:              time.Second,
+		LoginLockoutDuration:        15 * time.Minute,
+	})
+}
+
+func TestLockoutDuration(t *testing.T) {
+	guard := newTestGuard(nil)
+
+	require.Equal(t, time.Second, guard.lockoutDuration(1, 5))
+	require.Equal(t, 2*time.Second, guard.lockoutDuration(2, 5))
+	require.Equal(t, 8*time.Second, guard.lockoutDuration(4, 5))
+	require.Equal(t, 15*time.Minute, guard.lockoutDuration(5, 5))
+	require.Equal(t, 15*time.Minute, guard.lockoutDuration(19, 20))
+}
+
+func TestBeginLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	username := tools.RandomOwner()
+
+	store.EXPECT().
+		StartLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.StartLoginAttemptParams) (db.LoginFailure, error) {
+			require.Equal(t, ScopeUsername, arg.Scope)
+			require.Equal(t, username, arg.Identifier)
+			require.WithinDuration(t, time.Now().Add(-time.Hour), arg.ResetBefore, time.Second)
+			require.WithinDuration(t, time.Now().Add(pendingAttemptTimeout), arg.LockedUntil, time.Second)
+			return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, FailedAttempts: 1}, nil
+		})
+	// the client IP is locked out, so the attempt is not counted for the username either
+	store.EXPECT().
+		CountLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.LoginFailure{}, db.ErrRecordNotFound)
+	store.EXPECT().
+		ReleaseLoginAttempt(gomock.Any(), gomock.Eq(db.ReleaseLoginAttemptParams{Scope: ScopeUsername, Identifier: username})).
+		Times(1)
+	store.EXPECT().
+		GetLoginFailure(gomock.Any(), gomock.Eq(db.GetLoginFailureParams{Scope: ScopeIP, Identifier: "10.0.0.1"})).
+		Times(1).
+		Return(db.LoginFailure{
+			Scope:       ScopeIP,
+			Identifier:  "10.0.0.1",
+			LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+		}, nil)
+
+	_, err := guard.Begin(context.Background(), username, "10.0.0.1:54321")
+
+	var lockedErr *LockedError
+	require.True(t, errors.As(err, &lockedErr))
+	require.True(t, lockedErr.RetryAfter > 0 && lockedErr.RetryAfter <= time.Minute)
+}
+
+func TestFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	username := tools.RandomOwner()
+
+	store.EXPECT().
+		StartLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.LoginFailure{Scope: ScopeUsername, Identifier: username, FailedAttempts: 5}, nil)
+	store.EXPECT().
+		LockLogin(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.LockLoginParams) (db.LoginFailure, error) {
+			require.Equal(t, username, arg.Identifier)
+			require.WithinDuration(t, time.Now().Add(15*time.Minute), arg.LockedUntil, time.Second)
+			return db.LoginFailure{}, nil
+		})
+
+	attempt, err := guard.Begin(context.Background(), username, "")
+	require.NoError(t, err)
+	require.NoError(t, attempt.Fail())
+}
+
+func TestSucceed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	username := tools.RandomOwner()
+
+	expectBegin(t, store, username, "10.0.0.1")
+	store.EXPECT().
+		DeleteLoginFailure(gomock.Any(), gomock.Eq(db.DeleteLoginFailureParams{Scope: ScopeUsername, Identifier: username})).
+		Times(1)
+	// the client IP keeps its failures and any lockout, only this attempt is uncounted
+	store.EXPECT().
+		UncountLoginAttempt(gomock.Any(), gomock.Eq(db.UncountLoginAttemptParams{Scope: ScopeIP, Identifier: "10.0.0.1"})).
+		Times(1)
+	store.EXPECT().ReleaseLoginAttempt(gomock.Any(), gomock.Any()).Times(0)
+
+	attempt, err := guard.Begin(context.Background(), username, "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, attempt.Succeed())
+}
+
+func TestRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	guard := newTestGuard(store)
+
+	username := tools.RandomOwner()
+
+	expectBegin(t, store, username, "10.0.0.1")
+	store.EXPECT().
+		ReleaseLoginAttempt(gomock.Any(), gomock.Eq(db.ReleaseLoginAttemptParams{Scope: ScopeUsername, Identifier: username})).
+		Times(1)
+	store.EXPECT().
+		UncountLoginAttempt(gomock.Any(), gomock.Eq(db.UncountLoginAttemptParams{Scope: ScopeIP, Identifier: "10.0.0.1"})).
+		Times(1)
+
+	// the request is canceled once the password was checked, the attempt is still resolved
+	ctx, cancel := context.WithCancel(context.Background())
+	attempt, err := guard.Begin(ctx, username, "10.0.0.1")
+	require.NoError(t, err)
+	cancel()
+	require.NoError(t, attempt.Release())
+}
+
+// expectBegin expects an attempt that locks the username while it is pending and only counts the client IP
+func expectBegin(t *testing.T, store *mockdb.MockStore, username string, clientIP string) {
+	store.EXPECT().
+		StartLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.StartLoginAttemptParams) (db.LoginFailure, error) {
+			require.Equal(t, ScopeUsername, arg.Scope)
+			return db.LoginFailure{Scope: ScopeUsername, Identifier: username, FailedAttempts: 1}, nil
+		})
+	store.EXPECT().
+		CountLoginAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CountLoginAttemptParams) (db.LoginFailure, error) {
+			require.Equal(t, ScopeIP, arg.Scope)
+			require.Equal(t, clientIP, arg.Identifier)
+			return db.LoginFailure{Scope: arg.Scope, Identifier: arg.Identifier, FailedAttempts: 1}, nil
+		})
+}
+
+func TestVerifyPassword(t *testing.T) {
+	password := tools.RandomString(8)
+	hashedPassword, err := tools.HashPassword(password)
+	require.NoError(t, err)
+
+	require.NoError(t, VerifyPassword(password, hashedPassword, true))
+	require.ErrorIs(t, VerifyPassword("wrong", hashedPassword, true), ErrInvalidCredentials)
+	require.ErrorIs(t, VerifyPassword(password, "", false), ErrInvalidCredentials)
+}
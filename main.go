@@ -1,65 +1,289 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"errors"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	_ "github.com/lib/pq"
 	"github.com/radugaf/simplebank/api"
+	"github.com/radugaf/simplebank/db/migration"
 	db "github.com/radugaf/simplebank/db/sqlc"
 	"github.com/radugaf/simplebank/grpc_api"
+	"github.com/radugaf/simplebank/healthcheck"
+	"github.com/radugaf/simplebank/hold"
+	"github.com/radugaf/simplebank/interest"
+	"github.com/radugaf/simplebank/logging"
+	"github.com/radugaf/simplebank/metrics"
 	"github.com/radugaf/simplebank/pb"
 	"github.com/radugaf/simplebank/tools"
+	"github.com/radugaf/simplebank/tracing"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 func main() {
 	config, err := tools.LoadConfig(".")
 	if err != nil {
-		log.Fatal("cannot load config:", err)
+		log.Fatal().Err(err).Msg("cannot load config")
 	}
 
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err := logging.Setup(config.LogLevel, config.LogFormat); err != nil {
+		log.Fatal().Err(err).Msg("cannot set up logging")
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     config.TracingExporter,
+		OTLPEndpoint: config.TracingOTLPEndpoint,
+		OTLPInsecure: config.TracingOTLPInsecure,
+	})
 	if err != nil {
-		log.Fatal("cannot connect to db:", err)
+		log.Fatal().Err(err).Msg("cannot set up tracing")
+	}
+	defer shutdownTracing(context.Background())
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(config, os.Args[2:]); err != nil {
+			log.Fatal().Err(err).Msg("cannot run migrate command")
+		}
+		return
+	}
+
+	if err := migrateOnStart(config); err != nil {
+		log.Fatal().Err(err).Msg("cannot start with the current schema")
+	}
+
+	poolConfig := db.PoolConfig{
+		MaxConns:           config.DBMaxConns,
+		MinConns:           config.DBMinConns,
+		MaxConnLifetime:    config.DBMaxConnLifetime,
+		MaxConnIdleTime:    config.DBMaxConnIdleTime,
+		StatementCacheMode: config.DBStatementCacheMode,
 	}
 
-	store := db.NewStore(conn)
-	runGrpcServer(config, store)
+	connPool, err := db.NewPool(context.Background(), config.DBSource, poolConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot connect to db")
+	}
+	defer connPool.Close()
+
+	if err := metrics.RegisterPool(connPool, "primary"); err != nil {
+		log.Fatal().Err(err).Msg("cannot register connection pool metrics")
+	}
+
+	schemaVersion, err := migration.ExpectedVersion()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot get schema version")
+	}
+
+	healthChecker := healthcheck.New(config.HealthCheckTimeout)
+	healthChecker.AddCheck("database", healthcheck.DBCheck(connPool))
+	healthChecker.AddCheck("migrations", healthcheck.MigrationCheck(connPool, schemaVersion))
+
+	storeOptions := []db.StoreOption{
+		db.WithTxRetries(config.TxMaxRetries, config.TxRetryBaseDelay),
+		db.WithTxRetryHook(metrics.ObserveTxRetry),
+		db.WithTransferLimits(db.TransferLimits{
+			PerTransfer:    config.TransferMaxAmounts,
+			DailyAccount:   config.TransferDailyAccountLimits,
+			MonthlyAccount: config.TransferMonthlyAccountLimits,
+			DailyUser:      config.TransferDailyUserLimits,
+			MonthlyUser:    config.TransferMonthlyUserLimits,
+		}),
+	}
+
+	// the replica is optional and left out of the readiness checks,
+	// the queries fall back to the primary while it is unavailable
+	if config.DBReplicaSource != "" {
+		replicaPool, err := db.NewPool(context.Background(), config.DBReplicaSource, poolConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot connect to replica db")
+		}
+		defer replicaPool.Close()
+
+		if err := metrics.RegisterPool(replicaPool, "replica"); err != nil {
+			log.Fatal().Err(err).Msg("cannot register connection pool metrics")
+		}
+
+		replica := db.NewReplica(replicaPool, config.DBReplicaMaxLag)
+		if err := metrics.RegisterReplica(replica); err != nil {
+			log.Fatal().Err(err).Msg("cannot register replica metrics")
+		}
+
+		monitorCtx, stopMonitor := context.WithCancel(context.Background())
+		defer stopMonitor()
+		go replica.Monitor(monitorCtx, config.DBReplicaCheckInterval)
+
+		storeOptions = append(storeOptions, db.WithReplica(replica))
+	}
+
+	store := metrics.NewStore(db.NewStore(connPool, storeOptions...))
+
+	if config.MetricsAddress != "" {
+		go runMetricsServer(config)
+	}
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveCtx, stopServing := context.WithCancel(context.Background())
+	waitGroup := &sync.WaitGroup{}
+
+	runGinServer(serveCtx, waitGroup, config, store, healthChecker)
+	runGrpcServer(serveCtx, waitGroup, config, store, healthChecker)
+
+	// the interest job is stopped with the servers, an interrupted day is accrued again on the next start
+	if config.InterestJobInterval > 0 {
+		heartbeat := healthChecker.AddWorker("interest", workerMaxAge(config.InterestJobInterval))
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			interest.NewJob(store).Run(serveCtx, config.InterestJobInterval, heartbeat)
+		}()
+	}
+
+	// an expired hold cannot be captured even before the expiry releases its amount
+	if config.HoldExpiryInterval > 0 {
+		heartbeat := healthChecker.AddWorker("hold_expiry", workerMaxAge(config.HoldExpiryInterval))
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			hold.NewExpiry(store).Run(serveCtx, config.HoldExpiryInterval, heartbeat)
+		}()
+	}
+
+	<-signalCtx.Done()
+
+	// report not ready first, so that no new traffic is sent our way while we drain
+	log.Info().Msg("shutting down")
+	healthChecker.SetShuttingDown()
+	time.Sleep(config.ShutdownDrainDelay)
+
+	stopServing()
+	waitGroup.Wait()
+	log.Info().Msg("shutdown complete")
+}
+
+// workerMaxAge is how long a worker running every interval can go without a heartbeat
+// before it is reported as stuck, it leaves room for a run that is slower than usual
+func workerMaxAge(interval time.Duration) time.Duration {
+	return 3 * interval
 }
 
-func runGinServer(config tools.Config, store db.Store) {
-	server, err := api.NewServer(config, store)
+func runMetricsServer(config tools.Config) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	log.Info().Msgf("start metrics server at %s", config.MetricsAddress)
+	err := http.ListenAndServe(config.MetricsAddress, mux)
 	if err != nil {
-		log.Fatal("cannot create server:", err)
+		log.Fatal().Err(err).Msg("cannot start metrics server")
 	}
+}
 
-	err = server.Start(config.ServerAddress)
+func runGinServer(
+	ctx context.Context,
+	waitGroup *sync.WaitGroup,
+	config tools.Config,
+	store db.Store,
+	healthChecker *healthcheck.Checker,
+) {
+	server, err := api.NewServer(config, store, healthChecker)
 	if err != nil {
-		log.Fatal("cannot start server:", err)
+		log.Fatal().Err(err).Msg("cannot create server")
+	}
+
+	httpServer := &http.Server{
+		Addr:    config.ServerAddress,
+		Handler: server.Handler(),
 	}
+
+	waitGroup.Add(2)
+
+	go func() {
+		defer waitGroup.Done()
+
+		log.Info().Msgf("start HTTP server at %s", httpServer.Addr)
+		err := httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("cannot start HTTP server")
+		}
+	}()
+
+	go func() {
+		defer waitGroup.Done()
+
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("cannot shut down HTTP server")
+		}
+		log.Info().Msg("HTTP server stopped")
+	}()
 }
 
-func runGrpcServer(config tools.Config, store db.Store) {
+func runGrpcServer(
+	ctx context.Context,
+	waitGroup *sync.WaitGroup,
+	config tools.Config,
+	store db.Store,
+	healthChecker *healthcheck.Checker,
+) {
 	server, err := grpc_api.NewServer(config, store)
 	if err != nil {
-		log.Fatal("cannot create server:", err)
+		log.Fatal().Err(err).Msg("cannot create server")
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(server.ServerOptions()...)
 	pb.RegisterSimpleBankServer(grpcServer, server)
 	reflection.Register(grpcServer)
 
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go healthChecker.SyncGRPC(ctx, healthServer, config.HealthCheckInterval, pb.SimpleBank_ServiceDesc.ServiceName)
+
 	listener, err := net.Listen("tcp", config.GRPCServerAddress)
 	if err != nil {
-		log.Fatal("cannot create listener:", err)
+		log.Fatal().Err(err).Msg("cannot create listener")
 	}
 
-	log.Printf("start gRPC server at %s", listener.Addr().String())
-	err = grpcServer.Serve(listener)
-	if err != nil {
-		log.Fatal("cannot start gRPC server:", err)
-	}
+	waitGroup.Add(2)
+
+	go func() {
+		defer waitGroup.Done()
+
+		log.Info().Msgf("start gRPC server at %s", listener.Addr().String())
+		err := grpcServer.Serve(listener)
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot start gRPC server")
+		}
+	}()
+
+	go func() {
+		defer waitGroup.Done()
+
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(config.ShutdownTimeout):
+			grpcServer.Stop()
+		}
+		log.Info().Msg("gRPC server stopped")
+	}()
 }
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simplebank"
+
+// Results of a login attempt
+const (
+	LoginSucceeded = "succeeded"
+	LoginFailed    = "failed"
+)
+
+// Results of a database transaction
+const (
+	TxCommitted  = "committed"
+	TxRolledBack = "rolled_back"
+)
+
+var (
+	// HTTPRequests counts the HTTP requests per route, method and status code
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status_code"})
+
+	// HTTPRequestDuration observes the latency of HTTP requests per route and method
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// GRPCRequests counts the gRPC calls per method and status code
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Number of gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	// GRPCRequestDuration observes the latency of gRPC calls per method
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Latency of gRPC calls by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// Transactions counts the database transactions per operation and result
+	Transactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_transactions_total",
+		Help:      "Number of database transactions by operation and result.",
+	}, []string{"operation", "result"})
+
+	// TransactionRetries counts the database transactions retried per Postgres error code
+	TransactionRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_transaction_retries_total",
+		Help:      "Number of database transactions retried after a serialization failure or deadlock, by error code.",
+	}, []string{"code"})
+
+	// Transfers counts the completed transfers per currency
+	Transfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Number of completed transfers by currency.",
+	}, []string{"currency"})
+
+	// TransferAmount observes the amount of completed transfers per currency, in minor units
+	TransferAmount = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transfer_amount",
+		Help:      "Amount of completed transfers by currency, in minor units.",
+		Buckets:   prometheus.ExponentialBuckets(100, 10, 7),
+	}, []string{"currency"})
+
+	// Logins counts the login attempts per result
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Number of login attempts by result.",
+	}, []string{"result"})
+
+	// SessionsCreated counts the sessions created by logging in
+	SessionsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_created_total",
+		Help:      "Number of sessions created.",
+	})
+)
+
+// Handler serves the metrics of the default registry, including the Go runtime and process metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveTxRetry counts a retried transaction, to be passed to db.WithTxRetryHook
+func ObserveTxRetry(code string) {
+	TransactionRetries.WithLabelValues(code).Inc()
+}
+
+// LoginResult returns the result label of a login attempt
+func LoginResult(succeeded bool) string {
+	if succeeded {
+		return LoginSucceeded
+	}
+	return LoginFailed
+}
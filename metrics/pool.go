@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	db "github.com/radugaf/simplebank/db/sqlc"
+)
+
+// poolCollector reads the statistics of the connection pool on every scrape
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquires             *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquires        *prometheus.Desc
+	canceledAcquires     *prometheus.Desc
+	newConns             *prometheus.Desc
+	maxLifetimeDestroyed *prometheus.Desc
+	maxIdleDestroyed     *prometheus.Desc
+}
+
+// NewPoolCollector creates a collector of the statistics of the connection pool
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		stat:                 pool.Stat,
+		acquiredConns:        desc("acquired_conns", "Number of connections currently in use."),
+		idleConns:            desc("idle_conns", "Number of idle connections."),
+		constructingConns:    desc("constructing_conns", "Number of connections being opened."),
+		totalConns:           desc("total_conns", "Number of open connections, in use, idle or being opened."),
+		maxConns:             desc("max_conns", "Maximum number of connections of the pool."),
+		acquires:             desc("acquires_total", "Number of connections acquired from the pool."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Time spent acquiring connections from the pool."),
+		emptyAcquires:        desc("empty_acquires_total", "Number of acquires that waited for a connection because the pool was empty."),
+		canceledAcquires:     desc("canceled_acquires_total", "Number of acquires canceled by their context."),
+		newConns:             desc("new_conns_total", "Number of connections opened."),
+		maxLifetimeDestroyed: desc("max_lifetime_destroyed_total", "Number of connections closed for exceeding the maximum lifetime."),
+		maxIdleDestroyed:     desc("max_idle_destroyed_total", "Number of connections closed for exceeding the maximum idle time."),
+	}
+}
+
+// RegisterPool registers the collector of the connection pool statistics on the default
+// registry, labeled with the role of the database, such as "primary" or "replica"
+func RegisterPool(pool *pgxpool.Pool, role string) error {
+	return prometheus.WrapRegistererWith(prometheus.Labels{"role": role}, prometheus.DefaultRegisterer).
+		Register(NewPoolCollector(pool))
+}
+
+// RegisterReplica registers gauges of the replication lag of the replica and whether it is in use
+func RegisterReplica(replica *db.Replica) error {
+	lag := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_lag_seconds",
+		Help:      "Replication lag of the read replica at its last check.",
+	}, func() float64 {
+		lag, _ := replica.Status()
+		return lag.Seconds()
+	})
+
+	healthy := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_healthy",
+		Help:      "Whether the read replica serves queries, 0 when they fall back to the primary.",
+	}, func() float64 {
+		if _, healthy := replica.Status(); healthy {
+			return 1
+		}
+		return 0
+	})
+
+	if err := prometheus.Register(lag); err != nil {
+		return err
+	}
+	return prometheus.Register(healthy)
+}
+
+func (collector *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(collector, ch)
+}
+
+func (collector *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := collector.stat()
+
+	gauge := func(desc *prometheus.Desc, value int32) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(collector.acquiredConns, stat.AcquiredConns())
+	gauge(collector.idleConns, stat.IdleConns())
+	gauge(collector.constructingConns, stat.ConstructingConns())
+	gauge(collector.totalConns, stat.TotalConns())
+	gauge(collector.maxConns, stat.MaxConns())
+	counter(collector.acquires, float64(stat.AcquireCount()))
+	counter(collector.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(collector.emptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(collector.canceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(collector.newConns, float64(stat.NewConnsCount()))
+	counter(collector.maxLifetimeDestroyed, float64(stat.MaxLifetimeDestroyCount()))
+	counter(collector.maxIdleDestroyed, float64(stat.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	db "github.com/radugaf/simplebank/db/sqlc"
+)
+
+// Store is a db.Store decorator that records the store level and business metrics
+type Store struct {
+	db.Store
+}
+
+// NewStore wraps the store with metrics
+func NewStore(store db.Store) db.Store {
+	return &Store{Store: store}
+}
+
+// TransferTx records the result of the transaction and counts the completed transfer
+func (store *Store) TransferTx(ctx context.Context, arg db.CreateTransferParams) (db.TransferTxResult, error) {
+	result, err := store.Store.TransferTx(ctx, arg)
+	observeTx("transfer", err)
+	if err != nil {
+		return result, err
+	}
+
+	currency := result.FromAccount.Currency
+	Transfers.WithLabelValues(currency).Inc()
+	TransferAmount.WithLabelValues(currency).Observe(float64(result.Transfer.Amount))
+	return result, nil
+}
+
+// CaptureTransferTx records the result of the transaction and counts the transfer settling the hold
+func (store *Store) CaptureTransferTx(ctx context.Context, arg db.CaptureTransferTxParams) (db.CaptureTransferTxResult, error) {
+	result, err := store.Store.CaptureTransferTx(ctx, arg)
+	observeTx("capture", err)
+	if err != nil {
+		return result, err
+	}
+
+	currency := result.FromAccount.Currency
+	Transfers.WithLabelValues(currency).Inc()
+	TransferAmount.WithLabelValues(currency).Observe(float64(result.Transfer.Amount))
+	return result, nil
+}
+
+// TransferBatchTx records the result of the transaction and counts the transfers of the batch
+func (store *Store) TransferBatchTx(ctx context.Context, arg db.TransferBatchTxParams) (db.TransferBatchTxResult, error) {
+	result, err := store.Store.TransferBatchTx(ctx, arg)
+	if err != nil || result.Batch.Status == db.TransferBatchStatusFailed {
+		Transactions.WithLabelValues("transfer_batch", TxRolledBack).Inc()
+		return result, err
+	}
+
+	currency := result.Batch.Currency
+	Transactions.WithLabelValues("transfer_batch", TxCommitted).Inc()
+	for _, item := range result.Items {
+		if item.Status == db.TransferBatchItemStatusSucceeded {
+			Transfers.WithLabelValues(currency).Inc()
+			TransferAmount.WithLabelValues(currency).Observe(float64(item.Amount))
+		}
+	}
+	return result, nil
+}
+
+// UpdateAccountStatusTx records the result of the transaction
+func (store *Store) UpdateAccountStatusTx(ctx context.Context, arg db.UpdateBankAccountStatusParams) (db.BankAccount, error) {
+	account, err := store.Store.UpdateAccountStatusTx(ctx, arg)
+	observeTx("update_account_status", err)
+	return account, err
+}
+
+// CloseAccountTx records the result of the transaction
+func (store *Store) CloseAccountTx(ctx context.Context, arg db.CloseAccountTxParams) (db.CloseAccountTxResult, error) {
+	result, err := store.Store.CloseAccountTx(ctx, arg)
+	observeTx("close_account", err)
+	return result, err
+}
+
+// AccrueInterestTx records the result of the transaction
+func (store *Store) AccrueInterestTx(ctx context.Context, date time.Time) (db.AccrueInterestTxResult, error) {
+	result, err := store.Store.AccrueInterestTx(ctx, date)
+	observeTx("accrue_interest", err)
+	return result, err
+}
+
+// PostInterestTx records the result of the transaction
+func (store *Store) PostInterestTx(ctx context.Context, date time.Time) (db.PostInterestTxResult, error) {
+	result, err := store.Store.PostInterestTx(ctx, date)
+	observeTx("post_interest", err)
+	return result, err
+}
+
+// AuthorizeTransferTx records the result of the transaction
+func (store *Store) AuthorizeTransferTx(ctx context.Context, arg db.CreateHoldParams) (db.AuthorizeTransferTxResult, error) {
+	result, err := store.Store.AuthorizeTransferTx(ctx, arg)
+	observeTx("authorize", err)
+	return result, err
+}
+
+// VoidTransferTx records the result of the transaction
+func (store *Store) VoidTransferTx(ctx context.Context, arg db.VoidTransferTxParams) (db.VoidTransferTxResult, error) {
+	result, err := store.Store.VoidTransferTx(ctx, arg)
+	observeTx("void", err)
+	return result, err
+}
+
+// ExpireHoldsTx records the result of the transaction
+func (store *Store) ExpireHoldsTx(ctx context.Context, now time.Time, limit int32) ([]db.Hold, error) {
+	holds, err := store.Store.ExpireHoldsTx(ctx, now, limit)
+	observeTx("expire_holds", err)
+	return holds, err
+}
+
+// observeTx counts a transaction of the operation as committed, or rolled back if it failed
+func observeTx(operation string, err error) {
+	if err != nil {
+		Transactions.WithLabelValues(operation, TxRolledBack).Inc()
+		return
+	}
+	Transactions.WithLabelValues(operation, TxCommitted).Inc()
+}
+
+// CreateSession counts the created session
+func (store *Store) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	session, err := store.Store.CreateSession(ctx, arg)
+	if err == nil {
+		SessionsCreated.Inc()
+	}
+	return session, err
+}
@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	mockdb "github.com/radugaf/simplebank/db/mock"
+	db "github.com/radugaf/simplebank/db/sqlc"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreTransferTx(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockdb.NewMockStore(ctrl)
+	store := NewStore(mockStore)
+
+	result := db.TransferTxResult{
+		FromAccount: db.BankAccount{ID: 1, Currency: tools.EUR},
+		ToAccount:   db.BankAccount{ID: 2, Currency: tools.EUR},
+		Transfer:    db.Transfer{FromAccountID: 1, ToAccountID: 2, Amount: 250},
+	}
+	arg := db.CreateTransferParams{FromAccountID: 1, ToAccountID: 2, Amount: 250}
+
+	committed := testutil.ToFloat64(Transactions.WithLabelValues("transfer", TxCommitted))
+	rolledBack := testutil.ToFloat64(Transactions.WithLabelValues("transfer", TxRolledBack))
+	transfers := testutil.ToFloat64(Transfers.WithLabelValues(tools.EUR))
+
+	mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+	_, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, committed+1, testutil.ToFloat64(Transactions.WithLabelValues("transfer", TxCommitted)))
+	require.Equal(t, transfers+1, testutil.ToFloat64(Transfers.WithLabelValues(tools.EUR)))
+
+	mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{}, errors.New("boom"))
+	_, err = store.TransferTx(context.Background(), arg)
+	require.Error(t, err)
+
+	require.Equal(t, rolledBack+1, testutil.ToFloat64(Transactions.WithLabelValues("transfer", TxRolledBack)))
+	require.Equal(t, transfers+1, testutil.ToFloat64(Transfers.WithLabelValues(tools.EUR)))
+}
+
+func TestStoreCaptureTransferTx(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockdb.NewMockStore(ctrl)
+	store := NewStore(mockStore)
+
+	result := db.CaptureTransferTxResult{
+		Hold: db.Hold{ID: 7, Status: db.HoldStatusCaptured},
+		TransferTxResult: db.TransferTxResult{
+			FromAccount: db.BankAccount{ID: 1, Currency: tools.CAD},
+			Transfer:    db.Transfer{FromAccountID: 1, ToAccountID: 2, Amount: 80},
+		},
+	}
+	arg := db.CaptureTransferTxParams{HoldID: 7}
+
+	committed := testutil.ToFloat64(Transactions.WithLabelValues("capture", TxCommitted))
+	rolledBack := testutil.ToFloat64(Transactions.WithLabelValues("capture", TxRolledBack))
+	transfers := testutil.ToFloat64(Transfers.WithLabelValues(tools.CAD))
+
+	mockStore.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+	_, err := store.CaptureTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, committed+1, testutil.ToFloat64(Transactions.WithLabelValues("capture", TxCommitted)))
+	require.Equal(t, transfers+1, testutil.ToFloat64(Transfers.WithLabelValues(tools.CAD)))
+
+	mockStore.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.CaptureTransferTxResult{}, db.ErrHoldNotAuthorized)
+	_, err = store.CaptureTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrHoldNotAuthorized)
+
+	require.Equal(t, rolledBack+1, testutil.ToFloat64(Transactions.WithLabelValues("capture", TxRolledBack)))
+	require.Equal(t, transfers+1, testutil.ToFloat64(Transfers.WithLabelValues(tools.CAD)))
+}
+
+func TestStoreTransferBatchTx(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockdb.NewMockStore(ctrl)
+	store := NewStore(mockStore)
+
+	result := db.TransferBatchTxResult{
+		Batch: db.TransferBatch{ID: 3, Currency: tools.USD, Status: db.TransferBatchStatusPartiallyCompleted},
+		Items: []db.TransferBatchItem{
+			{BatchID: 3, Position: 0, Amount: 100, Status: db.TransferBatchItemStatusSucceeded},
+			{BatchID: 3, Position: 1, Amount: 200, Status: db.TransferBatchItemStatusFailed},
+			{BatchID: 3, Position: 2, Amount: 300, Status: db.TransferBatchItemStatusSucceeded},
+		},
+	}
+	arg := db.TransferBatchTxParams{FromAccountID: 1, Currency: tools.USD, Mode: db.TransferBatchModePerItem}
+
+	committed := testutil.ToFloat64(Transactions.WithLabelValues("transfer_batch", TxCommitted))
+	rolledBack := testutil.ToFloat64(Transactions.WithLabelValues("transfer_batch", TxRolledBack))
+	transfers := testutil.ToFloat64(Transfers.WithLabelValues(tools.USD))
+
+	mockStore.EXPECT().TransferBatchTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+	_, err := store.TransferBatchTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// only the transfers that succeeded are counted
+	require.Equal(t, committed+1, testutil.ToFloat64(Transactions.WithLabelValues("transfer_batch", TxCommitted)))
+	require.Equal(t, transfers+2, testutil.ToFloat64(Transfers.WithLabelValues(tools.USD)))
+
+	failed := db.TransferBatchTxResult{Batch: db.TransferBatch{ID: 4, Currency: tools.USD, Status: db.TransferBatchStatusFailed}}
+	mockStore.EXPECT().TransferBatchTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(failed, nil)
+	_, err = store.TransferBatchTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, rolledBack+1, testutil.ToFloat64(Transactions.WithLabelValues("transfer_batch", TxRolledBack)))
+	require.Equal(t, transfers+2, testutil.ToFloat64(Transfers.WithLabelValues(tools.USD)))
+}
+
+func TestStoreObservesTx(t *testing.T) {
+	testCases := []struct {
+		operation  string
+		buildStubs func(mockStore *mockdb.MockStore, err error)
+		run        func(store db.Store) error
+	}{
+		{
+			operation: "update_account_status",
+			buildStubs: func(mockStore *mockdb.MockStore, err error) {
+				mockStore.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BankAccount{}, err)
+			},
+			run: func(store db.Store) error {
+				_, err := store.UpdateAccountStatusTx(context.Background(), db.UpdateBankAccountStatusParams{})
+				return err
+			},
+		},
+		{
+			operation: "close_account",
+			buildStubs: func(mockStore *mockdb.MockStore, err error) {
+				mockStore.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CloseAccountTxResult{}, err)
+			},
+			run: func(store db.Store) error {
+				_, err := store.CloseAccountTx(context.Background(), db.CloseAccountTxParams{})
+				return err
+			},
+		},
+		{
+			operation: "accrue_interest",
+			buildStubs: func(mockStore *mockdb.MockStore, err error) {
+				mockStore.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccrueInterestTxResult{}, err)
+			},
+			run: func(store db.Store) error {
+				_, err := store.AccrueInterestTx(context.Background(), time.Now())
+				return err
+			},
+		},
+		{
+			operation: "post_interest",
+			buildStubs: func(mockStore *mockdb.MockStore, err error) {
+				mockStore.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PostInterestTxResult{}, err)
+			},
+			run: func(store db.Store) error {
+				_, err := store.PostInterestTx(context.Background(), time.Now())
+				return err
+			},
+		},
+		{
+			operation: "authorize",
+			buildStubs: func(mockStore *mockdb.MockStore, err error) {
+				mockStore.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AuthorizeTransferTxResult{}, err)
+			},
+			run: func(store db.Store) error {
+				_, err := store.AuthorizeTransferTx(context.Background(), db.CreateHoldParams{})
+				return err
+			},
+		},
+		{
+			operation: "void",
+			buildStubs: func(mockStore *mockdb.MockStore, err error) {
+				mockStore.EXPECT().VoidTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.VoidTransferTxResult{}, err)
+			},
+			run: func(store db.Store) error {
+				_, err := store.VoidTransferTx(context.Background(), db.VoidTransferTxParams{})
+				return err
+			},
+		},
+		{
+			operation: "expire_holds",
+			buildStubs: func(mockStore *mockdb.MockStore, err error) {
+				mockStore.EXPECT().ExpireHoldsTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, err)
+			},
+			run: func(store db.Store) error {
+				_, err := store.ExpireHoldsTx(context.Background(), time.Now(), 10)
+				return err
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.operation, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mockdb.NewMockStore(ctrl)
+			store := NewStore(mockStore)
+
+			committed := testutil.ToFloat64(Transactions.WithLabelValues(tc.operation, TxCommitted))
+			rolledBack := testutil.ToFloat64(Transactions.WithLabelValues(tc.operation, TxRolledBack))
+
+			tc.buildStubs(mockStore, nil)
+			require.NoError(t, tc.run(store))
+			require.Equal(t, committed+1, testutil.ToFloat64(Transactions.WithLabelValues(tc.operation, TxCommitted)))
+
+			tc.buildStubs(mockStore, errors.New("boom"))
+			require.Error(t, tc.run(store))
+			require.Equal(t, rolledBack+1, testutil.ToFloat64(Transactions.WithLabelValues(tc.operation, TxRolledBack)))
+		})
+	}
+}
+
+func TestStoreCreateSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockdb.NewMockStore(ctrl)
+	store := NewStore(mockStore)
+
+	sessions := testutil.ToFloat64(SessionsCreated)
+
+	mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+	_, err := store.CreateSession(context.Background(), db.CreateSessionParams{})
+	require.NoError(t, err)
+	require.Equal(t, sessions+1, testutil.ToFloat64(SessionsCreated))
+
+	mockStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, errors.New("boom"))
+	_, err = store.CreateSession(context.Background(), db.CreateSessionParams{})
+	require.Error(t, err)
+	require.Equal(t, sessions+1, testutil.ToFloat64(SessionsCreated))
+
+	// other methods are passed through to the wrapped store
+	mockStore.EXPECT().GetUser(gomock.Any(), gomock.Eq("alice")).Times(1).Return(db.User{Username: "alice"}, nil)
+	user, err := store.GetUser(context.Background(), "alice")
+	require.NoError(t, err)
+	require.Equal(t, "alice", user.Username)
+}
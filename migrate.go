@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/radugaf/simplebank/db/migration"
+	"github.com/radugaf/simplebank/tools"
+	"github.com/rs/zerolog/log"
+)
+
+const migrateUsage = "usage: migrate up [N] | down [N] | version | force VERSION"
+
+// runMigrateCommand runs the migrate subcommand against the database of the config
+func runMigrateCommand(config tools.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	m, err := migration.New(config.DBSource)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		if len(args) == 1 {
+			err = migration.Up(m)
+		} else {
+			steps, stepsErr := parseSteps(args[1])
+			if stepsErr != nil {
+				return stepsErr
+			}
+			err = m.Steps(steps)
+		}
+		if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("cannot migrate up: %w", err)
+		}
+
+	case "down":
+		// roll back a single migration unless told otherwise, since down loses data
+		steps := 1
+		if len(args) > 1 {
+			steps, err = parseSteps(args[1])
+			if err != nil {
+				return err
+			}
+		}
+		if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("cannot migrate down: %w", err)
+		}
+
+	case "version":
+
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		if err := m.Force(version); err != nil {
+			return fmt.Errorf("cannot force version: %w", err)
+		}
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return logSchemaVersion(m)
+}
+
+func parseSteps(arg string) (int, error) {
+	steps, err := strconv.Atoi(arg)
+	if err != nil || steps < 1 {
+		return 0, fmt.Errorf("invalid number of migrations: %s", arg)
+	}
+	return steps, nil
+}
+
+// migrateOnStart applies the pending migrations if MIGRATE_ON_START is set, then refuses
+// to go on if the schema is older than the migrations embedded in the binary
+func migrateOnStart(config tools.Config) error {
+	m, err := migration.New(config.DBSource)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if config.MigrateOnStart {
+		if err := migration.Up(m); err != nil {
+			return fmt.Errorf("cannot migrate up: %w", err)
+		}
+		if err := logSchemaVersion(m); err != nil {
+			return err
+		}
+	}
+
+	return migration.CheckVersion(m)
+}
+
+func logSchemaVersion(m *migrate.Migrate) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		log.Info().Msg("no migration has been applied")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get schema version: %w", err)
+	}
+
+	log.Info().Uint("version", version).Bool("dirty", dirty).Msg("schema version")
+	return nil
+}
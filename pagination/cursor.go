@@ -0,0 +1,123 @@
+// Package pagination implements keyset pagination with opaque cursors. A cursor holds the
+// (created_at, id) of the last item of a page and is signed, so that a client cannot forge
+// one or reuse it on another listing.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sizes of a page
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+const (
+	minKeySize = 32
+	payloadLen = 16
+	macLen     = 16
+)
+
+// ErrInvalidCursor is returned when a cursor was not issued by the codec for the listing
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last item of a page
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// IsZero reports whether the cursor points before the first page
+func (cursor Cursor) IsZero() bool {
+	return cursor.CreatedAt.IsZero() && cursor.ID == 0
+}
+
+// Codec encodes and decodes signed cursors
+type Codec struct {
+	key []byte
+}
+
+// NewCodec creates a new Codec signing the cursors with the key
+func NewCodec(key string) (*Codec, error) {
+	if len(key) < minKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minKeySize)
+	}
+	return &Codec{key: []byte(key)}, nil
+}
+
+// Encode returns the cursor as an opaque string, bound to the scope of the listing,
+// such as "bank_accounts:alice"
+func (codec *Codec) Encode(scope string, cursor Cursor) string {
+	payload := make([]byte, payloadLen, payloadLen+macLen)
+	binary.BigEndian.PutUint64(payload[:8], uint64(cursor.CreatedAt.UnixMicro()))
+	binary.BigEndian.PutUint64(payload[8:], uint64(cursor.ID))
+
+	return base64.RawURLEncoding.EncodeToString(append(payload, codec.sign(scope, payload)...))
+}
+
+// Decode returns the cursor encoded in the string for the same scope.
+// An empty string decodes to the zero cursor, the start of the listing.
+func (codec *Codec) Decode(scope string, s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) != payloadLen+macLen {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	payload, mac := data[:payloadLen], data[payloadLen:]
+	if !hmac.Equal(mac, codec.sign(scope, payload)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{
+		CreatedAt: time.UnixMicro(int64(binary.BigEndian.Uint64(payload[:8]))).UTC(),
+		ID:        int64(binary.BigEndian.Uint64(payload[8:])),
+	}, nil
+}
+
+func (codec *Codec) sign(scope string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, codec.key)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)[:macLen]
+}
+
+// Scope returns the scope of the listing of a resource under its parent,
+// such as the entries of an account
+func Scope(resource string, parent interface{}) string {
+	return fmt.Sprintf("%s:%v", resource, parent)
+}
+
+// Limit returns how many rows to fetch for a page of the requested size, the default size
+// if it is zero: one more than the page holds, to find out whether there is a next page
+func Limit(pageSize int32) int32 {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return pageSize + 1
+}
+
+// Page trims the rows fetched with Limit to the page, and returns the cursor of the
+// next page encoded for the scope, or an empty string on the last page
+func Page[T any](codec *Codec, scope string, rows []T, pageSize int32, cursorOf func(T) Cursor) ([]T, string) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if len(rows) <= int(pageSize) {
+		return rows, ""
+	}
+
+	rows = rows[:pageSize]
+	return rows, codec.Encode(scope, cursorOf(rows[len(rows)-1]))
+}
@@ -0,0 +1,94 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCodec(t *testing.T) *Codec {
+	codec, err := NewCodec(tools.RandomString(32))
+	require.NoError(t, err)
+	return codec
+}
+
+func TestNewCodecKeySize(t *testing.T) {
+	_, err := NewCodec(tools.RandomString(31))
+	require.Error(t, err)
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	codec := newTestCodec(t)
+
+	cursor := Cursor{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		ID:        tools.RandomInt(1, 1000),
+	}
+
+	s := codec.Encode("entries:1", cursor)
+	require.NotEmpty(t, s)
+
+	decoded, err := codec.Decode("entries:1", s)
+	require.NoError(t, err)
+	require.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	require.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestDecodeEmptyCursor(t *testing.T) {
+	cursor, err := newTestCodec(t).Decode("entries:1", "")
+	require.NoError(t, err)
+	require.True(t, cursor.IsZero())
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	codec := newTestCodec(t)
+	s := codec.Encode("entries:1", Cursor{CreatedAt: time.Now(), ID: 42})
+
+	// flip a bit of the payload
+	tampered := []byte(s)
+	if tampered[3] == 'A' {
+		tampered[3] = 'B'
+	} else {
+		tampered[3] = 'A'
+	}
+
+	for name, value := range map[string]string{
+		"Tampered":   string(tampered),
+		"Truncated":  s[:len(s)-2],
+		"NotBase64":  "not a cursor!",
+		"OtherKey":   newTestCodec(t).Encode("entries:1", Cursor{CreatedAt: time.Now(), ID: 42}),
+		"OtherScope": codec.Encode("entries:2", Cursor{CreatedAt: time.Now(), ID: 42}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := codec.Decode("entries:1", value)
+			require.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
+
+func TestPage(t *testing.T) {
+	codec := newTestCodec(t)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	rows := make([]Cursor, 4)
+	for i := range rows {
+		rows[i] = Cursor{CreatedAt: now.Add(time.Duration(i) * time.Second), ID: int64(i + 1)}
+	}
+	identity := func(cursor Cursor) Cursor { return cursor }
+
+	require.Equal(t, int32(4), Limit(3))
+	require.Equal(t, int32(DefaultPageSize+1), Limit(0))
+
+	page, next := Page(codec, "scope", rows, 3, identity)
+	require.Equal(t, rows[:3], page)
+
+	cursor, err := codec.Decode("scope", next)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), cursor.ID)
+
+	page, next = Page(codec, "scope", rows[3:], 3, identity)
+	require.Equal(t, rows[3:], page)
+	require.Empty(t, next)
+}
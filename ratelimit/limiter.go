@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/radugaf/simplebank/tools"
+)
+
+// sweepInterval is how often buckets that have refilled completely are dropped
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  tools.RateLimit
+}
+
+// Limiter is an in-memory token bucket rate limiter. Every caller gets one bucket
+// per route, sized by the limit of the route or by the default limit.
+type Limiter struct {
+	mu        sync.Mutex
+	limits    tools.RateLimits
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewLimiter creates a new Limiter
+func NewLimiter(limits tools.RateLimits) *Limiter {
+	return &Limiter{
+		limits:    limits,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from the caller's bucket for the route. If the bucket is empty
+// the request is rejected and the time until the next token is available is returned.
+func (limiter *Limiter) Allow(route string, caller string) (bool, time.Duration) {
+	limit, ok := limiter.limits[route]
+	if !ok {
+		limit, ok = limiter.limits[tools.DefaultRateLimitKey]
+	}
+	if !ok {
+		return true, 0
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	limiter.sweep(now)
+
+	key := route + " " + caller
+	b, ok := limiter.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now, limit: limit}
+		limiter.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that would be full by now, since a new bucket starts full anyway
+func (limiter *Limiter) sweep(now time.Time) {
+	if now.Sub(limiter.lastSweep) < sweepInterval {
+		return
+	}
+	limiter.lastSweep = now
+
+	for key, b := range limiter.buckets {
+		refill := time.Duration((float64(b.limit.Burst) - b.tokens) / b.limit.Rate * float64(time.Second))
+		if now.Sub(b.last) >= refill {
+			delete(limiter.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/radugaf/simplebank/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(tools.RateLimits{
+		tools.DefaultRateLimitKey: {Rate: 10, Burst: 10},
+		"POST /users/login":       {Rate: 1, Burst: 2},
+	})
+
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	// the burst is available right away
+	for i := 0; i < 2; i++ {
+		ok, _ := limiter.Allow("POST /users/login", "ip:1.2.3.4")
+		require.True(t, ok)
+	}
+
+	ok, retryAfter := limiter.Allow("POST /users/login", "ip:1.2.3.4")
+	require.False(t, ok)
+	require.Equal(t, time.Second, retryAfter)
+
+	// other callers and other routes have buckets of their own
+	ok, _ = limiter.Allow("POST /users/login", "ip:5.6.7.8")
+	require.True(t, ok)
+	ok, _ = limiter.Allow("GET /bank_accounts", "ip:1.2.3.4")
+	require.True(t, ok)
+
+	// tokens are refilled over time
+	now = now.Add(500 * time.Millisecond)
+	ok, retryAfter = limiter.Allow("POST /users/login", "ip:1.2.3.4")
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = limiter.Allow("POST /users/login", "ip:1.2.3.4")
+	require.True(t, ok)
+}
+
+func TestLimiterWithoutLimits(t *testing.T) {
+	limiter := NewLimiter(nil)
+
+	for i := 0; i < 100; i++ {
+		ok, _ := limiter.Allow("POST /transfers", "user:alice")
+		require.True(t, ok)
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	limiter := NewLimiter(tools.RateLimits{tools.DefaultRateLimitKey: {Rate: 1, Burst: 5}})
+
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.Allow("GET /bank_accounts", "user:alice")
+	require.True(t, ok)
+	require.Len(t, limiter.buckets, 1)
+
+	now = now.Add(sweepInterval)
+	ok, _ = limiter.Allow("GET /bank_accounts", "user:bob")
+	require.True(t, ok)
+	require.Len(t, limiter.buckets, 1)
+}
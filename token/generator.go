@@ -26,8 +26,8 @@ func NewJWTGenerator(secretKey string) (Token, error) {
 }
 
 // CreateToken creates a new token for a specific username and duration
-func (generator *JWTGenerator) GenerateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+func (generator *JWTGenerator) GenerateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -84,8 +84,8 @@ func NewPasetoGenerator(symmetricKey string) (Token, error) {
 }
 
 // CreateTokegeneratorates a new token for a specific username and duration
-func (generator *PasetoGenerator) GenerateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+func (generator *PasetoGenerator) GenerateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -14,12 +14,13 @@ func TestJWTGenerator(t *testing.T) {
 	require.NoError(t, err)
 
 	username := tools.RandomOwner()
+	role := tools.DepositorRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, payload, err := generator.GenerateToken(username, duration)
+	token, payload, err := generator.GenerateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -30,6 +31,7 @@ func TestJWTGenerator(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiresAt, time.Second)
 }
@@ -38,7 +40,7 @@ func TestExpiredJWTToken(t *testing.T) {
 	generator, err := NewJWTGenerator(tools.RandomString(32))
 	require.NoError(t, err)
 
-	token, payload, err := generator.GenerateToken(tools.RandomOwner(), -time.Minute)
+	token, payload, err := generator.GenerateToken(tools.RandomOwner(), tools.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -50,7 +52,7 @@ func TestExpiredJWTToken(t *testing.T) {
 }
 
 func TestInvalidJWTTokenAlgIsNone(t *testing.T) {
-	payload, err := NewPayload(tools.RandomOwner(), time.Minute)
+	payload, err := NewPayload(tools.RandomOwner(), tools.DepositorRole, time.Minute)
 	require.NoError(t, err)
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
@@ -71,12 +73,13 @@ func TestPasetoGenerator(t *testing.T) {
 	require.NoError(t, err)
 
 	username := tools.RandomOwner()
+	role := tools.DepositorRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, payload, err := generator.GenerateToken(username, duration)
+	token, payload, err := generator.GenerateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -87,6 +90,7 @@ func TestPasetoGenerator(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiresAt, time.Second)
 }
@@ -95,7 +99,7 @@ func TestExpiredPasetoToken(t *testing.T) {
 	generator, err := NewPasetoGenerator(tools.RandomString(32))
 	require.NoError(t, err)
 
-	token, payload, err := generator.GenerateToken(tools.RandomOwner(), -time.Minute)
+	token, payload, err := generator.GenerateToken(tools.RandomOwner(), tools.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
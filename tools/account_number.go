@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Account numbers are built like IBANs: the AccountNumberPrefix, two check digits
+// and the basic number. The check digits make the number valid under ISO 7064 mod-97.
+const (
+	AccountNumberPrefix    = "SB"
+	AccountNumberLength    = len(AccountNumberPrefix) + 2 + accountNumberBasicLen
+	accountNumberBasicLen  = 16
+	accountNumberCheckBase = 98
+)
+
+var maxAccountNumberBasic = new(big.Int).Exp(big.NewInt(10), big.NewInt(accountNumberBasicLen), nil)
+
+// NewAccountNumber returns a new account number with a random basic number,
+// so that account numbers cannot be guessed from one another
+func NewAccountNumber() (string, error) {
+	n, err := rand.Int(rand.Reader, maxAccountNumberBasic)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate account number: %w", err)
+	}
+
+	basic := fmt.Sprintf("%0*d", accountNumberBasicLen, n)
+	check := accountNumberCheckBase - mod97(basic+AccountNumberPrefix+"00")
+	return fmt.Sprintf("%s%02d%s", AccountNumberPrefix, check, basic), nil
+}
+
+// NormalizeAccountNumber removes the spaces an account number is often written with and upper cases it
+func NormalizeAccountNumber(number string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(number), ""))
+}
+
+// IsValidAccountNumber returns true if the normalized account number has the expected format and check digits
+func IsValidAccountNumber(number string) bool {
+	if len(number) != AccountNumberLength || !strings.HasPrefix(number, AccountNumberPrefix) {
+		return false
+	}
+
+	for _, c := range number[len(AccountNumberPrefix):] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	// move the prefix and check digits to the end, like for an IBAN
+	return mod97(number[4:]+number[:4]) == 1
+}
+
+// mod97 returns the remainder of the division by 97 of the alphanumeric string read as
+// a number, with the letters A to Z replaced by 10 to 35
+func mod97(s string) int {
+	remainder := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return -1
+		}
+	}
+	return remainder
+}
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMod97(t *testing.T) {
+	// example IBAN of ISO 13616, rearranged with its country code and check digits at the end
+	require.Equal(t, 1, mod97("WEST12345698765432"+"GB82"))
+	require.Equal(t, -1, mod97("12-34"))
+}
+
+func TestNewAccountNumber(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		number, err := NewAccountNumber()
+		require.NoError(t, err)
+		require.Len(t, number, AccountNumberLength)
+		require.True(t, IsValidAccountNumber(number), number)
+	}
+}
+
+func TestIsValidAccountNumber(t *testing.T) {
+	number, err := NewAccountNumber()
+	require.NoError(t, err)
+
+	// a single wrong digit changes the remainder
+	last := number[len(number)-1]
+	typo := number[:len(number)-1] + string('0'+(last-'0'+1)%10)
+
+	// swapping two different adjacent digits changes it too
+	swapped := []byte(number)
+	for i := len(swapped) - 2; i >= 4; i-- {
+		if swapped[i] != swapped[i+1] {
+			swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+			break
+		}
+	}
+
+	for _, invalid := range []string{
+		typo,
+		string(swapped),
+		number[:len(number)-1],
+		"XX" + number[2:],
+		number[:10] + "A" + number[11:],
+		"",
+	} {
+		require.False(t, IsValidAccountNumber(invalid), invalid)
+	}
+
+	spaced := number[:4] + " " + number[4:8] + " " + number[8:]
+	require.Equal(t, number, NormalizeAccountNumber(spaced))
+	require.True(t, IsValidAccountNumber(NormalizeAccountNumber(" sb"+number[2:])))
+}
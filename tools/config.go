@@ -1,21 +1,211 @@
 package tools
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 // Config stores the configuration for the application.
 // The values are read by viper from a config file or environment variables.
 type Config struct {
-	DBDriver             string        `mapstructure:"DB_DRIVER"`
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
-	GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	DBSource                     string          `mapstructure:"DB_SOURCE"`
+	DBMaxConns                   int32           `mapstructure:"DB_MAX_CONNS"`
+	DBMinConns                   int32           `mapstructure:"DB_MIN_CONNS"`
+	DBMaxConnLifetime            time.Duration   `mapstructure:"DB_MAX_CONN_LIFETIME"`
+	DBMaxConnIdleTime            time.Duration   `mapstructure:"DB_MAX_CONN_IDLE_TIME"`
+	DBStatementCacheMode         string          `mapstructure:"DB_STATEMENT_CACHE_MODE"`
+	DBReplicaSource              string          `mapstructure:"DB_REPLICA_SOURCE"`
+	DBReplicaMaxLag              time.Duration   `mapstructure:"DB_REPLICA_MAX_LAG"`
+	DBReplicaCheckInterval       time.Duration   `mapstructure:"DB_REPLICA_CHECK_INTERVAL"`
+	ServerAddress                string          `mapstructure:"SERVER_ADDRESS"`
+	GRPCServerAddress            string          `mapstructure:"GRPC_SERVER_ADDRESS"`
+	TrustedProxies               []string        `mapstructure:"TRUSTED_PROXIES"`
+	TokenSymmetricKey            string          `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration          time.Duration   `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration         time.Duration   `mapstructure:"REFRESH_TOKEN_DURATION"`
+	CursorSigningKey             string          `mapstructure:"CURSOR_SIGNING_KEY"`
+	StepUpThresholds             CurrencyAmounts `mapstructure:"STEP_UP_THRESHOLDS"`
+	StepUpChallengeDuration      time.Duration   `mapstructure:"STEP_UP_CHALLENGE_DURATION"`
+	StepUpMaxAttempts            int32           `mapstructure:"STEP_UP_MAX_ATTEMPTS"`
+	PayeeStepUpRequired          bool            `mapstructure:"PAYEE_STEP_UP_REQUIRED"`
+	TransferMaxAmounts           CurrencyAmounts `mapstructure:"TRANSFER_MAX_AMOUNTS"`
+	TransferDailyAccountLimits   CurrencyAmounts `mapstructure:"TRANSFER_DAILY_ACCOUNT_LIMITS"`
+	TransferMonthlyAccountLimits CurrencyAmounts `mapstructure:"TRANSFER_MONTHLY_ACCOUNT_LIMITS"`
+	TransferDailyUserLimits      CurrencyAmounts `mapstructure:"TRANSFER_DAILY_USER_LIMITS"`
+	TransferMonthlyUserLimits    CurrencyAmounts `mapstructure:"TRANSFER_MONTHLY_USER_LIMITS"`
+	InterestJobInterval          time.Duration   `mapstructure:"INTEREST_JOB_INTERVAL"`
+	HoldDuration                 time.Duration   `mapstructure:"HOLD_DURATION"`
+	HoldExpiryInterval           time.Duration   `mapstructure:"HOLD_EXPIRY_INTERVAL"`
+	LoginMaxFailedAttempts       int32           `mapstructure:"LOGIN_MAX_FAILED_ATTEMPTS"`
+	LoginMaxFailedAttemptsPerIP  int32           `mapstructure:"LOGIN_MAX_FAILED_ATTEMPTS_PER_IP"`
+	LoginFailureWindow           time.Duration   `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginBaseDelay               time.Duration   `mapstructure:"LOGIN_BASE_DELAY"`
+	LoginLockoutDuration         time.Duration   `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	PasswordMinLength            int             `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength            int             `mapstructure:"PASSWORD_MAX_LENGTH"`
+	PasswordRequireUpper         bool            `mapstructure:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower         bool            `mapstructure:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit         bool            `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol        bool            `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	Argon2Memory                 uint32          `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations             uint32          `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism            uint8           `mapstructure:"ARGON2_PARALLELISM"`
+	RateLimits                   RateLimits      `mapstructure:"RATE_LIMITS"`
+	LogLevel                     string          `mapstructure:"LOG_LEVEL"`
+	LogFormat                    string          `mapstructure:"LOG_FORMAT"`
+	MetricsAddress               string          `mapstructure:"METRICS_ADDRESS"`
+	TracingExporter              string          `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint          string          `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure          bool            `mapstructure:"TRACING_OTLP_INSECURE"`
+	MigrateOnStart               bool            `mapstructure:"MIGRATE_ON_START"`
+	TxMaxRetries                 int             `mapstructure:"TX_MAX_RETRIES"`
+	TxRetryBaseDelay             time.Duration   `mapstructure:"TX_RETRY_BASE_DELAY"`
+	HealthCheckTimeout           time.Duration   `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	HealthCheckInterval          time.Duration   `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	ShutdownDrainDelay           time.Duration   `mapstructure:"SHUTDOWN_DRAIN_DELAY"`
+	ShutdownTimeout              time.Duration   `mapstructure:"SHUTDOWN_TIMEOUT"`
+}
+
+// PasswordPolicy returns the configured password policy, unset lengths fall back to the defaults
+func (config Config) PasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:     config.PasswordMinLength,
+		MaxLength:     config.PasswordMaxLength,
+		RequireUpper:  config.PasswordRequireUpper,
+		RequireLower:  config.PasswordRequireLower,
+		RequireDigit:  config.PasswordRequireDigit,
+		RequireSymbol: config.PasswordRequireSymbol,
+	}
+	if policy.MinLength == 0 {
+		policy.MinLength = DefaultPasswordPolicy.MinLength
+	}
+	if policy.MaxLength == 0 {
+		policy.MaxLength = DefaultPasswordPolicy.MaxLength
+	}
+	return policy
+}
+
+// PasswordHasher returns a hasher with the configured Argon2id parameters
+func (config Config) PasswordHasher() *PasswordHasher {
+	return NewPasswordHasher(Argon2Params{
+		Memory:      config.Argon2Memory,
+		Iterations:  config.Argon2Iterations,
+		Parallelism: config.Argon2Parallelism,
+	})
+}
+
+// CurrencyAmounts maps a currency code to an amount in that currency.
+// In the config file it is written as a comma separated list, e.g. "USD:100000,EUR:90000".
+type CurrencyAmounts map[string]int64
+
+// Get returns the amount configured for the currency, if any
+func (amounts CurrencyAmounts) Get(currency string) (int64, bool) {
+	amount, ok := amounts[currency]
+	return amount, ok
+}
+
+// ParseCurrencyAmounts parses a comma separated list of CURRENCY:AMOUNT pairs
+func ParseCurrencyAmounts(value string) (CurrencyAmounts, error) {
+	amounts := CurrencyAmounts{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		fields := strings.SplitN(pair, ":", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid currency amount %q: must be CURRENCY:AMOUNT", pair)
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(fields[0]))
+		if !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid currency amount %q: unsupported currency %s", pair, currency)
+		}
+
+		amount, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid currency amount %q: amount must be a non-negative integer", pair)
+		}
+
+		amounts[currency] = amount
+	}
+
+	return amounts, nil
+}
+
+// stringToCurrencyAmountsHook lets viper decode CurrencyAmounts from a plain string
+func stringToCurrencyAmountsHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(CurrencyAmounts{}) {
+		return data, nil
+	}
+	return ParseCurrencyAmounts(data.(string))
+}
+
+// DefaultRateLimitKey is the RateLimits entry used for routes without a limit of their own
+const DefaultRateLimitKey = "default"
+
+// RateLimit is a token bucket refilled with Rate tokens per second and holding at most Burst tokens
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits maps a Gin route ("POST /transfers") or a gRPC method ("/pb.SimpleBank/LoginUser")
+// to its limit. In the config file it is written as a comma separated list of ROUTE=RATE:BURST,
+// e.g. "default=10:20,POST /users/login=0.2:5".
+type RateLimits map[string]RateLimit
+
+// ParseRateLimits parses a comma separated list of ROUTE=RATE:BURST entries
+func ParseRateLimits(value string) (RateLimits, error) {
+	limits := RateLimits{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: must be ROUTE=RATE:BURST", entry)
+		}
+		route := strings.TrimSpace(entry[:i])
+
+		fields := strings.SplitN(entry[i+1:], ":", 2)
+		if route == "" || len(fields) != 2 {
+			return nil, fmt.Errorf("invalid rate limit %q: must be ROUTE=RATE:BURST", entry)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: rate must be a positive number", entry)
+		}
+
+		burst, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil || burst <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", entry)
+		}
+
+		limits[route] = RateLimit{Rate: rate, Burst: burst}
+	}
+
+	return limits, nil
+}
+
+// stringToRateLimitsHook lets viper decode RateLimits from a plain string
+func stringToRateLimitsHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(RateLimits{}) {
+		return data, nil
+	}
+	return ParseRateLimits(data.(string))
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -30,7 +220,12 @@ func LoadConfig(path string) (config Config, err error) {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	err = viper.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToCurrencyAmountsHook,
+		stringToRateLimitsHook,
+	)))
 	return
 
 }
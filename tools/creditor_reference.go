@@ -0,0 +1,33 @@
+package tools
+
+import "strings"
+
+// Creditor references of ISO 11649 are made of the prefix "RF", two check digits and up to
+// 21 letters or digits chosen by the creditor. Like account numbers, they are valid under
+// ISO 7064 mod-97 once the prefix and the check digits are moved to the end.
+const (
+	CreditorReferencePrefix    = "RF"
+	creditorReferenceMinLength = 5
+	creditorReferenceMaxLength = 25
+)
+
+// NormalizeCreditorReference removes the spaces a creditor reference is often written with and upper cases it
+func NormalizeCreditorReference(reference string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(reference), ""))
+}
+
+// IsValidCreditorReference returns true if the normalized creditor reference has the expected format and check digits
+func IsValidCreditorReference(reference string) bool {
+	if len(reference) < creditorReferenceMinLength || len(reference) > creditorReferenceMaxLength ||
+		!strings.HasPrefix(reference, CreditorReferencePrefix) {
+		return false
+	}
+
+	for _, c := range reference[2:4] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return mod97(reference[4:]+reference[:4]) == 1
+}
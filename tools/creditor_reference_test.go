@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidCreditorReference(t *testing.T) {
+	for _, reference := range []string{
+		// examples of ISO 11649
+		"RF18 5390 0754 7034",
+		"rf18 5390 0754 7034",
+		"RF712348231",
+		"RF18000000000539007547034",
+	} {
+		require.True(t, IsValidCreditorReference(NormalizeCreditorReference(reference)), reference)
+	}
+
+	for _, reference := range []string{
+		"",
+		"RF18",
+		"RF19 5390 0754 7034",
+		"RF18 5390 0754 7043",
+		"XX18 5390 0754 7034",
+		"RFAB 5390 0754 7034",
+		"RF18 5390-0754-7034",
+		"RF180000000000539007547034",
+	} {
+		require.False(t, IsValidCreditorReference(NormalizeCreditorReference(reference)), reference)
+	}
+}
@@ -1,21 +1,165 @@
 package tools
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword returns the bcrypt hash of the password
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+const argon2idPrefix = "$argon2id$"
+
+// ErrMismatchedHashAndPassword is returned by CheckPassword when the password doesn't match the hash
+var ErrMismatchedHashAndPassword = errors.New("hashed password is not the hash of the given password")
+
+// Argon2Params are the cost parameters of the Argon2id hash
+type Argon2Params struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the second recommended option of RFC 9106
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher hashes passwords with Argon2id and encodes them as PHC strings
+type PasswordHasher struct {
+	params Argon2Params
+}
+
+// NewPasswordHasher creates a new PasswordHasher, zero params fall back to the defaults
+func NewPasswordHasher(params Argon2Params) *PasswordHasher {
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2Params.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2Params.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2Params.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2Params.KeyLength
+	}
+	return &PasswordHasher{params: params}
+}
+
+var defaultHasher = NewPasswordHasher(DefaultArgon2Params)
+
+// Hash returns the Argon2id hash of the password in PHC string format
+func (hasher *PasswordHasher) Hash(password string) (string, error) {
+	p := hasher.params
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashedPassword), nil
+
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p.Memory,
+		p.Iterations,
+		p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// NeedsRehash reports whether the hash was made with bcrypt or with Argon2id parameters
+// other than the hasher's, in which case it should be replaced on the next successful login.
+func (hasher *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	params, _, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != hasher.params.Memory ||
+		params.Iterations != hasher.params.Iterations ||
+		params.Parallelism != hasher.params.Parallelism ||
+		uint32(len(key)) != hasher.params.KeyLength
+}
+
+// HashPassword returns the Argon2id hash of the password using the default parameters
+func HashPassword(password string) (string, error) {
+	return defaultHasher.Hash(password)
 }
 
-// CheckPassword checks if the provided password is correct or not
+// CheckPassword checks if the provided password is correct or not.
+// It accepts both Argon2id PHC strings and legacy bcrypt hashes.
 func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return ErrMismatchedHashAndPassword
+		}
+		return err
+	}
+
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+func decodeArgon2id(hashedPassword string) (params Argon2Params, salt []byte, key []byte, err error) {
+	// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+	fields := strings.Split(hashedPassword, "$")
+	if len(fields) != 6 || fields[1] != "argon2id" {
+		err = errors.New("invalid argon2id hash format")
+		return
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(fields[2], "v=%d", &version); err != nil {
+		err = fmt.Errorf("invalid argon2id hash version: %w", err)
+		return
+	}
+	if version != argon2.Version {
+		err = fmt.Errorf("unsupported argon2 version %d", version)
+		return
+	}
+
+	if _, err = fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		err = fmt.Errorf("invalid argon2id hash parameters: %w", err)
+		return
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(fields[4]); err != nil {
+		err = fmt.Errorf("invalid argon2id salt: %w", err)
+		return
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(fields[5]); err != nil {
+		err = fmt.Errorf("invalid argon2id key: %w", err)
+		return
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return
 }
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = func() map[string]bool {
+	passwords := map[string]bool{}
+	for _, password := range strings.Fields(commonPasswordList) {
+		passwords[strings.ToLower(password)] = true
+	}
+	return passwords
+}()
+
+// PasswordPolicy describes the rules a new password must follow
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy is used for the limits that are not configured
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength: 6,
+	MaxLength: 100,
+}
+
+// IsCommonPassword returns true if the password is on the built-in denylist
+func IsCommonPassword(password string) bool {
+	return commonPasswords[strings.ToLower(password)]
+}
+
+// Validate checks the password against the policy
+func (policy PasswordPolicy) Validate(password string) error {
+	n := len(password)
+	if n < policy.MinLength || n > policy.MaxLength {
+		return fmt.Errorf("must contain from %d-%d characters", policy.MinLength, policy.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if policy.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("must contain %s", strings.Join(missing, ", "))
+	}
+
+	if IsCommonPassword(password) {
+		return fmt.Errorf("is too common")
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -13,16 +14,70 @@ func TestPassword(t *testing.T) {
 	hashedPassword1, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword1)
+	require.True(t, strings.HasPrefix(hashedPassword1, "$argon2id$v=19$m=65536,t=3,p=4$"))
 
 	err = CheckPassword(password, hashedPassword1)
 	require.NoError(t, err)
 
 	wrongPassword := RandomString(6)
 	err = CheckPassword(wrongPassword, hashedPassword1)
-	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+	require.EqualError(t, err, ErrMismatchedHashAndPassword.Error())
 
 	hashedPassword2, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+func TestCheckBcryptPassword(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	err = CheckPassword(password, string(hashedPassword))
+	require.NoError(t, err)
+
+	err = CheckPassword(RandomString(6), string(hashedPassword))
+	require.EqualError(t, err, ErrMismatchedHashAndPassword.Error())
+}
+
+func TestNeedsRehash(t *testing.T) {
+	password := RandomString(6)
+
+	hasher := NewPasswordHasher(Argon2Params{Memory: 16 * 1024, Iterations: 1, Parallelism: 1})
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.NoError(t, CheckPassword(password, hashedPassword))
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+
+	// stronger parameters make the old hash outdated
+	stronger := NewPasswordHasher(Argon2Params{Memory: 32 * 1024, Iterations: 2, Parallelism: 1})
+	require.True(t, stronger.NeedsRehash(hashedPassword))
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.True(t, hasher.NeedsRehash(string(bcryptHash)))
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:    10,
+		MaxLength:    20,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+	}
+
+	require.NoError(t, policy.Validate("Correct1Horse"))
+	require.EqualError(t, policy.Validate("Short1"), "must contain from 10-20 characters")
+	require.EqualError(t, policy.Validate("alllowercase1"), "must contain an uppercase letter")
+	require.EqualError(t, policy.Validate("NoDigitsHere"), "must contain a digit")
+
+	symbolPolicy := PasswordPolicy{MinLength: 6, MaxLength: 100, RequireSymbol: true}
+	require.EqualError(t, symbolPolicy.Validate("abcdefg"), "must contain a symbol")
+	require.NoError(t, symbolPolicy.Validate("abc-defg"))
+
+	require.EqualError(t, DefaultPasswordPolicy.Validate("Password1"), "is too common")
+	require.EqualError(t, DefaultPasswordPolicy.Validate("qwerty"), "is too common")
+}
@@ -50,3 +50,9 @@ func RandomCurrency() string {
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
+
+// MistypedAccountNumber changes the last digit of the account number, which always breaks its check digits
+func MistypedAccountNumber(number string) string {
+	last := number[len(number)-1]
+	return number[:len(number)-1] + string('0'+(last-'0'+1)%10)
+}
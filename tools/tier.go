@@ -0,0 +1,16 @@
+package tools
+
+// Constants for all customer tiers, they select the fee schedule of the transfers of a user
+const (
+	StandardTier = "standard"
+	PremiumTier  = "premium"
+)
+
+// IsSupportedTier returns true if the tier is supported
+func IsSupportedTier(tier string) bool {
+	switch tier {
+	case StandardTier, PremiumTier:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSetup(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+	require.Equal(t, provider, otel.GetTracerProvider())
+
+	fields := otel.GetTextMapPropagator().Fields()
+	require.Contains(t, fields, "traceparent")
+
+	shutdown, err = Setup(context.Background(), Config{Exporter: ExporterStdout})
+	require.NoError(t, err)
+	require.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Exporter: "zipkin"})
+	require.EqualError(t, err, `unsupported tracing exporter "zipkin"`)
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+}